@@ -0,0 +1,346 @@
+package greq
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gookit/goutil/netutil/httpreq"
+)
+
+// ErrNoEndpoints the Balancer has no endpoints to send the request.
+var ErrNoEndpoints = errors.New("greq: no endpoints")
+
+// DefaultHealthCheckInterval the default interval of the Balancer.StartHealthCheck
+var DefaultHealthCheckInterval = 10 * time.Second
+
+// the placeholder host of the request URL, it's replaced by the picked endpoint on each attempt.
+const endpointsHost = "endpoints.greq.invalid"
+
+// LBStrategy the load balancing strategy for pick an endpoint
+type LBStrategy uint8
+
+// the load balancing strategies
+const (
+	// RoundRobin pick the endpoints in turn
+	RoundRobin LBStrategy = iota
+	// Random pick an endpoint randomly
+	Random
+	// LeastInFlight pick the endpoint with the least in-flight requests
+	LeastInFlight
+	// Weighted pick an endpoint randomly by the weights. see Balancer.AddEndpoint
+	Weighted
+)
+
+// Endpoint is a base URL of the Balancer
+type Endpoint struct {
+	url    string
+	weight int
+	// in-flight requests, until the response body closed
+	inFlight atomic.Int64
+
+	// below fields are guarded by Balancer.mu
+	fails int
+	// ejected by passive check until the time
+	ejectUntil time.Time
+	// marked down by active health check
+	down bool
+}
+
+// URL get the base URL of the endpoint
+func (ep *Endpoint) URL() string { return ep.url }
+
+// Weight get the weight of the endpoint
+func (ep *Endpoint) Weight() int { return ep.weight }
+
+// InFlight get the in-flight requests count of the endpoint
+func (ep *Endpoint) InFlight() int64 { return ep.inFlight.Load() }
+
+// Balancer distribute the requests to multiple base URLs(endpoints).
+//
+// The endpoint is ejected after MaxFails consecutive failures(error or status >= 500),
+// and retries are sent to a different endpoint if possible. It can be shared by multiple clients.
+//
+// Usage:
+//
+//	b := greq.NewBalancer(greq.LeastInFlight, "http://10.0.0.1:8080", "http://10.0.0.2:8080")
+//	h := greq.New().WithBalancer(b).WithMaxRetries(2)
+//
+//	resp, err := h.GetDo("/users")
+//	fmt.Println(resp.Endpoint)
+type Balancer struct {
+	// Strategy for pick an endpoint. default is RoundRobin
+	Strategy LBStrategy
+	// MaxFails consecutive failures to eject an endpoint. <= 0: never eject
+	MaxFails int
+	// EjectTime the duration of an ejection.
+	EjectTime time.Duration
+
+	mu        sync.Mutex
+	endpoints []*Endpoint
+	next      int
+}
+
+// NewBalancer create a Balancer, an endpoint is ejected 30s after 3 consecutive failures.
+func NewBalancer(strategy LBStrategy, urls ...string) *Balancer {
+	b := &Balancer{Strategy: strategy, MaxFails: 3, EjectTime: 30 * time.Second}
+	for _, u := range urls {
+		b.AddEndpoint(u, 1)
+	}
+	return b
+}
+
+// AddEndpoint add an endpoint with the weight, the weight is used by the Weighted strategy.
+func (b *Balancer) AddEndpoint(baseURL string, weight int) *Balancer {
+	if weight <= 0 {
+		weight = 1
+	}
+
+	b.mu.Lock()
+	b.endpoints = append(b.endpoints, &Endpoint{url: strings.TrimRight(baseURL, "/"), weight: weight})
+	b.mu.Unlock()
+	return b
+}
+
+// Endpoints get all endpoints
+func (b *Balancer) Endpoints() []*Endpoint {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]*Endpoint(nil), b.endpoints...)
+}
+
+// Healthy check the endpoint is not ejected or marked down.
+func (b *Balancer) Healthy(ep *Endpoint) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return ep.healthy(time.Now())
+}
+
+func (ep *Endpoint) healthy(now time.Time) bool {
+	return !ep.down && !now.Before(ep.ejectUntil)
+}
+
+// pick an endpoint, prefer the healthy endpoints not in the excludes.
+func (b *Balancer) pick(excludes []*Endpoint) *Endpoint {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	var cands, fallback []*Endpoint
+	for _, ep := range b.endpoints {
+		if !ep.healthy(now) {
+			continue
+		}
+		fallback = append(fallback, ep)
+		if !containsEndpoint(excludes, ep) {
+			cands = append(cands, ep)
+		}
+	}
+	if len(cands) == 0 {
+		cands = fallback
+	}
+	// all ejected, try them all instead of fail directly
+	if len(cands) == 0 {
+		cands = b.endpoints
+	}
+	if len(cands) == 0 {
+		return nil
+	}
+
+	switch b.Strategy {
+	case Random:
+		return cands[rand.IntN(len(cands))]
+	case LeastInFlight:
+		best := cands[0]
+		for _, ep := range cands[1:] {
+			if ep.InFlight() < best.InFlight() {
+				best = ep
+			}
+		}
+		return best
+	case Weighted:
+		total := 0
+		for _, ep := range cands {
+			total += ep.weight
+		}
+		n := rand.IntN(total)
+		for _, ep := range cands {
+			if n -= ep.weight; n < 0 {
+				return ep
+			}
+		}
+	}
+
+	ep := cands[b.next%len(cands)]
+	b.next++
+	return ep
+}
+
+func containsEndpoint(eps []*Endpoint, ep *Endpoint) bool {
+	for _, e := range eps {
+		if e == ep {
+			return true
+		}
+	}
+	return false
+}
+
+// report the result of a request sent to the endpoint, for the passive ejection.
+func (b *Balancer) report(ep *Endpoint, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		ep.fails = 0
+		return
+	}
+
+	ep.fails++
+	if b.MaxFails > 0 && ep.fails >= b.MaxFails {
+		ep.fails = 0
+		ep.ejectUntil = time.Now().Add(b.EjectTime)
+	}
+}
+
+// StartHealthCheck probe the path of each endpoint by GET in the interval, the endpoint is marked down
+// if the probe failed(error or status is not 2xx), until a probe is succeeded. call stop() to stop it.
+//
+// doer is used for send the probe requests, nil for use the http.DefaultClient.
+// The interval is also the timeout of each probe, <= 0 for use the DefaultHealthCheckInterval.
+func (b *Balancer) StartHealthCheck(doer httpreq.Doer, path string, interval time.Duration) (stop func()) {
+	if doer == nil {
+		doer = http.DefaultClient
+	}
+	if interval <= 0 {
+		interval = DefaultHealthCheckInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			b.probeAll(ctx, doer, path, interval)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return cancel
+}
+
+func (b *Balancer) probeAll(ctx context.Context, doer httpreq.Doer, path string, timeout time.Duration) {
+	var wg sync.WaitGroup
+	for _, ep := range b.Endpoints() {
+		wg.Add(1)
+		go func(ep *Endpoint) {
+			defer wg.Done()
+			ok := probe(ctx, doer, ep.url+path, timeout)
+			if ctx.Err() != nil {
+				return
+			}
+
+			b.mu.Lock()
+			ep.down = !ok
+			if ok {
+				ep.fails, ep.ejectUntil = 0, time.Time{}
+			}
+			b.mu.Unlock()
+		}(ep)
+	}
+	wg.Wait()
+}
+
+func probe(ctx context.Context, doer httpreq.Doer, target string, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := doer.Do(req)
+	if err != nil {
+		return false
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
+
+// WithEndpoints send the requests to the base URLs by round-robin, instead of the BaseURL.
+// see WithBalancer for more strategies and options.
+//
+// Usage:
+//
+//	h := greq.New().WithEndpoints("http://10.0.0.1:8080", "http://10.0.0.2:8080").WithMaxRetries(1)
+func (h *Client) WithEndpoints(urls ...string) *Client {
+	return h.WithBalancer(NewBalancer(RoundRobin, urls...))
+}
+
+// WithBalancer send the requests to the endpoints of the balancer, instead of the BaseURL. nil for remove it.
+//
+// Only the requests with relative URL are balanced, Response.Endpoint is the endpoint served the request.
+func (h *Client) WithBalancer(b *Balancer) *Client {
+	h.balancer = b
+	return h
+}
+
+// key for save the tried endpoints to ReqState
+type triedEndpointsKey struct{}
+
+// bindEndpoint pick an endpoint for the request attempt, returns a shallow copy of the request with the endpoint URL.
+// The origin request keeps the placeholder URL for retries.
+func (h *Client) bindEndpoint(req *http.Request, st *ReqState) (*Endpoint, *http.Request, error) {
+	tried, _ := st.Value(triedEndpointsKey{}).([]*Endpoint)
+	ep := h.balancer.pick(tried)
+	if ep == nil {
+		return nil, nil, ErrNoEndpoints
+	}
+	st.SetValue(triedEndpointsKey{}, append(tried, ep))
+
+	base, err := url.Parse(ep.url)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	u := *req.URL
+	u.Scheme, u.Host, u.User = base.Scheme, base.Host, base.User
+	u.Path = base.Path + req.URL.Path
+	if req.URL.RawPath != "" {
+		u.RawPath = base.EscapedPath() + req.URL.RawPath
+	}
+
+	r := req.WithContext(req.Context())
+	r.URL, r.Host = &u, ""
+	return ep, r, nil
+}
+
+// endpointDone report the result to the balancer, and count the in-flight until the body closed.
+func (h *Client) endpointDone(ep *Endpoint, resp *Response, err error) {
+	failed := err != nil || resp.StatusCode >= 500
+	// not count the cancel by caller
+	if !errors.Is(err, context.Canceled) {
+		h.balancer.report(ep, failed)
+	}
+
+	if resp != nil {
+		resp.Endpoint = ep.url
+	}
+	if resp == nil || resp.Body == nil {
+		ep.inFlight.Add(-1)
+		return
+	}
+
+	var once sync.Once
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: func() {
+		once.Do(func() { ep.inFlight.Add(-1) })
+	}}
+}
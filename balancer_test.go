@@ -0,0 +1,166 @@
+package greq_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq"
+)
+
+// newEndpointServer respond the name and the request path, the status can be changed.
+func newEndpointServer(t *testing.T, name string) (*httptest.Server, *atomic.Int32) {
+	var status atomic.Int32
+	status.Store(200)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+		_, _ = w.Write([]byte(name + ":" + r.URL.RequestURI()))
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &status
+}
+
+func TestClient_WithEndpoints(t *testing.T) {
+	s1, _ := newEndpointServer(t, "s1")
+	s2, _ := newEndpointServer(t, "s2")
+
+	client := greq.New().WithEndpoints(s1.URL+"/", s2.URL+"/api")
+	var got []string
+	for i := 0; i < 4; i++ {
+		resp, err := client.GetDo("/users?id=1")
+		assert.NoErr(t, err)
+		got = append(got, resp.BodyString())
+		if i%2 == 0 {
+			assert.Eq(t, s1.URL, resp.Endpoint)
+		} else {
+			assert.Eq(t, s2.URL+"/api", resp.Endpoint)
+		}
+	}
+	assert.Eq(t, []string{"s1:/users?id=1", "s2:/api/users?id=1", "s1:/users?id=1", "s2:/api/users?id=1"}, got)
+
+	// absolute URL is not balanced
+	resp, err := client.GetDo(s2.URL + "/abs")
+	assert.NoErr(t, err)
+	assert.Eq(t, "s2:/abs", resp.BodyString())
+	assert.Empty(t, resp.Endpoint)
+
+	// sub client shares the balancer
+	resp, err = client.Sub().GetDo("/sub")
+	assert.NoErr(t, err)
+	assert.Eq(t, "s1:/sub", resp.BodyString())
+
+	_, err = greq.New().WithEndpoints().GetDo("/none")
+	assert.ErrIs(t, err, greq.ErrNoEndpoints)
+}
+
+func TestBalancer_failover(t *testing.T) {
+	s1, status1 := newEndpointServer(t, "s1")
+	s2, _ := newEndpointServer(t, "s2")
+	status1.Store(503)
+
+	b := greq.NewBalancer(greq.RoundRobin, s1.URL, s2.URL)
+	b.MaxFails = 2
+	client := greq.New().WithBalancer(b).WithMaxRetries(1)
+
+	// retry on the other endpoint
+	for i := 0; i < 2; i++ {
+		resp, err := client.GetDo("/retry")
+		assert.NoErr(t, err)
+		assert.Eq(t, "s2:/retry", resp.BodyString())
+	}
+
+	// s1 is ejected after 2 failures
+	eps := b.Endpoints()
+	assert.False(t, b.Healthy(eps[0]))
+	assert.True(t, b.Healthy(eps[1]))
+	for i := 0; i < 3; i++ {
+		resp, err := client.GetDo("/ejected")
+		assert.NoErr(t, err)
+		assert.Eq(t, s2.URL, resp.Endpoint)
+	}
+
+	// all ejected, still try them
+	b = greq.NewBalancer(greq.RoundRobin, s1.URL)
+	b.MaxFails = 1
+	client = greq.New().WithBalancer(b)
+	_, err := client.GetDo("/fail")
+	assert.NoErr(t, err)
+	assert.False(t, b.Healthy(b.Endpoints()[0]))
+
+	status1.Store(200)
+	resp, err := client.GetDo("/ok")
+	assert.NoErr(t, err)
+	assert.Eq(t, "s1:/ok", resp.BodyString())
+}
+
+func TestBalancer_strategies(t *testing.T) {
+	s1, _ := newEndpointServer(t, "s1")
+	s2, _ := newEndpointServer(t, "s2")
+
+	// least in-flight: the body of s1 is not closed
+	client := greq.New().WithBalancer(greq.NewBalancer(greq.LeastInFlight, s1.URL, s2.URL))
+	resp1, err := client.GetDo("/a")
+	assert.NoErr(t, err)
+	assert.Eq(t, s1.URL, resp1.Endpoint)
+	for i := 0; i < 3; i++ {
+		resp, err := client.GetDo("/b")
+		assert.NoErr(t, err)
+		assert.Eq(t, s2.URL, resp.Endpoint)
+		resp.QuietCloseBody()
+	}
+	resp1.QuietCloseBody()
+
+	// weighted
+	b := greq.NewBalancer(greq.Weighted).AddEndpoint(s1.URL, 9).AddEndpoint(s2.URL, 1)
+	client = greq.New().WithBalancer(b)
+	counts := map[string]int{}
+	for i := 0; i < 200; i++ {
+		resp, err := client.GetDo("/w")
+		assert.NoErr(t, err)
+		counts[resp.Endpoint]++
+		resp.QuietCloseBody()
+	}
+	assert.Gt(t, counts[s1.URL], counts[s2.URL]*2)
+	assert.Eq(t, 0, int(b.Endpoints()[0].InFlight()))
+
+	// random
+	client = greq.New().WithBalancer(greq.NewBalancer(greq.Random, s1.URL, s2.URL))
+	resp, err := client.GetDo("/r")
+	assert.NoErr(t, err)
+	assert.NotEmpty(t, resp.Endpoint)
+}
+
+func TestBalancer_StartHealthCheck(t *testing.T) {
+	s1, status1 := newEndpointServer(t, "s1")
+	s2, _ := newEndpointServer(t, "s2")
+	status1.Store(500)
+
+	b := greq.NewBalancer(greq.RoundRobin, s1.URL, s2.URL)
+	stop := b.StartHealthCheck(nil, "/health", 20*time.Millisecond)
+	defer stop()
+
+	time.Sleep(50 * time.Millisecond)
+	client := greq.New().WithBalancer(b)
+	for i := 0; i < 3; i++ {
+		resp, err := client.GetDo("/hc")
+		assert.NoErr(t, err)
+		assert.Eq(t, "s2:/hc", resp.BodyString())
+	}
+
+	// recovered
+	status1.Store(200)
+	time.Sleep(50 * time.Millisecond)
+	assert.True(t, b.Healthy(b.Endpoints()[0]))
+
+	// use the default interval, the first probe runs immediately
+	status1.Store(500)
+	b = greq.NewBalancer(greq.RoundRobin, s1.URL, s2.URL)
+	stop2 := b.StartHealthCheck(nil, "/health", 0)
+	defer stop2()
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, b.Healthy(b.Endpoints()[0]))
+	assert.True(t, b.Healthy(b.Endpoints()[1]))
+}
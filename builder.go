@@ -16,6 +16,7 @@ import (
 	"github.com/gookit/goutil/netutil/httpreq"
 	"github.com/gookit/goutil/strutil"
 	"github.com/gookit/goutil/x/basefn"
+	"github.com/gookit/greq/ext/httpfile"
 	"github.com/gookit/greq/internal/bodyprovider"
 )
 
@@ -365,6 +366,21 @@ func (b *Builder) Build(method, pathURL string) (*http.Request, error) {
 	return cli.NewRequestWithOptions(pathURL, b.Options)
 }
 
+// ToHTTPRequest build the request and convert it to an .http file request.
+//
+// Usage:
+//
+//	hr, err := client.Post("/users").JSONBody(data).ToHTTPRequest()
+//	hr.Name = "create user"
+//	fmt.Println(hr.String()) // canonical .http text
+func (b *Builder) ToHTTPRequest() (*httpfile.HTTPRequest, error) {
+	r, err := b.Build(b.Method, b.pathURL)
+	if err != nil {
+		return nil, err
+	}
+	return httpfile.NewRequestFromHTTP(r)
+}
+
 // String request to string.
 func (b *Builder) String() string {
 	r, err := b.Build(b.Method, b.pathURL)
@@ -505,8 +521,15 @@ type Options struct {
 
 	// EncodeJSON req body
 	EncodeJSON bool
-	// Timeout unit: ms
+	// GzipBody compress the request body by gzip, only when the body size >= GzipMinSize
+	GzipBody    bool
+	GzipMinSize int
+	// MaxBodySize max response body size, override the Client.MaxResponseBytes
+	MaxBodySize int64
+	// Timeout unit: ms. <= 0: use the Client.Timeout
 	Timeout int
+	// NoTimeout don't apply the Client.Timeout, for the long-lived streams. eg: SSE
+	NoTimeout bool
 	// TCancelFn will auto set it on Timeout > 0
 	TCancelFn context.CancelFunc
 	// Context for request
@@ -518,6 +541,10 @@ type Options struct {
 	MaxRetries   int
 	RetryDelay   int
 	RetryChecker RetryChecker
+	// Hedging configuration, override the Client.HedgeDelay and Client.MaxHedges.
+	// HedgeDelay < 0 for disable hedging.
+	HedgeDelay time.Duration
+	MaxHedges  int
 }
 
 // OptionFn for config request options
@@ -621,6 +648,38 @@ func WithTimeout(timeoutMs int) OptionFn {
 	}
 }
 
+// WithNoTimeout don't apply the Client.Timeout to the request, for the long-lived streams.
+// The Timeout set by WithTimeout is still applied.
+func WithNoTimeout() OptionFn {
+	return func(opt *Options) {
+		opt.NoTimeout = true
+	}
+}
+
+// WithGzipBody compress the request body by gzip, only when the body size >= minSize.
+//
+// NOTE: only the body with known size will be compressed. eg: string, []byte, url.Values
+func WithGzipBody(minSize int) OptionFn {
+	return func(opt *Options) {
+		opt.GzipBody = true
+		opt.GzipMinSize = minSize
+	}
+}
+
+// WithMaxBodySize set the max response body size, read more will return ErrBodyTooLarge.
+func WithMaxBodySize(n int64) OptionFn {
+	return func(opt *Options) {
+		opt.MaxBodySize = n
+	}
+}
+
+// WithContext set context for the request
+func WithContext(ctx context.Context) OptionFn {
+	return func(opt *Options) {
+		opt.Context = ctx
+	}
+}
+
 // WithRetry set retry configuration for the request
 func WithRetry(maxRetries, retryDelay int, checker RetryChecker) OptionFn {
 	return func(opt *Options) {
@@ -650,3 +709,11 @@ func WithRetryChecker(checker RetryChecker) OptionFn {
 		opt.RetryChecker = checker
 	}
 }
+
+// WithHedging set hedging configuration for the request, delay < 0 for disable it. see Client.WithHedging
+func WithHedging(delay time.Duration, maxExtra int) OptionFn {
+	return func(opt *Options) {
+		opt.HedgeDelay = delay
+		opt.MaxHedges = maxExtra
+	}
+}
@@ -1,12 +1,15 @@
 package greq_test
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gookit/goutil/testutil/assert"
 	"github.com/gookit/greq"
@@ -246,6 +249,22 @@ func TestBuilder_String(t *testing.T) {
 	assert.Contains(t, str, "/test")
 	assert.Contains(t, str, "X-Custom")
 }
+
+func TestBuilder_ToHTTPRequest(t *testing.T) {
+	hr, err := greq.New("https://example.com").
+		Post("/users").
+		AddHeader("X-Custom", "value").
+		JSONBody(map[string]string{"name": "inhere"}).
+		ToHTTPRequest()
+	assert.NoErr(t, err)
+
+	hr.Name = "create user"
+	str := hr.String()
+	assert.StrContains(t, str, "### create user\nPOST https://example.com/users\n")
+	assert.StrContains(t, str, "X-Custom: value\n")
+	assert.StrContains(t, str, "\n\n{\"name\":\"inhere\"}\n")
+}
+
 func TestBodyProvider_Interface(t *testing.T) {
 	// Test that BodyProvider interface is properly defined
 	// Using the actual constructor functions from builder.go
@@ -461,4 +480,21 @@ func TestWithTimeout(t *testing.T) {
 	opt := &greq.Options{}
 	fn(opt)
 	assert.Equal(t, 5000, opt.Timeout)
-}
\ No newline at end of file
+}
+
+func TestWithNoTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(80 * time.Millisecond)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	// the timeout <= 0 use the client timeout
+	client := greq.New(ts.URL).DefaultTimeout(30)
+	_, err := client.GetDo("/", greq.WithTimeout(-1))
+	assert.ErrIs(t, err, context.DeadlineExceeded)
+
+	resp, err := client.GetDo("/", greq.WithNoTimeout())
+	assert.NoErr(t, err)
+	assert.Eq(t, "ok", resp.BodyString())
+}
@@ -63,6 +63,32 @@ type Client struct {
 	RetryDelay int
 	// RetryChecker retry condition checker. default is nil (not retry)
 	RetryChecker RetryChecker
+	// HedgeDelay send a hedged request if no response within the delay. default is 0 (not hedge)
+	HedgeDelay time.Duration
+	// MaxHedges max hedged requests for each attempt. see WithHedging
+	MaxHedges int
+
+	// AcceptEncoding the advertised encodings, the response body will be decoded transparently.
+	// set it by WithCompression(). eg: "gzip, deflate"
+	AcceptEncoding string
+	// MaxCompressionRatio max ratio of decoded size to compressed size. default is 0 (not limit)
+	MaxCompressionRatio float64
+	// MaxResponseBytes max response body size, read more will return ErrBodyTooLarge.
+	// default is 0 (not limit)
+	MaxResponseBytes int64
+
+	// proxy selection rules. set by WithProxy, WithHostProxy, WithNoProxy
+	proxy *proxyRules
+	// error on config TLS by WithTLS, returned on send request
+	tlsErr error
+	// the pinned public keys verifier installed by WithTLS
+	pins *pinVerifier
+	// dialer for the network options. set by WithResolve, WithConnectTo, WithDNSCache, WithUnixSocket
+	dialer *netDialer
+	// network policy for the request destinations. set by WithNetPolicy
+	netPolicy *netPolicy
+	// balancer for the requests to multiple endpoints. set by WithEndpoints, WithBalancer
+	balancer *Balancer
 }
 
 // NewClient create a new http request client. alias of New()
@@ -118,8 +144,20 @@ func (h *Client) Sub() *Client {
 		MaxRetries:   h.MaxRetries,
 		RetryDelay:   h.RetryDelay,
 		RetryChecker: h.RetryChecker,
+		HedgeDelay:   h.HedgeDelay,
+		MaxHedges:    h.MaxHedges,
 		BeforeSend:   h.BeforeSend,
 		AfterSend:    h.AfterSend,
+		// compression and limits
+		AcceptEncoding:      h.AcceptEncoding,
+		MaxCompressionRatio: h.MaxCompressionRatio,
+		MaxResponseBytes:    h.MaxResponseBytes,
+		proxy:               h.proxy,
+		tlsErr:              h.tlsErr,
+		pins:                h.pins,
+		dialer:              h.dialer,
+		netPolicy:           h.netPolicy,
+		balancer:            h.balancer,
 	}
 	sub.wrapMiddlewares() // build the sub-client's own handler chain
 	return sub
@@ -505,6 +543,8 @@ func (h *Client) MustSend(method, url string, optFns ...OptionFn) *Response {
 //	Accept: */*
 //
 //	<content>
+//
+// The body is sent as text, the file include lines(eg: `< ./payload.json`) are not resolved.
 func (h *Client) SendRaw(raw string, varMp map[string]string) (*Response, error) {
 	rawReq, err := httpfile.ParseRequest(raw)
 	if err != nil {
@@ -521,8 +561,8 @@ func (h *Client) SendRaw(raw string, varMp map[string]string) (*Response, error)
 	}
 
 	// apply headers
-	if len(rawReq.Headers) > 0 {
-		httpreq.SetHeaderMap(req, rawReq.Headers)
+	for _, hd := range rawReq.Headers {
+		req.Header.Add(hd.Name, hd.Value)
 	}
 	// apply default content type
 	if len(h.ContentType) > 0 && req.Header.Get("Content-Type") == "" {
@@ -563,6 +603,9 @@ type retryCfg struct {
 	maxRetries int
 	retryDelay int
 	checker    RetryChecker
+	// hedging config
+	hedgeDelay time.Duration
+	maxHedges  int
 }
 
 // effectiveRetryCfg resolves the per-request retry config, falling back to Client defaults.
@@ -571,6 +614,8 @@ func (h *Client) effectiveRetryCfg(opt *Options) retryCfg {
 		maxRetries: h.MaxRetries,
 		retryDelay: h.RetryDelay,
 		checker:    h.RetryChecker,
+		hedgeDelay: h.HedgeDelay,
+		maxHedges:  h.MaxHedges,
 	}
 	if opt != nil {
 		if opt.MaxRetries > 0 {
@@ -582,6 +627,9 @@ func (h *Client) effectiveRetryCfg(opt *Options) retryCfg {
 		if opt.RetryChecker != nil {
 			cfg.checker = opt.RetryChecker
 		}
+		if opt.HedgeDelay != 0 {
+			cfg.hedgeDelay, cfg.maxHedges = opt.HedgeDelay, opt.MaxHedges
+		}
 	}
 	return cfg
 }
@@ -589,17 +637,58 @@ func (h *Client) effectiveRetryCfg(opt *Options) retryCfg {
 // sendRequestWithRetry send request with retry logic.
 // h.handler is built in New/Sub/Middlewares; this hot path only reads it.
 func (h *Client) sendRequestWithRetry(req *http.Request, attempt int, cfg retryCfg) (*Response, error) {
+	if h.tlsErr != nil {
+		return nil, h.tlsErr
+	}
+
 	start := time.Now()
+	st := GetReqState(req)
+	if attempt == 0 || st == nil {
+		req, st = withReqState(req)
+	}
+	st.Attempt = attempt
+
+	// pick an endpoint for each attempt, the req keeps the placeholder URL for retries
+	sendReq := req
+	var ep *Endpoint
+	if h.balancer != nil && req.URL.Host == endpointsHost {
+		var err error
+		if ep, sendReq, err = h.bindEndpoint(req, st); err != nil {
+			return nil, err
+		}
+		ep.inFlight.Add(1)
+	}
+
+	// the socket path in host is not a valid Host header
+	if _, ok := unixSocketPath(sendReq.URL.Host); ok && sendReq.Host == sendReq.URL.Host {
+		sendReq.Host = "localhost"
+	}
+	if h.AcceptEncoding != "" && sendReq.Header.Get("Accept-Encoding") == "" {
+		sendReq.Header.Set("Accept-Encoding", h.AcceptEncoding)
+	}
 
 	// call before send.
 	if h.BeforeSend != nil {
-		if err := h.BeforeSend(req); err != nil {
+		if err := h.BeforeSend(sendReq); err != nil {
+			if ep != nil {
+				ep.inFlight.Add(-1)
+			}
 			return nil, fmt.Errorf("before send check failed: %w", err)
 		}
 	}
 
 	// do send by core handler
-	resp, err := h.handler(req)
+	var resp *Response
+	var err error
+	if cfg.hedgeDelay > 0 && cfg.maxHedges > 0 && isHedgeable(sendReq) {
+		// the endpoints are reported by each hedge
+		resp, err = h.sendHedged(req, sendReq, ep, st, cfg)
+	} else {
+		resp, err = h.handler(sendReq)
+		if ep != nil {
+			h.endpointDone(ep, resp, err)
+		}
+	}
 	if resp != nil {
 		resp.CostTime = time.Since(start).Milliseconds()
 	}
@@ -609,6 +698,10 @@ func (h *Client) sendRequestWithRetry(req *http.Request, attempt int, cfg retryC
 	}
 
 	if shouldRetry(resp, err, attempt, cfg) {
+		// release the connection and the endpoint of the failed attempt
+		if resp != nil {
+			resp.QuietCloseBody()
+		}
 		if cfg.retryDelay > 0 {
 			time.Sleep(time.Duration(cfg.retryDelay) * time.Millisecond)
 		}
@@ -640,9 +733,16 @@ func (h *Client) NewRequest(method, url string, optFns ...OptionFn) (*http.Reque
 }
 
 func (h *Client) buildFullURL(url string) string {
+	if fullURL, ok := unixSocketURL(url); ok {
+		return fullURL
+	}
+
 	fullURL := url
 	hasScheme := strings.HasPrefix(url, "http")
-	if len(h.BaseURL) > 0 {
+	if h.balancer != nil && !hasScheme {
+		// the endpoint is picked on send
+		fullURL = "http://" + endpointsHost + url
+	} else if len(h.BaseURL) > 0 {
 		if !hasScheme {
 			fullURL = h.BaseURL + url
 		} else if len(url) == 0 {
@@ -665,13 +765,16 @@ func (h *Client) NewRequestWithOptions(url string, opt *Options) (*http.Request,
 	}
 
 	// set default timeout if not set
-	if opt.Timeout <= 0 {
+	if opt.Timeout <= 0 && !opt.NoTimeout {
 		opt.Timeout = h.Timeout
 	}
 	// convert timeout to duration
 	if opt.Timeout > 0 {
 		ctx, opt.TCancelFn = context.WithTimeout(ctx, time.Duration(opt.Timeout)*time.Millisecond)
 	}
+	if opt.MaxBodySize > 0 {
+		ctx = withMaxBodySize(ctx, opt.MaxBodySize)
+	}
 
 	// append Query params
 	qm := opt.Query
@@ -726,6 +829,9 @@ func (h *Client) NewRequestWithOptions(url string, opt *Options) (*http.Request,
 		req.Header.Set(httpheader.ContentType, cType)
 	}
 
+	if opt.GzipBody && allowBody {
+		err = gzipRequestBody(req, opt.GzipMinSize)
+	}
 	return req, err
 }
 
@@ -113,6 +113,12 @@ Accept: */*
 	assert.Eq(t, "inhere", jsonData["name"])
 	assert.Eq(t, float64(25), jsonData["age"])
 	dump.P(resData)
+
+	// the file include line is sent as text
+	resp, err = greq.New(testBaseURL).SendRaw("POST /post\nContent-Type: text/plain\n\n< ./go.mod", nil)
+	assert.NoErr(t, err)
+	resData = testutil.ParseRespToReply(resp.Response)
+	assert.Eq(t, "< ./go.mod", resData.Body)
 }
 
 // TestClient_Retry_Config 测试重试配置
@@ -340,6 +346,36 @@ func TestClient_Retry_Delay(t *testing.T) {
 	assert.Eq(t, 3, attemptCount) // 应该重试了2次，总共3次请求
 }
 
+func TestClient_ReqState(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(500)
+	}))
+	defer ts.Close()
+
+	var attempts []int
+	var states []*greq.ReqState
+	client := greq.New().WithMaxRetries(2).Use(greq.MiddleFunc(func(r *http.Request, next greq.HandleFunc) (*greq.Response, error) {
+		st := greq.GetReqState(r)
+		attempts = append(attempts, st.Attempt)
+		states = append(states, st)
+		if st.Attempt == 0 {
+			st.SetValue("first", "yes")
+		}
+		return next(r)
+	}))
+
+	_, err := client.GetDo(ts.URL)
+	assert.NoErr(t, err)
+	assert.Eq(t, []int{0, 1, 2}, attempts)
+	// shared by all attempts
+	assert.True(t, states[0] == states[2])
+	assert.Eq(t, "yes", states[2].Value("first"))
+	assert.Nil(t, states[0].Value("not-exists"))
+
+	req := httptest.NewRequest("GET", ts.URL, nil)
+	assert.Nil(t, greq.GetReqState(req))
+}
+
 func TestClient_String(t *testing.T) {
 	str := greq.New(testBaseURL).
 		UserAgent("some-cli/1.0").
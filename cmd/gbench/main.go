@@ -63,6 +63,7 @@ Example:
  - 1h: 1 hour;;z`)
 	cmd.StringVar(&benchOpts.data, "data", "", `data http request body for POST/PUT requests.
 Allow use <green>@filename</> to read data from file.
+Allow dynamic vars, evaluated on each request. eg: <green>${$uuid}</>, <green>${$randomInt(1,100)}</>
 	;;d`)
 	cmd.IntVar(&benchOpts.qpsLimit, "qps", 0, "rate limit for all, in queries per second (QPS);;q")
 	cmd.StringVar(&benchOpts.output, "output", "stdout", `Output file to write the results to.
@@ -85,6 +86,9 @@ If not specified, results are written to stdout.;;o`)
 
   # POST request with JSON data
   gbench -n 1000 -c 10 -m POST -d '{"key":"value"}' https://www.example.com
+
+  # POST request with dynamic vars
+  gbench -n 1000 -c 10 -m POST -d '{"id":"${$uuid}"}' https://www.example.com
 	`
 
 	cmd.AfterFlagParse = func(c *cflag.CFlags) bool {
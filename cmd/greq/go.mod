@@ -6,15 +6,18 @@ require (
 	github.com/gookit/cliui v0.2.1
 	github.com/gookit/goutil v0.7.5
 	github.com/gookit/greq v0.0.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/gookit/color v1.6.0 // indirect
 	github.com/xo/terminfo v0.0.0-20220910002029-abceb7e1c41e // indirect
+	golang.org/x/crypto v0.33.0 // indirect
 	golang.org/x/sync v0.11.0 // indirect
 	golang.org/x/sys v0.30.0 // indirect
 	golang.org/x/term v0.29.0 // indirect
 	golang.org/x/text v0.22.0 // indirect
+	software.sslmate.com/src/go-pkcs12 v0.5.0 // indirect
 )
 
 replace github.com/gookit/greq => ../..
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gookit/goutil/cflag"
+	"github.com/gookit/goutil/strutil"
+	"github.com/gookit/goutil/x/ccolor"
+	"github.com/gookit/greq"
+	"github.com/gookit/greq/ext/httpfile"
+	"gopkg.in/yaml.v3"
+)
+
+// subCommands run by: greq <name> [options] [args]
+//
+// The default command (greq [options] URL) is used when the first arg is not a sub-command.
+var subCommands = map[string]func(args []string){
+	"fmt":    runFmtCmd,
+	"import": runImportCmd,
+	"export": runExportCmd,
+	"ws":     runWSCmd,
+}
+
+// runSubCommand check and run the sub-command. returns false if not found.
+func runSubCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	fn, ok := subCommands[args[0]]
+	if ok {
+		fn(args[1:])
+	}
+	return ok
+}
+
+var fmtOpts = struct {
+	write bool
+	check bool
+}{}
+
+// runFmtCmd format .http file to canonical text
+func runFmtCmd(args []string) {
+	cmd := cflag.NewWith("greq fmt", Version, "Format the IDE .http request file to canonical text")
+	cmd.BoolVar(&fmtOpts.write, "write", false, "Write result to the source file instead of stdout;;w")
+	cmd.BoolVar(&fmtOpts.check, "check", false, "Exit with error if the file is not formatted;;c")
+	cmd.AddArg("file", "the .http file to format", true, nil)
+	cmd.Example = `
+  greq fmt api.http
+  greq fmt -w api.http
+`
+
+	cmd.Func = func(c *cflag.CFlags) error {
+		filePath := c.Arg("file").String()
+		hf, err := httpfile.ParseHTTPFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		formatted := hf.Format()
+		if fmtOpts.check {
+			if formatted != hf.Contents {
+				ccolor.Warnf("File is not formatted: %s\n", filePath)
+				os.Exit(1)
+			}
+			return nil
+		}
+
+		if fmtOpts.write {
+			if formatted == hf.Contents {
+				return nil
+			}
+			if err := os.WriteFile(filePath, []byte(formatted), 0644); err != nil {
+				return fmt.Errorf("write file failed: %v", err)
+			}
+			ccolor.Successf("Formatted: %s\n", filePath)
+			return nil
+		}
+
+		fmt.Print(formatted)
+		return nil
+	}
+	cmd.MustRun(args)
+}
+
+var importOpts = struct {
+	output  string
+	format  string
+	envName string
+}{}
+
+// runImportCmd import Postman collection or OpenAPI 3 document to .http file
+func runImportCmd(args []string) {
+	cmd := cflag.NewWith("greq import", Version, "Import Postman collection(v2.1) or OpenAPI 3 document to .http file")
+	cmd.StringVar(&importOpts.output, "output", "", `Output .http file, default print to stdout.
+The variables will be saved to the http-client.env.json in the dir of
+the output file, they are not saved if print to stdout;;o`)
+	cmd.StringVar(&importOpts.format, "format", "", "Input format: postman, openapi. default detect by contents;;f")
+	cmd.StringVar(&importOpts.envName, "env", httpfile.DefaultEnvName, "The env name for save variables;;e")
+	cmd.AddArg("file", "the Postman collection or OpenAPI file", true, nil)
+	cmd.Example = `
+  greq import collection.json
+  greq import -o api.http openapi.yaml
+`
+
+	cmd.Func = func(c *cflag.CFlags) error {
+		inFile := c.Arg("file").String()
+		data, err := os.ReadFile(inFile)
+		if err != nil {
+			return err
+		}
+
+		format := importOpts.format
+		if format == "" {
+			if format, err = detectImportFormat(data); err != nil {
+				return err
+			}
+		}
+
+		var hf *httpfile.HTTPFile
+		var vars map[string]string
+		switch format {
+		case "postman":
+			hf, vars, err = httpfile.ImportPostman(data)
+		case "openapi":
+			hf, vars, err = httpfile.ImportOpenAPI(data)
+		default:
+			return fmt.Errorf("unsupported import format %q", format)
+		}
+		if err != nil {
+			return err
+		}
+
+		if importOpts.output == "" {
+			fmt.Print(hf.Format())
+			// keep the stdout clean for the .http contents
+			if len(vars) > 0 {
+				fmt.Fprintf(os.Stderr, "%d variables are not saved, use --output to save them to the env file\n", len(vars))
+			}
+			return nil
+		}
+
+		if err := os.WriteFile(importOpts.output, []byte(hf.Format()), 0644); err != nil {
+			return fmt.Errorf("write file failed: %v", err)
+		}
+		ccolor.Successf("Imported %d requests to: %s\n", len(hf.Requests), importOpts.output)
+
+		if len(vars) == 0 {
+			return nil
+		}
+		envFile, err := saveEnvVars(filepath.Dir(importOpts.output), importOpts.envName, vars)
+		if err != nil {
+			return err
+		}
+		ccolor.Successf("Saved %d variables to env %q: %s\n", len(vars), importOpts.envName, envFile)
+		return nil
+	}
+	cmd.MustRun(args)
+}
+
+// detectImportFormat detect the input format by the top-level keys of the JSON or YAML document.
+//
+//   - "openapi" key: OpenAPI document
+//   - "info.schema" or "info._postman_id" key: Postman collection
+func detectImportFormat(data []byte) (string, error) {
+	// YAML is a superset of JSON, so both can be decoded by it
+	var doc struct {
+		OpenAPI any `yaml:"openapi"`
+		Info    struct {
+			Schema    any `yaml:"schema"`
+			PostmanID any `yaml:"_postman_id"`
+		} `yaml:"info"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err == nil {
+		switch {
+		case doc.OpenAPI != nil:
+			return "openapi", nil
+		case doc.Info.Schema != nil, doc.Info.PostmanID != nil:
+			return "postman", nil
+		}
+	}
+	return "", errors.New("cannot detect the import format, please set it by --format")
+}
+
+// saveEnvVars merge the variables to the env file in the dir, returns the env file path.
+func saveEnvVars(dir, envName string, vars map[string]string) (string, error) {
+	envFile := filepath.Join(dir, httpfile.DefaultEnvFile)
+	ef := make(httpfile.EnvFile)
+	if _, err := os.Stat(envFile); err == nil {
+		if ef, err = httpfile.LoadEnvFile(envFile); err != nil {
+			return "", err
+		}
+	}
+
+	if ef[envName] == nil {
+		ef[envName] = make(map[string]string, len(vars))
+	}
+	for key, val := range vars {
+		ef[envName][key] = val
+	}
+
+	if err := ef.Save(envFile); err != nil {
+		return "", fmt.Errorf("write env file failed: %v", err)
+	}
+	return envFile, nil
+}
+
+var exportOpts = struct {
+	output  string
+	name    string
+	envName string
+}{}
+
+// runExportCmd export .http file to Postman collection
+func runExportCmd(args []string) {
+	cmd := cflag.NewWith("greq export", Version, "Export .http file to Postman collection(v2.1)")
+	cmd.StringVar(&exportOpts.output, "output", "", "Output collection file, default print to stdout;;o")
+	cmd.StringVar(&exportOpts.name, "name", "", "The collection name, default is the file name;;n")
+	cmd.StringVar(&exportOpts.envName, "env", "", `Export variables of the env in http-client.env.json
+as collection variables;;e`)
+	cmd.AddArg("file", "the .http file to export", true, nil)
+	cmd.Example = `
+  greq export api.http
+  greq export -o collection.json --env dev api.http
+`
+
+	cmd.Func = func(c *cflag.CFlags) error {
+		filePath := c.Arg("file").String()
+		hf, err := httpfile.ParseHTTPFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		name := exportOpts.name
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+		}
+
+		var vars map[string]string
+		if exportOpts.envName != "" {
+			envFile := httpfile.FindEnvFile(filePath)
+			if envFile == "" {
+				return fmt.Errorf("env file %s not found in the dir of %s", httpfile.DefaultEnvFile, filePath)
+			}
+
+			ef, err := httpfile.LoadEnvFile(envFile)
+			if err != nil {
+				return err
+			}
+			vars = ef.Vars(exportOpts.envName)
+		}
+
+		bs, err := httpfile.ExportPostman(hf, name, vars).JSON()
+		if err != nil {
+			return err
+		}
+
+		if exportOpts.output == "" {
+			fmt.Println(string(bs))
+			return nil
+		}
+		if err := os.WriteFile(exportOpts.output, append(bs, '\n'), 0644); err != nil {
+			return fmt.Errorf("write file failed: %v", err)
+		}
+		ccolor.Successf("Exported %d requests to: %s\n", len(hf.Requests), exportOpts.output)
+		return nil
+	}
+	cmd.MustRun(args)
+}
+
+var wsOpts = struct {
+	headers   cflag.KVString
+	protocols string
+	compress  bool
+	insecure  bool
+	ping      time.Duration
+}{
+	headers: cflag.KVString{Sep: ":"},
+}
+
+// runWSCmd open an interactive WebSocket session: the stdin lines are sent as text messages,
+// the received messages are printed. Exit by Ctrl+C or EOF of stdin.
+func runWSCmd(args []string) {
+	cmd := cflag.NewWith("greq ws", Version, "Open an interactive WebSocket session")
+	cmd.Var(&wsOpts.headers, "header", `Custom HTTP header for the handshake, allow multi. eg: "Foo: bar";;H`)
+	cmd.StringVar(&wsOpts.protocols, "protocol", "", "The subprotocols to offer, multi split by comma;;p")
+	cmd.BoolVar(&wsOpts.compress, "compress", false, "Enable the permessage-deflate compression")
+	cmd.BoolVar(&wsOpts.insecure, "insecure", false, "Allow insecure SSL connections;;k")
+	cmd.DurationVar(&wsOpts.ping, "ping", 0, "Send ping frames in the interval to keep alive. eg: 30s")
+	cmd.AddArg("url", "the WebSocket URL, eg: ws://localhost:8080/echo", true, nil)
+	cmd.Example = `
+  greq ws ws://localhost:8080/echo
+  greq ws -H "Authorization: Bearer TOKEN" --compress wss://example.com/chat
+`
+
+	cmd.Func = func(c *cflag.CFlags) error {
+		client := greq.Std()
+		if wsOpts.insecure {
+			client.WithInsecureSkipVerify(true)
+		}
+
+		var optFns []greq.OptionFn
+		for k, v := range wsOpts.headers.Data() {
+			optFns = append(optFns, greq.WithHeader(k, v))
+		}
+		opts := []greq.WSOption{greq.WSRequestOptions(optFns...)}
+		if wsOpts.protocols != "" {
+			opts = append(opts, greq.WSSubprotocols(strutil.Split(wsOpts.protocols, ",")...))
+		}
+		if wsOpts.compress {
+			opts = append(opts, greq.WSCompression())
+		}
+		if wsOpts.ping > 0 {
+			opts = append(opts, greq.WSPingInterval(wsOpts.ping))
+		}
+
+		conn, err := client.WebSocket(c.Arg("url").String(), opts...)
+		if err != nil {
+			return err
+		}
+		ccolor.Successf("Connected to %s\n", c.Arg("url").String())
+		if p := conn.Subprotocol(); p != "" {
+			ccolor.Infof("Subprotocol: %s\n", p)
+		}
+		return wsSession(conn)
+	}
+	cmd.MustRun(args)
+}
+
+// wsSession send the stdin lines and print the received messages
+func wsSession(conn *greq.WSConn) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// close the connection on Ctrl+C or EOF of stdin, the main loop reads until the close frame
+	var closing atomic.Bool
+	closed := make(chan error, 1)
+	go func() {
+		lines := make(chan string)
+		go func() {
+			sc := bufio.NewScanner(os.Stdin)
+			for sc.Scan() {
+				lines <- sc.Text()
+			}
+			close(lines)
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+			case line, ok := <-lines:
+				if ok && conn.WriteText(line) == nil {
+					continue
+				}
+			}
+			closing.Store(true)
+			closed <- conn.Close()
+			return
+		}
+	}()
+
+	for {
+		typ, data, err := conn.ReadMessage()
+		if err != nil {
+			if closing.Load() {
+				return <-closed
+			}
+
+			var cerr *greq.WSCloseError
+			if errors.As(err, &cerr) {
+				ccolor.Warnf("Connection closed: %d %s\n", cerr.Code, cerr.Text)
+				return conn.Close()
+			}
+			_ = conn.Close()
+			return err
+		}
+
+		if typ == greq.WSText {
+			ccolor.Printf("<cyan>< </>%s\n", data)
+		} else {
+			ccolor.Printf("<cyan>< </>[binary %d bytes]\n", len(data))
+		}
+	}
+}
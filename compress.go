@@ -0,0 +1,214 @@
+package greq
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Decompressor create a reader to decode the response body of a content encoding.
+type Decompressor func(r io.Reader) (io.ReadCloser, error)
+
+// supported encodings in the preferred order of Accept-Encoding
+var encodingOrder = []string{"gzip", "deflate", "br", "zstd"}
+
+var (
+	decompMu      sync.RWMutex
+	decompressors = map[string]Decompressor{
+		"gzip":    func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+		"x-gzip":  func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+		"deflate": newDeflateReader,
+	}
+)
+
+// RegisterDecompressor register a decoder for the content encoding. eg: "br", "zstd"
+//
+// The core only supports gzip and deflate, register others to keep the core lean.
+//
+// Usage:
+//
+//	// github.com/andybalholm/brotli
+//	greq.RegisterDecompressor("br", func(r io.Reader) (io.ReadCloser, error) {
+//		return io.NopCloser(brotli.NewReader(r)), nil
+//	})
+func RegisterDecompressor(encoding string, fn Decompressor) {
+	encoding = strings.ToLower(encoding)
+	decompMu.Lock()
+	defer decompMu.Unlock()
+
+	decompressors[encoding] = fn
+	for _, enc := range encodingOrder {
+		if enc == encoding {
+			return
+		}
+	}
+	encodingOrder = append(encodingOrder, encoding)
+}
+
+func getDecompressor(encoding string) Decompressor {
+	decompMu.RLock()
+	defer decompMu.RUnlock()
+	return decompressors[encoding]
+}
+
+// SupportedEncodings get the supported content encodings, in the preferred order.
+func SupportedEncodings() []string {
+	decompMu.RLock()
+	defer decompMu.RUnlock()
+
+	var encs []string
+	for _, enc := range encodingOrder {
+		if _, ok := decompressors[enc]; ok {
+			encs = append(encs, enc)
+		}
+	}
+	return encs
+}
+
+// WithCompression advertise the encodings by Accept-Encoding header, and decode the response body transparently.
+//
+// If encodings is empty, will use all SupportedEncodings(). The encoding without decompressor will be ignored.
+func (h *Client) WithCompression(encodings ...string) *Client {
+	if len(encodings) == 0 {
+		encodings = SupportedEncodings()
+	}
+
+	var accepts []string
+	for _, enc := range encodings {
+		if getDecompressor(strings.ToLower(enc)) != nil {
+			accepts = append(accepts, strings.ToLower(enc))
+		}
+	}
+	h.AcceptEncoding = strings.Join(accepts, ", ")
+	return h
+}
+
+// decompressBody decode the response body by the Content-Encoding. the decoder is created on first read.
+//
+// Multiple encodings are decoded in the reverse order. Unsupported encodings will keep the body as is.
+// maxRatio > 0: check the ratio of decoded size to compressed size.
+func decompressBody(resp *http.Response, maxRatio float64) {
+	ce := resp.Header.Get("Content-Encoding")
+	if ce == "" || resp.Body == nil || resp.Body == http.NoBody {
+		return
+	}
+
+	encs := strings.Split(ce, ",")
+	decoders := make([]Decompressor, 0, len(encs))
+	for i := len(encs) - 1; i >= 0; i-- {
+		enc := strings.ToLower(strings.TrimSpace(encs[i]))
+		if enc == "identity" || enc == "" {
+			continue
+		}
+		fn := getDecompressor(enc)
+		if fn == nil {
+			return
+		}
+		decoders = append(decoders, fn)
+	}
+
+	if maxRatio > 0 {
+		in := &countReader{r: resp.Body}
+		ld := &lazyDecoder{body: resp.Body, src: in, decoders: decoders}
+		resp.Body = &ratioBody{ReadCloser: ld, in: in, max: maxRatio}
+	} else {
+		resp.Body = &lazyDecoder{body: resp.Body, src: resp.Body, decoders: decoders}
+	}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
+
+// lazyDecoder create the decoders on first read, so empty bodies(eg: HEAD) won't fail.
+type lazyDecoder struct {
+	body io.ReadCloser
+	// src for read the encoded data, it's the body or a wrapper of it.
+	src      io.Reader
+	decoders []Decompressor
+
+	r       io.Reader
+	err     error
+	closers []io.Closer
+}
+
+func (d *lazyDecoder) Read(p []byte) (int, error) {
+	if d.r == nil && d.err == nil {
+		d.init()
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+	return d.r.Read(p)
+}
+
+func (d *lazyDecoder) init() {
+	r := d.src
+	for _, fn := range d.decoders {
+		rc, err := fn(r)
+		if err != nil {
+			d.err = err
+			return
+		}
+		d.closers = append(d.closers, rc)
+		r = rc
+	}
+	d.r = r
+}
+
+func (d *lazyDecoder) Close() error {
+	for i := len(d.closers) - 1; i >= 0; i-- {
+		_ = d.closers[i].Close()
+	}
+	return d.body.Close()
+}
+
+// newDeflateReader "deflate" should be zlib format, but some servers send the raw deflate.
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	// zlib header: CM=8 and (CMF*256+FLG)%31 == 0
+	if len(head) == 2 && head[0]&0x0f == 8 && (uint16(head[0])<<8|uint16(head[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// gzipRequestBody compress the request body by gzip, if the body size is known and >= minSize.
+func gzipRequestBody(req *http.Request, minSize int) error {
+	if req.GetBody == nil || req.ContentLength <= 0 || req.ContentLength < int64(minSize) {
+		return nil
+	}
+	if req.Header.Get("Content-Encoding") != "" {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := io.Copy(zw, req.Body); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	_ = req.Body.Close()
+
+	bs := buf.Bytes()
+	req.Body = io.NopCloser(bytes.NewReader(bs))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(bs)), nil
+	}
+	req.ContentLength = int64(len(bs))
+	req.Header.Set("Content-Encoding", "gzip")
+	return nil
+}
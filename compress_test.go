@@ -0,0 +1,148 @@
+package greq_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq"
+)
+
+func gzipBytes(s string) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write([]byte(s))
+	_ = zw.Close()
+	return buf.Bytes()
+}
+
+func newEncodingServer(t *testing.T) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Accept-Encoding", r.Header.Get("Accept-Encoding"))
+		body := "hello " + r.URL.Path
+
+		var buf bytes.Buffer
+		switch enc := r.URL.Query().Get("enc"); enc {
+		case "gzip":
+			buf.Write(gzipBytes(body))
+		case "deflate":
+			zw := zlib.NewWriter(&buf)
+			_, _ = zw.Write([]byte(body))
+			_ = zw.Close()
+		case "raw-deflate":
+			enc = "deflate"
+			fw, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+			_, _ = fw.Write([]byte(body))
+			_ = fw.Close()
+		case "gzip, x-b64":
+			buf.WriteString(base64.StdEncoding.EncodeToString(gzipBytes(body)))
+		case "unknown":
+			buf.WriteString(body)
+		default:
+			buf.WriteString(body)
+			enc = ""
+		}
+
+		if enc := r.URL.Query().Get("enc"); enc != "" {
+			w.Header().Set("Content-Encoding", strings.Replace(enc, "raw-", "", 1))
+		}
+		_, _ = w.Write(buf.Bytes())
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestClient_WithCompression(t *testing.T) {
+	ts := newEncodingServer(t)
+	greq.RegisterDecompressor("x-b64", func(r io.Reader) (io.ReadCloser, error) {
+		return io.NopCloser(base64.NewDecoder(base64.StdEncoding, r)), nil
+	})
+	assert.Contains(t, greq.SupportedEncodings(), "x-b64")
+
+	client := greq.New(ts.URL).WithCompression("gzip", "deflate", "br", "x-b64")
+	// br is not registered
+	assert.Eq(t, "gzip, deflate, x-b64", client.AcceptEncoding)
+	assert.Eq(t, client.AcceptEncoding, client.Sub().AcceptEncoding)
+
+	for _, enc := range []string{"gzip", "deflate", "raw-deflate", "gzip,%20x-b64", ""} {
+		resp, err := client.GetDo("/p1?enc=" + enc)
+		assert.NoErr(t, err)
+		assert.Eq(t, "gzip, deflate, x-b64", resp.Header.Get("X-Accept-Encoding"))
+		assert.Empty(t, resp.Header.Get("Content-Encoding"))
+		assert.Eq(t, "hello /p1", resp.BodyString(), "enc="+enc)
+		if enc != "" {
+			assert.True(t, resp.Uncompressed)
+		}
+	}
+
+	// unknown encoding: keep as is
+	resp, err := client.GetDo("/p2?enc=unknown")
+	assert.NoErr(t, err)
+	assert.Eq(t, "unknown", resp.Header.Get("Content-Encoding"))
+	assert.Eq(t, "hello /p2", resp.BodyString())
+
+	// user header is not overwritten
+	resp, err = client.GetDo("/p3", greq.WithHeader("Accept-Encoding", "gzip"))
+	assert.NoErr(t, err)
+	assert.Eq(t, "gzip", resp.Header.Get("X-Accept-Encoding"))
+
+	// HEAD with empty body
+	resp, err = client.HeadDo("/p4?enc=gzip")
+	assert.NoErr(t, err)
+	assert.Eq(t, "", resp.BodyString())
+
+	// invalid gzip body
+	bad := greq.New().WithCompression().Doer(httpDoerFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{"Content-Encoding": {"gzip"}},
+			Body:       io.NopCloser(strings.NewReader("not gzip")),
+		}, nil
+	}))
+	resp, err = bad.GetDo("http://mock.local/")
+	assert.NoErr(t, err)
+	_, err = resp.BodyStringE()
+	assert.Err(t, err)
+}
+
+func TestWithGzipBody(t *testing.T) {
+	var gotEnc, gotBody string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotEnc = r.Header.Get("Content-Encoding")
+		var rd io.Reader = r.Body
+		if gotEnc == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			assert.NoErr(t, err)
+			rd = zr
+		}
+		bs, _ := io.ReadAll(rd)
+		gotBody = string(bs)
+	}))
+	defer ts.Close()
+
+	client := greq.New(ts.URL)
+	body := strings.Repeat("hello,", 100)
+	_, err := client.PostDo("/upload", greq.WithBody(body), greq.WithGzipBody(100))
+	assert.NoErr(t, err)
+	assert.Eq(t, "gzip", gotEnc)
+	assert.Eq(t, body, gotBody)
+
+	// less than min size
+	_, err = client.PostDo("/upload", greq.WithBody("small"), greq.WithGzipBody(100))
+	assert.NoErr(t, err)
+	assert.Eq(t, "", gotEnc)
+	assert.Eq(t, "small", gotBody)
+
+	// GET not has body
+	req, err := client.NewRequest("GET", "/", greq.WithGzipBody(0))
+	assert.NoErr(t, err)
+	assert.Empty(t, req.Header.Get("Content-Encoding"))
+}
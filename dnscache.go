@@ -0,0 +1,143 @@
+package greq
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DNSCache an in-process DNS cache with TTL, the failed lookups are cached by NegativeTTL.
+// It can be shared by multiple clients.
+//
+// Usage:
+//
+//	cache := greq.NewDNSCache(time.Minute, 5*time.Second)
+//	h := greq.New().WithDNSCache(cache)
+type DNSCache struct {
+	// TTL for the resolved addresses. <= 0: not cache
+	TTL time.Duration
+	// NegativeTTL for the failed lookups. <= 0: not cache
+	NegativeTTL time.Duration
+	// LookupFunc custom the lookup func. default is net.DefaultResolver.LookupHost
+	LookupFunc func(ctx context.Context, host string) ([]string, error)
+
+	mu      sync.Mutex
+	entries map[string]*dnsEntry
+}
+
+type dnsEntry struct {
+	addrs   []string
+	err     error
+	expires time.Time
+	// closed on the lookup is done
+	done chan struct{}
+}
+
+// NewDNSCache create a DNS cache
+func NewDNSCache(ttl, negativeTTL time.Duration) *DNSCache {
+	return &DNSCache{TTL: ttl, NegativeTTL: negativeTTL}
+}
+
+// LookupHost get the addresses of the host from cache, or lookup it.
+// Concurrent lookups of the same host are merged into one, the lookup runs on the ctx of the first caller.
+// If it's canceled, the other callers will lookup again by their own ctx.
+func (c *DNSCache) LookupHost(ctx context.Context, host string) ([]string, error) {
+	host = strings.ToLower(host)
+	if net.ParseIP(host) != nil {
+		return []string{host}, nil
+	}
+
+	for {
+		addrs, shared, err := c.lookupHost(ctx, host)
+		// the shared lookup failed by the ctx of another caller
+		if shared && isContextErr(err) && ctx.Err() == nil {
+			continue
+		}
+		return addrs, err
+	}
+}
+
+// lookupHost get from cache or lookup it. shared is true if the result is from the lookup of another caller.
+func (c *DNSCache) lookupHost(ctx context.Context, host string) (addrs []string, shared bool, err error) {
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = make(map[string]*dnsEntry)
+	}
+
+	ent, ok := c.entries[host]
+	if ok {
+		select {
+		case <-ent.done:
+			if time.Now().Before(ent.expires) {
+				c.mu.Unlock()
+				return ent.addrs, false, ent.err
+			}
+			ok = false // expired
+		default: // the lookup is in progress
+		}
+	}
+
+	if !ok {
+		ent = &dnsEntry{done: make(chan struct{})}
+		c.entries[host] = ent
+		c.mu.Unlock()
+		c.lookup(ctx, host, ent)
+		return ent.addrs, false, ent.err
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-ent.done:
+		return ent.addrs, true, ent.err
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+}
+
+func isContextErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func (c *DNSCache) lookup(ctx context.Context, host string, ent *dnsEntry) {
+	lookupFn := c.LookupFunc
+	if lookupFn == nil {
+		lookupFn = net.DefaultResolver.LookupHost
+	}
+
+	ent.addrs, ent.err = lookupFn(ctx, host)
+	ttl := c.TTL
+	if ent.err != nil {
+		ttl = c.NegativeTTL
+		// not cache the error caused by the caller
+		if isContextErr(ent.err) {
+			ttl = 0
+		}
+	}
+	ent.expires = time.Now().Add(ttl)
+	close(ent.done)
+
+	if ttl <= 0 {
+		c.mu.Lock()
+		if c.entries[host] == ent {
+			delete(c.entries, host)
+		}
+		c.mu.Unlock()
+	}
+}
+
+// Remove the cached host
+func (c *DNSCache) Remove(host string) {
+	c.mu.Lock()
+	delete(c.entries, strings.ToLower(host))
+	c.mu.Unlock()
+}
+
+// Clear all cached hosts
+func (c *DNSCache) Clear() {
+	c.mu.Lock()
+	c.entries = nil
+	c.mu.Unlock()
+}
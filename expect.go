@@ -0,0 +1,254 @@
+package greq
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// TestingT is the interface of *testing.T used by the Response.Expect
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// RespExpect is the fluent assertions of a response, create by Response.Expect.
+// The failures are reported by t.Errorf, and the assertions continue.
+type RespExpect struct {
+	t    TestingT
+	resp *Response
+}
+
+// Expect create the fluent assertions for the response in tests.
+//
+// The body is buffered on the first body assertion, so multiple assertions can run on the same
+// response, and the body can be read again after them.
+//
+// Usage:
+//
+//	resp.Expect(t).
+//		Status(200).
+//		Header("Content-Type", "application/json").
+//		JSONPath("$.data.id", 5).
+//		BodyContains(`"name"`).
+//		Within(200 * time.Millisecond)
+func (r *Response) Expect(t TestingT) *RespExpect {
+	return &RespExpect{t: t, resp: r}
+}
+
+// Status expect the response status code
+func (e *RespExpect) Status(code int) *RespExpect {
+	e.t.Helper()
+	if e.resp.StatusCode != code {
+		e.t.Errorf("greq: expect status %d, but got %d\nbody: %s", code, e.resp.StatusCode, e.bodySnippet())
+	}
+	return e
+}
+
+// Header expect the response header value. If the value has no params,
+// the params of the header are ignored. eg: "application/json" matches "application/json; charset=utf-8"
+func (e *RespExpect) Header(key, value string) *RespExpect {
+	e.t.Helper()
+	got := e.resp.Header.Get(key)
+	if got == value {
+		return e
+	}
+
+	if !strings.Contains(value, ";") {
+		if mt, _, ok := strings.Cut(got, ";"); ok && strings.TrimSpace(mt) == value {
+			return e
+		}
+	}
+	e.t.Errorf("greq: expect header %s: %q, but got %q", key, value, got)
+	return e
+}
+
+// BodyContains expect the response body contains the sub string
+func (e *RespExpect) BodyContains(sub string) *RespExpect {
+	e.t.Helper()
+	if body, ok := e.body(); ok && !bytes.Contains(body, []byte(sub)) {
+		e.t.Errorf("greq: expect body contains %q\nbody: %s", sub, e.bodySnippet())
+	}
+	return e
+}
+
+// JSONPath expect the value at the JSON path is JSON-equivalent to want. eg: 5 equals 5.0
+//
+// The path format: "$.data.id", "data.items[0].name", `$["a.b"]`.
+func (e *RespExpect) JSONPath(path string, want any) *RespExpect {
+	e.t.Helper()
+	data, ok := e.jsonBody()
+	if !ok {
+		return e
+	}
+
+	segs, err := parseJSONPath(path)
+	if err != nil {
+		e.t.Errorf("%v", err)
+		return e
+	}
+	got, found := lookupJSONPath(data, segs)
+	if !found {
+		e.t.Errorf("greq: expect JSON path %q exists\nbody: %s", path, e.bodySnippet())
+		return e
+	}
+
+	wantVal, err := normalizeJSON(want)
+	if err != nil {
+		e.t.Errorf("greq: encode the expected value of JSON path %q: %v", path, err)
+		return e
+	}
+	if !reflect.DeepEqual(wantVal, got) {
+		e.t.Errorf("greq: JSON path %q not equal:\n%s", path, jsonDiff(wantVal, got))
+	}
+	return e
+}
+
+// JSONSchema expect the response body is valid by the JSON schema. The schema can be a JSON
+// string, []byte or a value can be JSON encoded.
+//
+// The supported keywords: type, enum, const, properties, required, additionalProperties, items,
+// minItems, maxItems, minLength, maxLength, pattern, minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum, allOf, anyOf, oneOf. Others are ignored.
+func (e *RespExpect) JSONSchema(schema any) *RespExpect {
+	e.t.Helper()
+	data, ok := e.jsonBody()
+	if !ok {
+		return e
+	}
+
+	if errs := validateJSONSchema(schema, data); len(errs) > 0 {
+		e.t.Errorf("greq: body not match the JSON schema:\n  %s", strings.Join(errs, "\n  "))
+	}
+	return e
+}
+
+// Within expect the response is received within the duration, by Response.CostTime
+func (e *RespExpect) Within(d time.Duration) *RespExpect {
+	e.t.Helper()
+	if cost := time.Duration(e.resp.CostTime) * time.Millisecond; cost > d {
+		e.t.Errorf("greq: expect response within %s, but cost %s", d, cost)
+	}
+	return e
+}
+
+// body read and buffer the body, the Response.Body is reset for read again.
+func (e *RespExpect) body() ([]byte, bool) {
+	r := e.resp
+	if r.buffered == nil && r.bufErr == nil {
+		r.buffered = []byte{}
+		if r.Body != nil {
+			bs, err := io.ReadAll(r.Body)
+			r.QuietCloseBody()
+			// don't keep the partial body, the assertions on it would be misleading
+			if err != nil {
+				r.buffered, r.bufErr = nil, err
+			} else {
+				r.buffered = bs
+			}
+		}
+	}
+	if r.bufErr != nil {
+		e.t.Helper()
+		e.t.Errorf("greq: read response body: %v", r.bufErr)
+		return nil, false
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(r.buffered))
+	return r.buffered, true
+}
+
+func (e *RespExpect) jsonBody() (any, bool) {
+	e.t.Helper()
+	body, ok := e.body()
+	if !ok {
+		return nil, false
+	}
+
+	var data any
+	if err := json.Unmarshal(body, &data); err != nil {
+		e.t.Errorf("greq: expect JSON body, decode error: %v\nbody: %s", err, e.bodySnippet())
+		return nil, false
+	}
+	return data, true
+}
+
+// bodySnippet get the body for the failure message, long body is truncated.
+func (e *RespExpect) bodySnippet() string {
+	body, ok := e.body()
+	if !ok {
+		return ""
+	}
+	if len(body) > 512 {
+		return string(body[:512]) + "...(truncated)"
+	}
+	return string(body)
+}
+
+// normalizeJSON convert the value to the JSON decoded value
+func normalizeJSON(v any) (any, error) {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var out any
+	err = json.Unmarshal(bs, &out)
+	return out, err
+}
+
+// jsonDiff format the JSON values to a line diff.
+func jsonDiff(want, got any) string {
+	wantBs, _ := json.MarshalIndent(want, "", "  ")
+	gotBs, _ := json.MarshalIndent(got, "", "  ")
+	a := strings.Split(string(wantBs), "\n")
+	b := strings.Split(string(gotBs), "\n")
+
+	// the longest common subsequence of the lines
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("--- expected\n+++ actual\n")
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			sb.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			sb.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			sb.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// toJSONValue decode the JSON string or []byte, other values are JSON encoded then decoded.
+func toJSONValue(v any) (any, error) {
+	switch val := v.(type) {
+	case string:
+		v = json.RawMessage(val)
+	case []byte:
+		v = json.RawMessage(val)
+	}
+	return normalizeJSON(v)
+}
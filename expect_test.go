@@ -0,0 +1,165 @@
+package greq_test
+
+import (
+	"fmt"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq"
+)
+
+// fakeT collect the errors reported by the Expect
+type fakeT struct {
+	errs []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errs = append(f.errs, fmt.Sprintf(format, args...))
+}
+
+const expectBody = `{"data": {"id": 5, "name": "inhere", "tags": ["a", "b"]}, "total": 1}`
+
+func TestResponse_Expect(t *testing.T) {
+	resp, _ := mockStreamResp(t, "application/json; charset=utf-8", expectBody)
+
+	// use the real t, all passed
+	resp.Expect(t).
+		Status(200).
+		Header("Content-Type", "application/json").
+		Header("Content-Type", "application/json; charset=utf-8").
+		JSONPath("$.data.id", 5).
+		JSONPath("data.tags", []string{"a", "b"}).
+		JSONPath("$.data.tags[1]", "b").
+		BodyContains(`"name": "inhere"`).
+		Within(time.Second)
+
+	// the body is still readable
+	bs, err := io.ReadAll(resp.Body)
+	assert.NoErr(t, err)
+	assert.Eq(t, expectBody, string(bs))
+
+	ft := &fakeT{}
+	resp.Expect(ft).
+		Status(201).
+		Header("Content-Type", "text/plain").
+		JSONPath("$.data.id", 6).
+		JSONPath("$.data.none", 1).
+		BodyContains("not-exists")
+	assert.Len(t, ft.errs, 5)
+	assert.StrContains(t, ft.errs[0], "expect status 201, but got 200\nbody: {")
+	assert.StrContains(t, ft.errs[1], `expect header Content-Type: "text/plain"`)
+	assert.StrContains(t, ft.errs[2], "--- expected\n+++ actual\n- 6\n+ 5")
+	assert.StrContains(t, ft.errs[3], `expect JSON path "$.data.none" exists`)
+	assert.StrContains(t, ft.errs[4], `expect body contains "not-exists"`)
+
+	// the diff of the composite value
+	ft = &fakeT{}
+	resp.Expect(ft).JSONPath("$.data.tags", []string{"a", "c"})
+	assert.Len(t, ft.errs, 1)
+	assert.StrContains(t, ft.errs[0], "[\n    \"a\",\n-   \"c\"\n+   \"b\"\n  ]")
+
+	// within
+	ft = &fakeT{}
+	resp.CostTime = 300
+	resp.Expect(ft).Within(200 * time.Millisecond)
+	assert.Len(t, ft.errs, 1)
+	assert.StrContains(t, ft.errs[0], "expect response within 200ms, but cost 300ms")
+
+	// not JSON body
+	resp, _ = mockStreamResp(t, "text/plain", "hello")
+	ft = &fakeT{}
+	resp.Expect(ft).BodyContains("hello").JSONPath("$.id", 1)
+	assert.Len(t, ft.errs, 1)
+	assert.StrContains(t, ft.errs[0], "expect JSON body")
+}
+
+func TestRespExpect_JSONSchema(t *testing.T) {
+	resp, _ := mockStreamResp(t, "application/json", expectBody)
+	schema := `{
+		"type": "object",
+		"required": ["data", "total"],
+		"properties": {
+			"total": {"type": "integer", "minimum": 1},
+			"data": {
+				"type": "object",
+				"required": ["id", "name"],
+				"additionalProperties": false,
+				"properties": {
+					"id": {"type": "integer"},
+					"name": {"type": "string", "minLength": 2, "pattern": "^[a-z]+$"},
+					"tags": {"type": "array", "items": {"enum": ["a", "b"]}, "maxItems": 3}
+				}
+			}
+		}
+	}`
+	resp.Expect(t).JSONSchema(schema)
+
+	// the schema as a map
+	resp.Expect(t).JSONSchema(map[string]any{"type": "object", "required": []string{"data"}})
+
+	bad := `{
+		"type": "object",
+		"required": ["data", "meta"],
+		"properties": {
+			"total": {"type": "string"},
+			"data": {
+				"additionalProperties": false,
+				"properties": {
+					"id": {"oneOf": [{"type": "integer"}, {"type": "number"}]},
+					"name": {"maxLength": 3},
+					"tags": {"items": {"const": "a"}, "minItems": 3}
+				}
+			}
+		}
+	}`
+	ft := &fakeT{}
+	resp.Expect(ft).JSONSchema(bad)
+	assert.Len(t, ft.errs, 1)
+	msg := ft.errs[0]
+	for _, want := range []string{
+		`$: missing required property "meta"`,
+		"$.data.id: expect match one of the oneOf schemas, but matched 2",
+		"$.data.name: expect max length 3, but got 6",
+		"$.data.tags: expect at least 3 items, but got 2",
+		`$.data.tags[1]: expect const "a", but got "b"`,
+		"$.total: expect type string, but got integer",
+	} {
+		assert.StrContains(t, msg, want)
+	}
+	assert.Len(t, strings.Split(msg, "\n"), 7)
+}
+
+func TestRespExpect_readBodyError(t *testing.T) {
+	body := io.MultiReader(strings.NewReader(`{"id": 1}`), iotest.ErrReader(errors.New("connection reset")))
+	resp := greq.NewResponse(&http.Response{StatusCode: 200, Body: io.NopCloser(body)}, nil)
+
+	// the partial body is not used, every body assertion reports the read error
+	ft := &fakeT{}
+	resp.Expect(ft).
+		JSONPath("$.id", 1).
+		BodyContains(`"id"`).
+		Status(200)
+	assert.Len(t, ft.errs, 2)
+	assert.StrContains(t, ft.errs[0], "read response body: connection reset")
+	assert.StrContains(t, ft.errs[1], "read response body: connection reset")
+}
+
+func TestRespExpect_realServer(t *testing.T) {
+	ts, _ := newListServer(t)
+	resp, err := greq.New(ts.URL).GetDo("/pages?keep=yes&page=1&per_page=2")
+	assert.NoErr(t, err)
+
+	resp.Expect(t).
+		Status(http.StatusOK).
+		JSONPath("$[1].name", "b").
+		JSONSchema(`{"type": "array", "items": {"required": ["id", "name"]}}`).
+		Within(time.Second)
+}
@@ -1,6 +1,7 @@
 package bench
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/gookit/goutil/strutil"
 	"github.com/gookit/greq"
+	"github.com/gookit/greq/ext/httpfile"
 )
 
 // Snapshot is a point-in-time view of bench progress, delivered to OnProgress.
@@ -55,12 +57,18 @@ type HTTPBench struct {
 	statusCodes map[int]int64
 	// 响应时间统计
 	respTimes []time.Duration
+	// 各阶段耗时累计，来自 Response.Timings()
+	phaseSum    PhaseTimings
+	reusedConns int64
 
 	// Progress 回调 — nil 时不启动进度协程。CLI 渲染通过这个钩子接入，
 	// 让库本身不依赖任何 UI 包。
 	onProgress     ProgressFn
 	progressTick   time.Duration // 触发间隔, 0 时默认 200ms
 
+	// body 包含动态变量时(eg: ${$uuid})，每个请求重新渲染
+	dynBody bool
+
 	// 客户端
 	client *greq.Client
 
@@ -86,6 +94,45 @@ type BenchResult struct {
 	ReqsPerSecond  float64
 	BytesPerSecond float64
 	StatusCodes    map[int]int64
+	// Phases 各阶段平均耗时
+	Phases PhaseTimings
+	// ReusedConns 复用连接的请求数
+	ReusedConns int64
+}
+
+// PhaseTimings 请求各阶段耗时, see greq.Timings
+type PhaseTimings struct {
+	DNSLookup        time.Duration
+	TCPConnect       time.Duration
+	TLSHandshake     time.Duration
+	ServerProcessing time.Duration
+	TTFB             time.Duration
+	ContentTransfer  time.Duration
+	Total            time.Duration
+}
+
+func (p *PhaseTimings) add(tm greq.Timings) {
+	p.DNSLookup += tm.DNSLookup
+	p.TCPConnect += tm.TCPConnect
+	p.TLSHandshake += tm.TLSHandshake
+	p.ServerProcessing += tm.ServerProcessing
+	p.TTFB += tm.TTFB
+	p.ContentTransfer += tm.ContentTransfer
+	p.Total += tm.Total
+}
+
+// avg 计算平均值
+func (p PhaseTimings) avg(n int) PhaseTimings {
+	d := time.Duration(n)
+	return PhaseTimings{
+		DNSLookup:        p.DNSLookup / d,
+		TCPConnect:       p.TCPConnect / d,
+		TLSHandshake:     p.TLSHandshake / d,
+		ServerProcessing: p.ServerProcessing / d,
+		TTFB:             p.TTFB / d,
+		ContentTransfer:  p.ContentTransfer / d,
+		Total:            p.Total / d,
+	}
 }
 
 // NewHTTPBench 创建新的HTTPBench实例
@@ -113,7 +160,7 @@ func (b *HTTPBench) SetHeaders(headers map[string]string) *HTTPBench {
 	return b
 }
 
-// SetBody 设置请求体
+// SetBody 设置请求体. 支持动态变量，每个请求重新求值. eg: ${$uuid}, ${$randomInt(1,100)}
 func (b *HTTPBench) SetBody(body []byte) *HTTPBench {
 	b.Body = body
 	return b
@@ -191,6 +238,8 @@ func (b *HTTPBench) initClient() {
 	for k, v := range b.Headers {
 		b.client.DefaultHeader(k, v)
 	}
+
+	b.dynBody = bytes.Contains(b.Body, []byte("${$"))
 }
 
 // Run executes the benchmark with a background context. Equivalent to RunCtx(context.Background()).
@@ -317,8 +366,12 @@ func (b *HTTPBench) doRequest() {
 	var resp *greq.Response
 
 	if len(b.Body) > 0 {
+		body := b.Body
+		if b.dynBody {
+			body = []byte(httpfile.RenderVars(string(b.Body), nil))
+		}
 		resp, err = b.client.Do(b.Method, b.URL, func(opt *greq.Options) {
-			opt.Body = b.Body
+			opt.Body = body
 		})
 	} else {
 		resp, err = b.client.Do(b.Method, b.URL)
@@ -356,6 +409,15 @@ func (b *HTTPBench) doRequest() {
 	}
 
 	resp.CloseBody()
+
+	// body 读取完成后统计各阶段耗时
+	tm := resp.Timings()
+	b.mu.Lock()
+	b.phaseSum.add(tm)
+	if tm.Reused {
+		b.reusedConns++
+	}
+	b.mu.Unlock()
 }
 
 // generateResult 生成最终结果
@@ -401,6 +463,8 @@ func (b *HTTPBench) generateResult() *BenchResult {
 		}
 
 		result.AvgRespTime = totalTime / time.Duration(len(b.respTimes))
+		result.Phases = b.phaseSum.avg(len(b.respTimes))
+		result.ReusedConns = b.reusedConns
 	}
 
 	return result
@@ -448,6 +512,16 @@ func (r *BenchResult) format(colored bool) string {
 		buf = append(buf, fmt.Sprintf("Average response time: %s\n", r.AvgRespTime)...)
 		buf = append(buf, fmt.Sprintf("Minimum response time: %s\n", r.MinRespTime)...)
 		buf = append(buf, fmt.Sprintf("Maximum response time: %s\n", r.MaxRespTime)...)
+
+		p := r.Phases
+		buf = append(buf, "\nPhase timings (average):\n"...)
+		buf = append(buf, fmt.Sprintf("  DNS lookup:         %s\n", p.DNSLookup)...)
+		buf = append(buf, fmt.Sprintf("  TCP connect:        %s\n", p.TCPConnect)...)
+		buf = append(buf, fmt.Sprintf("  TLS handshake:      %s\n", p.TLSHandshake)...)
+		buf = append(buf, fmt.Sprintf("  Server processing:  %s\n", p.ServerProcessing)...)
+		buf = append(buf, fmt.Sprintf("  Time to first byte: %s\n", p.TTFB)...)
+		buf = append(buf, fmt.Sprintf("  Content transfer:   %s\n", p.ContentTransfer)...)
+		buf = append(buf, fmt.Sprintf("  Reused connections: %d\n", r.ReusedConns)...)
 	}
 
 	if len(r.StatusCodes) > 0 {
@@ -0,0 +1,50 @@
+package bench
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+func TestHTTPBench_dynamicBody(t *testing.T) {
+	var mu sync.Mutex
+	bodies := make(map[string]bool)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bs, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies[string(bs)] = true
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	b := NewHTTPBench(srv.URL).SetMethod("post").SetNumber(5).SetBody([]byte(`{"id":"${$uuid}"}`))
+	res, err := b.Run()
+	assert.NoErr(t, err)
+	assert.Eq(t, int64(5), res.SuccessReqs)
+
+	// each request got a new uuid
+	assert.Len(t, bodies, 5)
+	for body := range bodies {
+		assert.NotContains(t, body, "${")
+	}
+}
+
+func TestHTTPBench_phases(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	res, err := NewHTTPBench(srv.URL).SetNumber(5).Run()
+	assert.NoErr(t, err)
+	assert.Eq(t, int64(5), res.SuccessReqs)
+	assert.True(t, res.Phases.TTFB > 0)
+	assert.True(t, res.Phases.Total >= res.Phases.TTFB)
+	// concurrency is 1, the connection is reused after the first request
+	assert.Eq(t, int64(4), res.ReusedConns)
+	assert.StrContains(t, res.PlainString(), "Phase timings (average):")
+}
@@ -0,0 +1,223 @@
+// Package har provides HAR 1.2 (HTTP Archive) recording and replay for greq.
+//
+//   - Recorder is a greq.Middleware, records each request/response as a HAR entry
+//   - Replayer is a httpreq.Doer, returns the recorded responses without hitting the network
+//
+// Spec: http://www.softwareishard.com/blog/har-12-spec/
+package har
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Version of the HAR format
+const Version = "1.2"
+
+// HAR is the root object of a HAR file
+type HAR struct {
+	Log *Log `json:"log"`
+}
+
+// New create an empty HAR with creator info.
+func New() *HAR {
+	return &HAR{Log: &Log{
+		Version: Version,
+		Creator: Creator{Name: "greq", Version: "1.0"},
+		Entries: make([]*Entry, 0),
+	}}
+}
+
+// Load HAR from file
+func Load(path string) (*HAR, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(bs)
+}
+
+// Parse HAR from JSON contents
+func Parse(data []byte) (*HAR, error) {
+	h := &HAR{}
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, fmt.Errorf("har: parse error: %w", err)
+	}
+	if h.Log == nil {
+		return nil, fmt.Errorf("har: missing the log object")
+	}
+	return h, nil
+}
+
+// WriteTo write the HAR as indented JSON to w. implements io.WriterTo
+func (h *HAR) WriteTo(w io.Writer) (int64, error) {
+	bs, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(append(bs, '\n'))
+	return int64(n), err
+}
+
+// Save the HAR to file
+func (h *HAR) Save(path string) error {
+	fh, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.WriteTo(fh)
+	if cerr := fh.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Log is the log object of HAR
+type Log struct {
+	Version string   `json:"version"`
+	Creator Creator  `json:"creator"`
+	Entries []*Entry `json:"entries"`
+	Comment string   `json:"comment,omitempty"`
+}
+
+// Creator of the HAR file
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Entry is one request-response pair
+type Entry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	// Time total elapsed time of the request in milliseconds
+	Time     float64   `json:"time"`
+	Request  *Request  `json:"request"`
+	Response *Response `json:"response"`
+	Cache    struct{}  `json:"cache"`
+	Timings  Timings   `json:"timings"`
+	// ServerIPAddress IP address of the server
+	ServerIPAddress string `json:"serverIPAddress,omitempty"`
+	// Comment the error message when request failed
+	Comment string `json:"comment,omitempty"`
+}
+
+// clone the entry deeply
+func (e *Entry) clone() *Entry {
+	ne := *e
+	if e.Request != nil {
+		req := *e.Request
+		req.Cookies = slices.Clone(req.Cookies)
+		req.Headers = slices.Clone(req.Headers)
+		req.QueryString = slices.Clone(req.QueryString)
+		if req.PostData != nil {
+			pd := *req.PostData
+			req.PostData = &pd
+		}
+		ne.Request = &req
+	}
+	if e.Response != nil {
+		resp := *e.Response
+		resp.Cookies = slices.Clone(resp.Cookies)
+		resp.Headers = slices.Clone(resp.Headers)
+		ne.Response = &resp
+	}
+	return &ne
+}
+
+// Request info of an entry
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *PostData   `json:"postData,omitempty"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// Response info of an entry
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// NameValue pair. use for headers and query string
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Cookie info
+type Cookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+// PostData the request body
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	// Encoding is "base64" for binary body. NOTE: it is not in the spec, but widely used.
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Content the response body
+type Content struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	// Encoding is "base64" for binary body.
+	Encoding string `json:"encoding,omitempty"`
+	// Truncated the text is truncated on record, see WithMaxBodySize. it's a custom field of greq.
+	Truncated bool `json:"_truncated,omitempty"`
+}
+
+// Timings of the request phases in milliseconds. -1 if not applicable.
+type Timings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// toNameValues convert the http.Header to sorted name-value pairs
+func toNameValues(h http.Header) []NameValue {
+	nvs := make([]NameValue, 0, len(h))
+	for name, values := range h {
+		for _, val := range values {
+			nvs = append(nvs, NameValue{Name: name, Value: val})
+		}
+	}
+	sortNameValues(nvs)
+	return nvs
+}
+
+// sortNameValues by name, keep the order of values with same name
+func sortNameValues(nvs []NameValue) {
+	sort.SliceStable(nvs, func(i, j int) bool { return nvs[i].Name < nvs[j].Name })
+}
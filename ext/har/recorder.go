@@ -0,0 +1,474 @@
+package har
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gookit/greq"
+)
+
+// Redacted is the replacement for redacted values
+const Redacted = "REDACTED"
+
+// RecorderOptionFn is a function to configure the Recorder
+type RecorderOptionFn func(r *Recorder)
+
+// WithRedactHeaders redact the header values(and cookies on Cookie, Set-Cookie) on record.
+//
+// eg: WithRedactHeaders("Authorization", "Cookie", "Set-Cookie")
+func WithRedactHeaders(names ...string) RecorderOptionFn {
+	return func(r *Recorder) {
+		r.redactHeaders = append(r.redactHeaders, names...)
+	}
+}
+
+// WithRedactFunc custom redact func, will be called before add the entry to the log.
+func WithRedactFunc(fn func(e *Entry)) RecorderOptionFn {
+	return func(r *Recorder) {
+		r.redactFn = fn
+	}
+}
+
+// WithoutBody don't record the request and response bodies
+func WithoutBody() RecorderOptionFn {
+	return func(r *Recorder) {
+		r.skipBody = true
+	}
+}
+
+// DefaultMaxBodySize the default max size of the recorded response body
+const DefaultMaxBodySize = 1 << 20
+
+// WithMaxBodySize set the max size of the recorded response body, default is DefaultMaxBodySize.
+// The caller still reads the whole body, only the recorded text is truncated and marked by Content.Truncated.
+func WithMaxBodySize(n int) RecorderOptionFn {
+	return func(r *Recorder) {
+		r.maxBodySize = n
+	}
+}
+
+// Recorder is a greq.Middleware to record requests and responses as HAR entries.
+//
+// The response body is recorded while the caller reads it, the entry content is completed when
+// the body is read to EOF or closed. The "text/event-stream" body is not recorded.
+//
+// The entry is added on the response received, until it's completed the entry in
+// HAR(), Entries() and Save() is marked by the comment PendingComment.
+//
+// Usage:
+//
+//	rec := har.NewRecorder(har.WithRedactHeaders("Authorization"))
+//	client := greq.New("https://example.com").Use(rec)
+//	// ... send requests
+//	err := rec.HAR().Save("out.har")
+type Recorder struct {
+	mu  sync.Mutex
+	har *HAR
+	// the entries wait for the response body read done or closed
+	pending map[*Entry]bool
+
+	redactHeaders []string
+	redactFn      func(e *Entry)
+	skipBody      bool
+	maxBodySize   int
+}
+
+// NewRecorder create a new HAR recorder
+func NewRecorder(optFns ...RecorderOptionFn) *Recorder {
+	r := &Recorder{har: New(), pending: make(map[*Entry]bool), maxBodySize: DefaultMaxBodySize}
+	for _, fn := range optFns {
+		fn(r)
+	}
+	return r
+}
+
+// PendingComment is the comment of the entry whose response body is not read done or closed yet
+const PendingComment = "pending: the response body is not read done or closed"
+
+// HAR get a copy of the recorded HAR
+func (r *Recorder) HAR() *HAR {
+	h := New()
+	h.Log.Entries = r.Entries()
+	return h
+}
+
+// Entries get a copy of the recorded entries
+func (r *Recorder) Entries() []*Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]*Entry, len(r.har.Log.Entries))
+	for i, e := range r.har.Log.Entries {
+		entries[i] = e.clone()
+		if r.pending[e] {
+			entries[i].Comment = PendingComment
+			if r.redactFn != nil {
+				r.redactFn(entries[i])
+			}
+		}
+	}
+	return entries
+}
+
+// Reset clear the recorded entries
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.har.Log.Entries = make([]*Entry, 0)
+	r.pending = make(map[*Entry]bool)
+	r.mu.Unlock()
+}
+
+// Save the recorded HAR to file
+func (r *Recorder) Save(path string) error {
+	return r.HAR().Save(path)
+}
+
+// Handle implements the greq.Middleware
+func (r *Recorder) Handle(req *http.Request, next greq.HandleFunc) (*greq.Response, error) {
+	entry := &Entry{StartedDateTime: time.Now()}
+	reqBody, err := r.readReqBody(req)
+	if err != nil {
+		return nil, err
+	}
+	entry.Request = r.buildRequest(req, reqBody)
+
+	var tm greq.Timings
+	var recBody *recordBody
+	resp, err := next(req)
+	if err != nil {
+		entry.Comment = err.Error()
+		entry.Response = &Response{Cookies: []Cookie{}, Headers: []NameValue{}, HeadersSize: -1, BodySize: -1}
+	} else {
+		entry.Response = r.buildResponse(resp)
+		tm = resp.Timings()
+		if r.shouldRecordBody(resp) {
+			recBody = &recordBody{ReadCloser: resp.Body, limit: r.maxBodySize, points: tm.Points}
+			resp.Body = recBody
+		}
+	}
+
+	if host, _, err := net.SplitHostPort(tm.RemoteAddr); err == nil {
+		entry.ServerIPAddress = host
+	}
+	// the time of the recorded body is set when it's read done
+	if recBody == nil {
+		setEntryTime(entry, tm.Points, time.Now())
+	}
+
+	r.add(entry, recBody != nil)
+	if recBody == nil {
+		r.complete(entry, nil)
+	} else {
+		recBody.done = func(rb *recordBody) { r.complete(entry, rb) }
+	}
+	return resp, err
+}
+
+// setEntryTime set the total time and the timings of the entry, end is the time of the response completed.
+func setEntryTime(e *Entry, pt greq.TimePoints, end time.Time) {
+	e.Time = msOf(end.Sub(e.StartedDateTime))
+	e.Timings = buildTimings(pt, e.StartedDateTime, end)
+}
+
+// shouldRecordBody check the response body should be recorded
+func (r *Recorder) shouldRecordBody(resp *greq.Response) bool {
+	if r.skipBody || resp.Body == nil || resp.Body == http.NoBody {
+		return false
+	}
+	// the upgraded connection and the endless event stream
+	return resp.StatusCode != http.StatusSwitchingProtocols && !resp.IsContentType("text/event-stream")
+}
+
+// add the entry to the log on the response received, keep the order of the requests.
+// pending is true if the entry is completed on the response body read done.
+func (r *Recorder) add(e *Entry, pending bool) {
+	if len(r.redactHeaders) > 0 {
+		e.Request.Headers = r.redact(e.Request.Headers)
+		e.Response.Headers = r.redact(e.Response.Headers)
+		if r.isRedacted("Cookie") {
+			redactCookies(e.Request.Cookies)
+		}
+		if r.isRedacted("Set-Cookie") {
+			redactCookies(e.Response.Cookies)
+		}
+	}
+
+	r.mu.Lock()
+	r.har.Log.Entries = append(r.har.Log.Entries, e)
+	if pending {
+		r.pending[e] = true
+	}
+	r.mu.Unlock()
+}
+
+// complete the entry with the recorded response body, rb is nil if the body is not recorded.
+func (r *Recorder) complete(e *Entry, rb *recordBody) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.pending, e)
+	if rb != nil {
+		// the receive time includes reading the body
+		setEntryTime(e, rb.points, rb.end)
+
+		body := rb.buf.Bytes()
+		e.Response.BodySize = rb.size
+		e.Response.Content.Size = rb.size
+		if len(body) > 0 {
+			e.Response.Content.Text, e.Response.Content.Encoding = encodeBody(body, e.Response.Content.MimeType)
+		}
+		if rb.err != nil {
+			e.Comment = rb.err.Error()
+		} else if rb.size > len(body) {
+			e.Response.Content.Truncated = true
+			e.Comment = fmt.Sprintf("the response body is truncated to %d bytes", len(body))
+		}
+	}
+	if r.redactFn != nil {
+		r.redactFn(e)
+	}
+}
+
+// recordBody record the response body while it's read by the caller
+type recordBody struct {
+	io.ReadCloser
+	buf   bytes.Buffer
+	limit int
+	// total size of the read body
+	size int
+	err  error
+	// the time points of the response, end is the time of the body read done or closed
+	points greq.TimePoints
+	end    time.Time
+	once   sync.Once
+	done   func(rb *recordBody)
+}
+
+func (b *recordBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.size += n
+		if remain := b.limit - b.buf.Len(); remain > 0 {
+			b.buf.Write(p[:min(n, remain)])
+		}
+	}
+	if err != nil {
+		if err != io.EOF {
+			b.err = err
+		}
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *recordBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish()
+	return err
+}
+
+func (b *recordBody) finish() {
+	b.once.Do(func() {
+		b.end = time.Now()
+		if b.done != nil {
+			b.done(b)
+		}
+	})
+}
+
+// readReqBody read the request body without consume it.
+func (r *Recorder) readReqBody(req *http.Request) ([]byte, error) {
+	if r.skipBody || req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+
+	// not rewindable, read it and reset the body
+	bs, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(bs))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(bs)), nil
+	}
+	return bs, nil
+}
+
+func (r *Recorder) buildRequest(req *http.Request, body []byte) *Request {
+	hr := &Request{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: req.Proto,
+		Cookies:     make([]Cookie, 0),
+		Headers:     toNameValues(req.Header),
+		QueryString: make([]NameValue, 0),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	if hr.HTTPVersion == "" {
+		hr.HTTPVersion = "HTTP/1.1"
+	}
+
+	for _, c := range req.Cookies() {
+		hr.Cookies = append(hr.Cookies, Cookie{Name: c.Name, Value: c.Value})
+	}
+	for name, values := range req.URL.Query() {
+		for _, val := range values {
+			hr.QueryString = append(hr.QueryString, NameValue{Name: name, Value: val})
+		}
+	}
+	sortNameValues(hr.QueryString)
+
+	if len(body) > 0 {
+		text, enc := encodeBody(body, req.Header.Get("Content-Type"))
+		hr.PostData = &PostData{MimeType: req.Header.Get("Content-Type"), Text: text, Encoding: enc}
+	}
+	return hr
+}
+
+func (r *Recorder) buildResponse(resp *greq.Response) *Response {
+	hr := &Response{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Cookies:     make([]Cookie, 0),
+		Headers:     toNameValues(resp.Header),
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    -1,
+		Content: Content{
+			MimeType: resp.Header.Get("Content-Type"),
+		},
+	}
+
+	for _, c := range resp.Cookies() {
+		hc := Cookie{Name: c.Name, Value: c.Value, Path: c.Path, Domain: c.Domain, HTTPOnly: c.HttpOnly, Secure: c.Secure}
+		if !c.Expires.IsZero() {
+			hc.Expires = c.Expires.Format(time.RFC3339)
+		}
+		hr.Cookies = append(hr.Cookies, hc)
+	}
+
+	return hr
+}
+
+func (r *Recorder) isRedacted(name string) bool {
+	for _, rn := range r.redactHeaders {
+		if strings.EqualFold(rn, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Recorder) redact(nvs []NameValue) []NameValue {
+	for i, nv := range nvs {
+		if r.isRedacted(nv.Name) {
+			nvs[i].Value = Redacted
+		}
+	}
+	return nvs
+}
+
+func redactCookies(cs []Cookie) {
+	for i := range cs {
+		cs[i].Value = Redacted
+	}
+}
+
+// encodeBody as text, binary body will be base64 encoded.
+func encodeBody(body []byte, cType string) (text, encoding string) {
+	if isTextType(cType) && utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+// decodeBody the text of PostData or Content
+func decodeBody(text, encoding string) ([]byte, error) {
+	if encoding == "base64" {
+		return base64.StdEncoding.DecodeString(text)
+	}
+	return []byte(text), nil
+}
+
+func isTextType(cType string) bool {
+	if cType == "" {
+		return true
+	}
+
+	mt, _, err := mime.ParseMediaType(cType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mt, "text/") || strings.HasSuffix(mt, "json") || strings.HasSuffix(mt, "xml") ||
+		mt == "application/x-www-form-urlencoded" || mt == "application/javascript"
+}
+
+// buildTimings build the HAR timings from the time points of greq.Response.Timings()
+func buildTimings(pt greq.TimePoints, start, end time.Time) Timings {
+	if !pt.BodyDone.IsZero() {
+		end = pt.BodyDone
+	}
+
+	span := func(from, to time.Time) float64 {
+		if from.IsZero() || to.IsZero() {
+			return -1
+		}
+		return msOf(to.Sub(from))
+	}
+
+	tm := Timings{
+		DNS:     span(pt.DNSStart, pt.DNSDone),
+		Connect: span(pt.ConnectStart, pt.ConnectDone),
+		SSL:     span(pt.TLSStart, pt.TLSDone),
+		Send:    span(pt.GotConn, pt.WroteRequest),
+		Wait:    span(pt.WroteRequest, pt.FirstByte),
+		Receive: span(pt.FirstByte, end),
+	}
+	// the connect time includes the ssl time
+	if tm.SSL >= 0 && tm.Connect >= 0 {
+		tm.Connect = span(pt.ConnectStart, pt.TLSDone)
+	}
+
+	// blocked: waiting for a connection, before dns/connect
+	blockedEnd := pt.GotConn
+	for _, t := range []time.Time{pt.ConnectStart, pt.DNSStart} {
+		if !t.IsZero() && (blockedEnd.IsZero() || t.Before(blockedEnd)) {
+			blockedEnd = t
+		}
+	}
+	tm.Blocked = span(pt.GetConn, blockedEnd)
+
+	// not sent, eg: custom doer without network
+	if tm.Send < 0 {
+		tm.Send, tm.Receive = 0, 0
+		tm.Wait = msOf(end.Sub(start))
+	}
+	return tm
+}
+
+// msOf duration to milliseconds, keep 3 decimals
+func msOf(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,205 @@
+package har_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq"
+	"github.com/gookit/greq/ext/har"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "sid", Value: "abc"})
+		switch r.URL.Path {
+		case "/events":
+			w.Header().Set("Content-Type", "text/event-stream")
+			for {
+				if _, err := w.Write([]byte("data: ping\n\n")); err != nil {
+					return
+				}
+				w.(http.Flusher).Flush()
+				time.Sleep(5 * time.Millisecond)
+			}
+		case "/slow":
+			_, _ = w.Write([]byte("first,"))
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+			_, _ = w.Write([]byte("last"))
+		case "/large":
+			_, _ = w.Write([]byte(strings.Repeat("a", 1000)))
+		case "/png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte{0x89, 'P', 'N', 'G', 0xff})
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"path": "` + r.URL.Path + `"}`))
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestRecorder(t *testing.T) {
+	ts := newTestServer(t)
+	rec := har.NewRecorder(har.WithRedactHeaders("Authorization", "Set-Cookie"))
+	client := greq.New(ts.URL).Use(rec)
+
+	resp, err := client.PostDo("/users?b=2&a=1",
+		greq.WithBody(`{"name": "inhere"}`),
+		greq.WithContentType("application/json"),
+		greq.WithHeader("Authorization", "Bearer token"),
+	)
+	assert.NoErr(t, err)
+	// body still can be read
+	assert.Eq(t, `{"path": "/users"}`, resp.BodyString())
+
+	resp, err = client.GetDo("/png")
+	assert.NoErr(t, err)
+	// the entry is added on the response, the body is recorded on read
+	entries := rec.Entries()
+	assert.Len(t, entries, 2)
+	assert.Eq(t, -1, entries[1].Response.BodySize)
+	resp.BodyString()
+
+	entries = rec.Entries()
+	assert.Len(t, entries, 2)
+
+	e := entries[0]
+	assert.Eq(t, "POST", e.Request.Method)
+	assert.Eq(t, ts.URL+"/users?b=2&a=1", e.Request.URL)
+	assert.Eq(t, []har.NameValue{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}}, e.Request.QueryString)
+	assert.Eq(t, `{"name": "inhere"}`, e.Request.PostData.Text)
+	assert.Eq(t, "application/json", e.Request.PostData.MimeType)
+	assert.Eq(t, 200, e.Response.Status)
+	assert.Eq(t, `{"path": "/users"}`, e.Response.Content.Text)
+	assert.Eq(t, "127.0.0.1", e.ServerIPAddress)
+	assert.True(t, e.Time > 0)
+	assert.True(t, e.Timings.Wait >= 0)
+	assert.Eq(t, float64(-1), e.Timings.SSL)
+
+	for _, h := range e.Request.Headers {
+		if h.Name == "Authorization" {
+			assert.Eq(t, har.Redacted, h.Value)
+		}
+	}
+	assert.Eq(t, har.Redacted, e.Response.Cookies[0].Value)
+
+	// binary body
+	e = entries[1]
+	assert.Eq(t, "base64", e.Response.Content.Encoding)
+	assert.Eq(t, 5, e.Response.Content.Size)
+
+	// save and load
+	path := filepath.Join(t.TempDir(), "out.har")
+	assert.NoErr(t, rec.Save(path))
+	h, err := har.Load(path)
+	assert.NoErr(t, err)
+	assert.Eq(t, har.Version, h.Log.Version)
+	assert.Len(t, h.Log.Entries, 2)
+
+	rec.Reset()
+	assert.Len(t, rec.Entries(), 0)
+}
+
+func TestRecorder_error(t *testing.T) {
+	rec := har.NewRecorder(har.WithoutBody(), har.WithRedactFunc(func(e *har.Entry) {
+		e.Comment = "custom: " + e.Comment
+	}))
+
+	_, err := greq.New("http://127.0.0.1:1").Use(rec).GetDo("/")
+	assert.Err(t, err)
+
+	entries := rec.Entries()
+	assert.Len(t, entries, 1)
+	assert.StrContains(t, entries[0].Comment, "custom: ")
+	assert.Eq(t, 0, entries[0].Response.Status)
+}
+
+func TestRecorder_streamBody(t *testing.T) {
+	ts := newTestServer(t)
+	rec := har.NewRecorder(har.WithMaxBodySize(100))
+	client := greq.New(ts.URL).Use(rec)
+
+	// the event stream is not buffered
+	resp, err := client.GetDo("/events")
+	assert.NoErr(t, err)
+	var n int
+	for ev, err := range resp.Events() {
+		assert.NoErr(t, err)
+		assert.Eq(t, "ping", ev.Data)
+		if n++; n == 2 {
+			break
+		}
+	}
+
+	// truncated
+	resp, err = client.GetDo("/large")
+	assert.NoErr(t, err)
+	assert.Len(t, resp.BodyString(), 1000)
+
+	entries := rec.Entries()
+	assert.Len(t, entries, 2)
+	assert.Eq(t, "", entries[0].Response.Content.Text)
+	assert.Eq(t, 1000, entries[1].Response.Content.Size)
+	assert.Len(t, entries[1].Response.Content.Text, 100)
+	assert.StrContains(t, entries[1].Comment, "truncated to 100 bytes")
+
+	// without body
+	rec = har.NewRecorder(har.WithoutBody())
+	resp, err = greq.New(ts.URL).Use(rec).GetDo("/large")
+	assert.NoErr(t, err)
+	assert.Len(t, resp.BodyString(), 1000)
+	assert.Eq(t, "", rec.Entries()[0].Response.Content.Text)
+}
+
+func TestRecorder_bodyTime(t *testing.T) {
+	ts := newTestServer(t)
+	rec := har.NewRecorder()
+
+	resp, err := greq.New(ts.URL).Use(rec).GetDo("/slow")
+	assert.NoErr(t, err)
+	assert.Eq(t, "first,last", resp.BodyString())
+
+	// the time to read the whole body is included
+	e := rec.Entries()[0]
+	assert.Eq(t, "first,last", e.Response.Content.Text)
+	assert.True(t, e.Timings.Receive >= 100, e.Timings.Receive)
+	assert.True(t, e.Time >= 100, e.Time)
+}
+
+func TestRecorder_pendingEntry(t *testing.T) {
+	ts := newTestServer(t)
+	rec := har.NewRecorder()
+
+	resp, err := greq.New(ts.URL).Use(rec).GetDo("/slow")
+	assert.NoErr(t, err)
+
+	done := make(chan string)
+	go func() { done <- resp.BodyString() }()
+
+	// read the entries while the body is streaming
+	e := rec.HAR().Log.Entries[0]
+	assert.Eq(t, har.PendingComment, e.Comment)
+	assert.Eq(t, float64(0), e.Time)
+	for i := 0; i < 10; i++ {
+		_ = rec.HAR()
+		_ = rec.Entries()
+		time.Sleep(2 * time.Millisecond)
+	}
+	assert.Eq(t, "first,last", <-done)
+
+	// the returned entries are copies
+	assert.Eq(t, har.PendingComment, e.Comment)
+	e = rec.Entries()[0]
+	assert.Eq(t, "", e.Comment)
+	assert.Eq(t, "first,last", e.Response.Content.Text)
+
+	e.Response.Headers[0].Value = "changed"
+	assert.NotEq(t, "changed", rec.Entries()[0].Response.Headers[0].Value)
+}
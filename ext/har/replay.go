@@ -0,0 +1,179 @@
+package har
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrNoEntry is returned by Replayer when no entry matched the request
+var ErrNoEntry = errors.New("har: no entry matched the request")
+
+// ErrTruncatedBody is returned by Replayer when the response body of the matched entry is truncated on record
+var ErrTruncatedBody = errors.New("har: the recorded response body is truncated")
+
+// ReplayOptionFn is a function to configure the Replayer
+type ReplayOptionFn func(rp *Replayer)
+
+// WithIgnoreBody don't match the request body
+func WithIgnoreBody() ReplayOptionFn {
+	return func(rp *Replayer) {
+		rp.ignoreBody = true
+	}
+}
+
+// Replayer is a httpreq.Doer to replay the HAR entries without hitting the network.
+//
+// Entries are matched by method, URL(query order-insensitive) and request body.
+// When multiple entries matched, they are returned in order, then the least used one is reused.
+//
+// The entry with the response body truncated on record(see WithMaxBodySize) is not replayed,
+// it returns an error wrapping ErrTruncatedBody. Record it with a larger max body size.
+//
+// Usage:
+//
+//	rp, err := har.LoadReplayer("testdata/api.har")
+//	client := greq.New("https://example.com").Doer(rp)
+type Replayer struct {
+	mu      sync.Mutex
+	entries []*Entry
+	// hits count of each entry
+	hits []int
+
+	ignoreBody bool
+}
+
+// NewReplayer create a Replayer from HAR
+func NewReplayer(h *HAR, optFns ...ReplayOptionFn) *Replayer {
+	rp := &Replayer{entries: h.Log.Entries, hits: make([]int, len(h.Log.Entries))}
+	for _, fn := range optFns {
+		fn(rp)
+	}
+	return rp
+}
+
+// LoadReplayer create a Replayer from HAR file
+func LoadReplayer(path string, optFns ...ReplayOptionFn) (*Replayer, error) {
+	h, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayer(h, optFns...), nil
+}
+
+// Do implements the httpreq.Doer
+func (rp *Replayer) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if !rp.ignoreBody && req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+	}
+
+	entry := rp.match(req, body)
+	if entry == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoEntry, req.Method, req.URL.String())
+	}
+	return buildResponse(req, entry.Response)
+}
+
+// match find the first entry with least hits
+func (rp *Replayer) match(req *http.Request, body []byte) *Entry {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	found := -1
+	for i, e := range rp.entries {
+		if !rp.matchEntry(e.Request, req, body) {
+			continue
+		}
+		if found < 0 || rp.hits[i] < rp.hits[found] {
+			found = i
+		}
+		// not used yet, return it in order
+		if rp.hits[i] == 0 {
+			break
+		}
+	}
+
+	if found < 0 {
+		return nil
+	}
+	rp.hits[found]++
+	return rp.entries[found]
+}
+
+func (rp *Replayer) matchEntry(er *Request, req *http.Request, body []byte) bool {
+	if er == nil || !strings.EqualFold(er.Method, req.Method) {
+		return false
+	}
+
+	eu, err := url.Parse(er.URL)
+	if err != nil || !sameURL(eu, req.URL) {
+		return false
+	}
+
+	if rp.ignoreBody {
+		return true
+	}
+	if er.PostData == nil {
+		return len(body) == 0
+	}
+
+	want, err := decodeBody(er.PostData.Text, er.PostData.Encoding)
+	return err == nil && bytes.Equal(want, body)
+}
+
+// sameURL compare URL without the query order
+func sameURL(a, b *url.URL) bool {
+	if a.Scheme != b.Scheme || a.Host != b.Host || a.Path != b.Path {
+		return false
+	}
+	return reflect.DeepEqual(a.Query(), b.Query())
+}
+
+func buildResponse(req *http.Request, hr *Response) (*http.Response, error) {
+	if hr == nil || hr.Status == 0 {
+		return nil, fmt.Errorf("har: the entry has no response for %s %s", req.Method, req.URL.String())
+	}
+	if hr.Content.Truncated {
+		return nil, fmt.Errorf("%w: %s %s, size %d", ErrTruncatedBody, req.Method, req.URL.String(), hr.Content.Size)
+	}
+
+	body, err := decodeBody(hr.Content.Text, hr.Content.Encoding)
+	if err != nil {
+		return nil, fmt.Errorf("har: decode response body error: %w", err)
+	}
+
+	proto := hr.HTTPVersion
+	if proto == "" {
+		proto = "HTTP/1.1"
+	}
+	major, minor, _ := http.ParseHTTPVersion(proto)
+
+	resp := &http.Response{
+		Status:        strconv.Itoa(hr.Status) + " " + hr.StatusText,
+		StatusCode:    hr.Status,
+		Proto:         proto,
+		ProtoMajor:    major,
+		ProtoMinor:    minor,
+		Header:        make(http.Header, len(hr.Headers)),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+	for _, nv := range hr.Headers {
+		resp.Header.Add(nv.Name, nv.Value)
+	}
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return resp, nil
+}
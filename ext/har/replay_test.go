@@ -0,0 +1,83 @@
+package har_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq"
+	"github.com/gookit/greq/ext/har"
+)
+
+func TestReplayer(t *testing.T) {
+	ts := newTestServer(t)
+	rec := har.NewRecorder()
+	client := greq.New(ts.URL).Use(rec)
+
+	// the body is recorded on read
+	resp, err := client.GetDo("/items?a=1&b=2")
+	assert.NoErr(t, err)
+	resp.BodyString()
+	resp, err = client.PostDo("/items", greq.WithBody("one"))
+	assert.NoErr(t, err)
+	resp.BodyString()
+	resp, err = client.GetDo("/png")
+	assert.NoErr(t, err)
+	resp.BodyString()
+	ts.Close()
+
+	rp := har.NewReplayer(rec.HAR())
+	replay := greq.New(ts.URL).Doer(rp)
+
+	// query order-insensitive
+	resp, err = replay.GetDo("/items?b=2&a=1")
+	assert.NoErr(t, err)
+	assert.Eq(t, 200, resp.StatusCode)
+	assert.Eq(t, "application/json", resp.ContentType())
+	assert.Eq(t, `{"path": "/items"}`, resp.BodyString())
+
+	resp, err = replay.PostDo("/items", greq.WithBody("one"))
+	assert.NoErr(t, err)
+	assert.Eq(t, 200, resp.StatusCode)
+
+	// binary body
+	resp, err = replay.GetDo("/png")
+	assert.NoErr(t, err)
+	assert.Eq(t, "\x89PNG\xff", resp.BodyString())
+
+	// body not matched
+	_, err = replay.PostDo("/items", greq.WithBody("two"))
+	assert.True(t, errors.Is(err, har.ErrNoEntry))
+
+	// ignore body
+	replay = greq.New(ts.URL).Doer(har.NewReplayer(rec.HAR(), har.WithIgnoreBody()))
+	resp, err = replay.PostDo("/items", greq.WithBody("two"))
+	assert.NoErr(t, err)
+	assert.Eq(t, 200, resp.StatusCode)
+}
+
+func TestReplayer_truncatedBody(t *testing.T) {
+	ts := newTestServer(t)
+	rec := har.NewRecorder(har.WithMaxBodySize(100))
+	resp, err := greq.New(ts.URL).Use(rec).GetDo("/large")
+	assert.NoErr(t, err)
+	assert.Len(t, resp.BodyString(), 1000)
+	assert.True(t, rec.Entries()[0].Response.Content.Truncated)
+
+	// the flag is kept on save and load
+	h, err := har.Parse(mustMarshal(t, rec.HAR()))
+	assert.NoErr(t, err)
+	assert.True(t, h.Log.Entries[0].Response.Content.Truncated)
+
+	_, err = greq.New(ts.URL).Doer(har.NewReplayer(h)).GetDo("/large")
+	assert.ErrIs(t, err, har.ErrTruncatedBody)
+	assert.ErrMsgContains(t, err, "size 1000")
+}
+
+func mustMarshal(t *testing.T, h *har.HAR) []byte {
+	var buf bytes.Buffer
+	_, err := h.WriteTo(&buf)
+	assert.NoErr(t, err)
+	return buf.Bytes()
+}
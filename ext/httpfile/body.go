@@ -0,0 +1,245 @@
+package httpfile
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gookit/greq/internal/bodyprovider"
+)
+
+// BodyProvider provides body content for send the HTTPRequest.
+//
+// It is structurally compatible with greq.BodyProvider, so the value can
+// be assigned to greq.Options.Provider directly.
+type BodyProvider interface {
+	// ContentType returns the Content-Type of the body.
+	ContentType() string
+	// Body returns the io.Reader body.
+	Body() (io.Reader, error)
+}
+
+// MultipartPart is one part of a multipart/form-data body in .http file.
+//
+// Format:
+//
+//	--boundary
+//	Content-Disposition: form-data; name="file"; filename="logo.png"
+//	Content-Type: image/png
+//
+//	< ./logo.png
+type MultipartPart struct {
+	Headers Headers
+	// Body inline content of the part.
+	Body string
+	// FilePath read part content from the file, parsed from `< path` line.
+	FilePath string
+	// FileVars is true on `<@ path`, will apply vars to the file contents.
+	FileVars bool
+}
+
+// parseInclude parse file include line: `< path` or `<@ path`
+func parseInclude(content string) (path string, withVars, ok bool) {
+	line := strings.TrimSpace(content)
+	if line == "" || line[0] != '<' || strings.ContainsRune(line, '\n') {
+		return "", false, false
+	}
+
+	line = line[1:]
+	if strings.HasPrefix(line, "@") {
+		withVars = true
+		line = line[1:]
+	}
+
+	// must have a space after `<`, eg: `< ./payload.json`. `<xml>` is a body.
+	if line == "" || (line[0] != ' ' && line[0] != '\t') {
+		return "", false, false
+	}
+	return strings.TrimSpace(line), withVars, true
+}
+
+// BodyFile get the body file path when the body is a file include line.
+//
+//   - `< ./payload.json`  send the file contents as body.
+//   - `<@ ./tmpl.json`  apply variables to the file contents, then send it.
+//
+// The returned path is resolved against BaseDir.
+func (req *HTTPRequest) BodyFile() (path string, withVars bool) {
+	path, withVars, ok := parseInclude(req.Body)
+	if !ok {
+		return "", false
+	}
+	return req.resolvePath(path), withVars
+}
+
+// MultipartBoundary get the boundary from the Content-Type header.
+// Returns empty if the request is not multipart/form-data.
+func (req *HTTPRequest) MultipartBoundary() string {
+	cType := req.HeaderValue("Content-Type")
+	if cType == "" {
+		return ""
+	}
+
+	mType, params, err := mime.ParseMediaType(cType)
+	if err != nil || mType != "multipart/form-data" {
+		return ""
+	}
+	return params["boundary"]
+}
+
+// MultipartParts parse the multipart/form-data body to parts.
+// Returns nil if the request is not multipart or has no boundary.
+//
+// The MultipartPart.FilePath is resolved against BaseDir.
+func (req *HTTPRequest) MultipartParts() []*MultipartPart {
+	boundary := req.MultipartBoundary()
+	if boundary == "" {
+		return nil
+	}
+
+	parts := parseMultipart(req.Body, boundary)
+	for _, part := range parts {
+		if part.FilePath != "" {
+			part.FilePath = req.resolvePath(part.FilePath)
+		}
+	}
+	return parts
+}
+
+func parseMultipart(body, boundary string) []*MultipartPart {
+	var parts []*MultipartPart
+	var part *MultipartPart
+	var inHeaders bool
+	var content []string
+
+	delimiter := "--" + boundary
+	finishPart := func() {
+		if part != nil {
+			part.Body = strings.Join(content, "\n")
+			if path, withVars, ok := parseInclude(part.Body); ok {
+				part.FilePath, part.FileVars = path, withVars
+			}
+			parts = append(parts, part)
+		}
+		part, content = nil, nil
+	}
+
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimSpace(line)
+
+		// 分隔行 --boundary 或结束行 --boundary--
+		if trimmed == delimiter || trimmed == delimiter+"--" {
+			finishPart()
+			if trimmed == delimiter {
+				part = &MultipartPart{Headers: make(Headers, 0)}
+				inHeaders = true
+			}
+			continue
+		}
+		if part == nil {
+			continue
+		}
+
+		if inHeaders {
+			if trimmed == "" {
+				inHeaders = false
+			} else if colonIndex := strings.Index(trimmed, ":"); colonIndex > 0 {
+				key := strings.TrimSpace(trimmed[:colonIndex])
+				part.Headers.Add(key, strings.TrimSpace(trimmed[colonIndex+1:]))
+			}
+			continue
+		}
+		content = append(content, line)
+	}
+
+	// 没有结束行时，保留最后一个 part
+	finishPart()
+	return parts
+}
+
+// BodyProvider build the body provider for send the request. Will return nil if the body is empty.
+//
+//   - body is `< path`: stream the file contents.
+//   - body is `<@ path`: read the file and apply vars from ApplyVars()
+//   - Content-Type is multipart/form-data: stream parts, `< path` parts are read from file.
+//   - otherwise: use the Body text.
+//
+// Relative paths are resolved against BaseDir.
+func (req *HTTPRequest) BodyProvider() (BodyProvider, error) {
+	if req.Body == "" {
+		return nil, nil
+	}
+
+	if boundary := req.MultipartBoundary(); boundary != "" {
+		return req.multipartProvider(boundary, req.MultipartParts())
+	}
+
+	path, withVars := req.BodyFile()
+	if path == "" {
+		return bodyprovider.NewReader(strings.NewReader(req.Body)), nil
+	}
+	if !withVars {
+		return bodyprovider.NewFile(path, ""), nil
+	}
+
+	contents, err := req.renderFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return bodyprovider.NewReader(strings.NewReader(contents)), nil
+}
+
+func (req *HTTPRequest) multipartProvider(boundary string, parts []*MultipartPart) (BodyProvider, error) {
+	bpParts := make([]bodyprovider.Part, 0, len(parts))
+
+	for _, part := range parts {
+		bpPart := bodyprovider.Part{
+			Header:  make(textproto.MIMEHeader, len(part.Headers)),
+			Content: part.Body,
+		}
+		for _, h := range part.Headers {
+			bpPart.Header.Add(h.Name, h.Value)
+		}
+
+		if part.FilePath != "" {
+			if part.FileVars {
+				contents, err := req.renderFile(part.FilePath)
+				if err != nil {
+					return nil, err
+				}
+				bpPart.Content = contents
+			} else {
+				bpPart.FilePath = part.FilePath
+			}
+		}
+		bpParts = append(bpParts, bpPart)
+	}
+
+	return bodyprovider.NewMultipartStream(boundary, bpParts), nil
+}
+
+// renderFile read the file contents and apply vars.
+func (req *HTTPRequest) renderFile(path string) (string, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read body file error: %w", err)
+	}
+
+	contents := string(bs)
+	if strings.Contains(contents, "${") {
+		contents = renderVars(contents, req.vars, req.BaseDir)
+	}
+	return contents, nil
+}
+
+func (req *HTTPRequest) resolvePath(path string) string {
+	if req.BaseDir == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(req.BaseDir, path)
+}
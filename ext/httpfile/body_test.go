@@ -0,0 +1,112 @@
+package httpfile_test
+
+import (
+	"io"
+	"mime/multipart"
+	"path/filepath"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq/ext/httpfile"
+)
+
+func readProvider(t *testing.T, bp httpfile.BodyProvider) string {
+	r, err := bp.Body()
+	assert.NoErr(t, err)
+	bs, err := io.ReadAll(r)
+	assert.NoErr(t, err)
+	if c, ok := r.(io.Closer); ok {
+		_ = c.Close()
+	}
+	return string(bs)
+}
+
+func TestHTTPRequest_BodyFile(t *testing.T) {
+	hf, err := httpfile.ParseHTTPFile("testdata/body-req.http")
+	assert.NoErr(t, err)
+	assert.Len(t, hf.Requests, 3)
+
+	t.Run("file body", func(t *testing.T) {
+		req := hf.FindByName("post file body")
+		assert.Eq(t, "testdata", req.BaseDir)
+
+		path, withVars := req.BodyFile()
+		assert.Eq(t, filepath.Join("testdata", "payload.json"), path)
+		assert.False(t, withVars)
+
+		bp, err := req.BodyProvider()
+		assert.NoErr(t, err)
+		assert.Eq(t, "{\"name\": \"gookit\"}\n", readProvider(t, bp))
+	})
+
+	t.Run("tmpl body", func(t *testing.T) {
+		req := hf.FindByName("post tmpl body")
+		req.ApplyVars(map[string]string{"name": "inhere"})
+
+		_, withVars := req.BodyFile()
+		assert.True(t, withVars)
+
+		bp, err := req.BodyProvider()
+		assert.NoErr(t, err)
+		assert.Eq(t, "{\"name\": \"inhere\"}\n", readProvider(t, bp))
+	})
+
+	t.Run("not include", func(t *testing.T) {
+		req, err := httpfile.ParseRequest("POST /post\nContent-Type: text/xml\n\n<xml>data</xml>")
+		assert.NoErr(t, err)
+
+		path, _ := req.BodyFile()
+		assert.Empty(t, path)
+
+		bp, err := req.BodyProvider()
+		assert.NoErr(t, err)
+		assert.Eq(t, "<xml>data</xml>", readProvider(t, bp))
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		req, err := httpfile.ParseRequest("POST /post\n\n<@ ./not-exists.json")
+		assert.NoErr(t, err)
+
+		_, err = req.BodyProvider()
+		assert.Err(t, err)
+	})
+}
+
+func TestHTTPRequest_MultipartParts(t *testing.T) {
+	hf, err := httpfile.ParseHTTPFile("testdata/body-req.http")
+	assert.NoErr(t, err)
+
+	req := hf.FindByName("upload file")
+	assert.Eq(t, "WebAppBoundary", req.MultipartBoundary())
+
+	parts := req.MultipartParts()
+	assert.Len(t, parts, 2)
+	assert.Eq(t, `form-data; name="name"`, parts[0].Headers.Get("Content-Disposition"))
+	assert.Eq(t, "inhere", parts[0].Body)
+	assert.Empty(t, parts[0].FilePath)
+	assert.Eq(t, "image/png", parts[1].Headers.Get("Content-Type"))
+	assert.Eq(t, filepath.Join("testdata", "logo.png"), parts[1].FilePath)
+
+	bp, err := req.BodyProvider()
+	assert.NoErr(t, err)
+	assert.Eq(t, "multipart/form-data; boundary=WebAppBoundary", bp.ContentType())
+
+	r, err := bp.Body()
+	assert.NoErr(t, err)
+	mr := multipart.NewReader(r, "WebAppBoundary")
+
+	part, err := mr.NextPart()
+	assert.NoErr(t, err)
+	assert.Eq(t, "name", part.FormName())
+	bs, _ := io.ReadAll(part)
+	assert.Eq(t, "inhere", string(bs))
+
+	part, err = mr.NextPart()
+	assert.NoErr(t, err)
+	assert.Eq(t, "logo.png", part.FileName())
+	bs, _ = io.ReadAll(part)
+	assert.Eq(t, "logo-bytes", string(bs))
+
+	// not multipart
+	assert.Nil(t, hf.FindByName("post file body").MultipartParts())
+}
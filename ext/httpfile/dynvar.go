@@ -0,0 +1,248 @@
+package httpfile
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VarFunc is a dynamic variable function. args are parsed from `${$name(arg1, arg2)}`
+// by split on ",", the spaces around each arg are kept.
+//
+// NOTE: the args can't contain ")", there is no quoting for it.
+type VarFunc func(args []string) (string, error)
+
+var (
+	varFuncMu sync.RWMutex
+	// built-in dynamic variable functions
+	varFuncs = map[string]VarFunc{
+		"uuid":         uuidVar,
+		"timestamp":    timestampVar,
+		"isoTimestamp": isoTimestampVar,
+		"randomInt":    randomIntVar,
+		"randomString": randomStringVar,
+		"base64":       base64Var,
+		"env":          envVar,
+		"file":         fileVar,
+	}
+
+	// dynamic var format: ${$name} or ${$name(arg1, arg2)}
+	dynVarReg = regexp.MustCompile(`\$\{\s*\$(\w+)(?:\(([^)]*)\))?\s*\}`)
+)
+
+// RegisterVarFunc register a custom dynamic variable function.
+// Will override the exists function with same name.
+//
+// Usage:
+//
+//	httpfile.RegisterVarFunc("tenant", func(args []string) (string, error) {
+//		return "acme", nil
+//	})
+//
+//	// use in .http file, Client.SendRaw or gbench body: ${$tenant}
+func RegisterVarFunc(name string, fn VarFunc) {
+	varFuncMu.Lock()
+	varFuncs[name] = fn
+	varFuncMu.Unlock()
+}
+
+// RenderVars render user vars and dynamic vars in the text.
+//
+// Dynamic vars are evaluated on each call:
+//
+//   - ${$uuid} random UUID v4
+//   - ${$timestamp} current unix timestamp in seconds
+//   - ${$isoTimestamp} current UTC time in ISO-8601 (RFC3339) format
+//   - ${$randomInt(min,max)} random int in [min, max]. default is [0, 1000]
+//   - ${$randomString(n)} random alphanumeric string with length n. default is 8
+//   - ${$base64(text)} base64 encode the text
+//   - ${$env(NAME)} value of the environment variable
+//   - ${$file(path)} contents of the file, relative path is based on the workdir.
+//     On render by HTTPRequest methods, it is based on the HTTPRequest.BaseDir.
+//
+// Dynamic vars are evaluated only in the template text, not in the user var values. User vars can be
+// used in dynamic var args, eg: ${$base64(${user}:${pass})}, the args are split by "," before replace them.
+// The args of a dynamic var can't contain ")", the var is kept as is on it.
+// If a dynamic var is not registered or returns error, the text is kept as is.
+func RenderVars(s string, varMap map[string]string) string {
+	return renderVars(s, varMap, "")
+}
+
+// renderVars render the vars, the relative path of ${$file(path)} is resolved against the baseDir.
+func renderVars(s string, varMap map[string]string, baseDir string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+
+	// the user var values are not evaluated as dynamic vars, they may come from env files,
+	// imported documents or responses. eg: a value "${$file(/etc/passwd)}"
+	render := literalVarsRender(varMap)
+	if !strings.Contains(s, "${$") {
+		return render(s)
+	}
+
+	var sb strings.Builder
+	var last int
+	for _, loc := range dynVarReg.FindAllStringSubmatchIndex(s, -1) {
+		sb.WriteString(render(s[last:loc[0]]))
+		sb.WriteString(renderDynVar(s, loc, render, baseDir))
+		last = loc[1]
+	}
+	sb.WriteString(render(s[last:]))
+	return sb.String()
+}
+
+// literalVarsRender returns a func to replace the user vars. The values contain dynamic vars are kept
+// as is, they are replaced by placeholders on render, so not parsed by rpl.
+func literalVarsRender(varMap map[string]string) func(s string) string {
+	// the rpl may change the map values, use a copy
+	vm := make(map[string]string, len(varMap))
+	var pairs []string
+	for name, val := range varMap {
+		if strings.Contains(val, "${$") {
+			holder := "\x00greq-var-" + strconv.Itoa(len(pairs)/2) + "\x00"
+			pairs = append(pairs, holder, val)
+			val = holder
+		}
+		vm[name] = val
+	}
+
+	if len(pairs) == 0 {
+		return func(s string) string { return rpl.RenderSimple(s, vm) }
+	}
+	restorer := strings.NewReplacer(pairs...)
+	return func(s string) string { return restorer.Replace(rpl.RenderSimple(s, vm)) }
+}
+
+// renderDynVar render a dynamic var matched at the loc of the text, the user vars in args are replaced.
+func renderDynVar(s string, loc []int, render func(s string) string, baseDir string) string {
+	expr, name := s[loc[0]:loc[1]], s[loc[2]:loc[3]]
+	// don't hold the lock on call, the func may register other funcs
+	varFuncMu.RLock()
+	fn, ok := varFuncs[name]
+	varFuncMu.RUnlock()
+	if !ok {
+		return expr
+	}
+
+	var args []string
+	if loc[4] >= 0 && strings.TrimSpace(s[loc[4]:loc[5]]) != "" {
+		args = strings.Split(s[loc[4]:loc[5]], ",")
+		for i, arg := range args {
+			args[i] = render(arg)
+		}
+	}
+	if name == "file" && len(args) > 0 && baseDir != "" {
+		if path := strings.TrimSpace(args[0]); !filepath.IsAbs(path) {
+			args[0] = filepath.Join(baseDir, path)
+		}
+	}
+
+	val, err := fn(args)
+	if err != nil {
+		return expr
+	}
+	return val
+}
+
+func uuidVar(_ []string) (string, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		return "", err
+	}
+
+	u[6] = (u[6] & 0x0f) | 0x40 // version 4
+	u[8] = (u[8] & 0x3f) | 0x80 // variant RFC 4122
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:]), nil
+}
+
+func timestampVar(_ []string) (string, error) {
+	return strconv.FormatInt(time.Now().Unix(), 10), nil
+}
+
+func isoTimestampVar(_ []string) (string, error) {
+	return time.Now().UTC().Format(time.RFC3339), nil
+}
+
+func randomIntVar(args []string) (string, error) {
+	minV, maxV := int64(0), int64(1000)
+	if len(args) > 0 {
+		if len(args) != 2 {
+			return "", fmt.Errorf("$randomInt: want 2 args (min, max), got %d", len(args))
+		}
+
+		var err error
+		if minV, err = strconv.ParseInt(strings.TrimSpace(args[0]), 10, 64); err != nil {
+			return "", err
+		}
+		if maxV, err = strconv.ParseInt(strings.TrimSpace(args[1]), 10, 64); err != nil {
+			return "", err
+		}
+		if maxV < minV {
+			return "", fmt.Errorf("$randomInt: max must be >= min")
+		}
+	}
+
+	// compute max-min+1 by big.Int, it may overflow int64 on a wide range
+	rng := new(big.Int).Sub(big.NewInt(maxV), big.NewInt(minV))
+	n, err := rand.Int(rand.Reader, rng.Add(rng, big.NewInt(1)))
+	if err != nil {
+		return "", err
+	}
+	return n.Add(n, big.NewInt(minV)).String(), nil
+}
+
+const alphaNum = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+func randomStringVar(args []string) (string, error) {
+	length := 8
+	if len(args) > 0 {
+		var err error
+		if length, err = strconv.Atoi(strings.TrimSpace(args[0])); err != nil || length < 0 {
+			return "", fmt.Errorf("$randomString: invalid length %q", args[0])
+		}
+	}
+
+	bs := make([]byte, length)
+	charNum := big.NewInt(int64(len(alphaNum)))
+	for i := range bs {
+		n, err := rand.Int(rand.Reader, charNum)
+		if err != nil {
+			return "", err
+		}
+		bs[i] = alphaNum[n.Int64()]
+	}
+	return string(bs), nil
+}
+
+func base64Var(args []string) (string, error) {
+	// the text may contain ",", join it back
+	return base64.StdEncoding.EncodeToString([]byte(strings.Join(args, ","))), nil
+}
+
+func envVar(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("$env: missing the env name")
+	}
+	return os.Getenv(strings.TrimSpace(args[0])), nil
+}
+
+func fileVar(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("$file: missing the file path")
+	}
+
+	bs, err := os.ReadFile(strings.TrimSpace(args[0]))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(bs), "\r\n"), nil
+}
@@ -0,0 +1,122 @@
+package httpfile_test
+
+import (
+	"encoding/base64"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gookit/goutil/testutil"
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq/ext/httpfile"
+)
+
+func TestRenderVars(t *testing.T) {
+	t.Run("uuid", func(t *testing.T) {
+		s1 := httpfile.RenderVars("${$uuid}", nil)
+		s2 := httpfile.RenderVars("${ $uuid }", nil)
+		assert.Len(t, s1, 36)
+		assert.Eq(t, "4", s1[14:15])
+		assert.NotEq(t, s1, s2)
+	})
+
+	t.Run("timestamp", func(t *testing.T) {
+		ts, err := strconv.ParseInt(httpfile.RenderVars("${$timestamp}", nil), 10, 64)
+		assert.NoErr(t, err)
+		assert.True(t, ts >= time.Now().Unix()-1)
+
+		_, err = time.Parse(time.RFC3339, httpfile.RenderVars("${$isoTimestamp}", nil))
+		assert.NoErr(t, err)
+	})
+
+	t.Run("random", func(t *testing.T) {
+		for i := 0; i < 20; i++ {
+			n, err := strconv.Atoi(httpfile.RenderVars("${$randomInt(5, 7)}", nil))
+			assert.NoErr(t, err)
+			assert.True(t, n >= 5 && n <= 7)
+		}
+		assert.Len(t, httpfile.RenderVars("${$randomString(12)}", nil), 12)
+		assert.Len(t, httpfile.RenderVars("${$randomString}", nil), 8)
+
+		// invalid args, keep as is
+		assert.Eq(t, "${$randomInt(9,1)}", httpfile.RenderVars("${$randomInt(9,1)}", nil))
+
+		// wide range overflows int64 on max-min+1
+		for _, expr := range []string{
+			"${$randomInt(0,9223372036854775807)}",
+			"${$randomInt(-9223372036854775808,9223372036854775807)}",
+		} {
+			_, err := strconv.ParseInt(httpfile.RenderVars(expr, nil), 10, 64)
+			assert.NoErr(t, err)
+		}
+	})
+
+	t.Run("base64 with user vars", func(t *testing.T) {
+		s := httpfile.RenderVars("Basic ${$base64(${user}:${pass})}", map[string]string{
+			"user": "inhere",
+			"pass": "a,b",
+		})
+		assert.Eq(t, "Basic "+base64.StdEncoding.EncodeToString([]byte("inhere:a,b")), s)
+	})
+
+	t.Run("dynamic var in user var value", func(t *testing.T) {
+		testutil.MockEnvValue("GREQ_TEST_TOKEN", "abc123", func(_ string) {
+			vars := map[string]string{"home": "${$env(HOME)}", "token": "${$env(GREQ_TEST_TOKEN)}"}
+			assert.Eq(t, "${$env(HOME)}|${$env(GREQ_TEST_TOKEN)}", httpfile.RenderVars("${home}|${token}", vars))
+			// in the dynamic var args
+			assert.Eq(t, base64.StdEncoding.EncodeToString([]byte("${$env(GREQ_TEST_TOKEN)}")),
+				httpfile.RenderVars("${$base64(${token})}", vars))
+			assert.Eq(t, "abc123/${$env(HOME)}", httpfile.RenderVars("${$env(GREQ_TEST_TOKEN)}/${home}", vars))
+		})
+	})
+
+	t.Run("env and file", func(t *testing.T) {
+		testutil.MockEnvValue("GREQ_TEST_TOKEN", "abc123", func(_ string) {
+			assert.Eq(t, "token=abc123", httpfile.RenderVars("token=${$env(GREQ_TEST_TOKEN)}", nil))
+		})
+
+		assert.Eq(t, "{\"name\": \"gookit\"}", httpfile.RenderVars("${$file(testdata/payload.json)}", nil))
+		assert.Eq(t, "${$file(not-exist.txt)}", httpfile.RenderVars("${$file(not-exist.txt)}", nil))
+	})
+
+	t.Run("custom func", func(t *testing.T) {
+		assert.Eq(t, "${$tenant}", httpfile.RenderVars("${$tenant}", nil))
+
+		httpfile.RegisterVarFunc("tenant", func(args []string) (string, error) {
+			if len(args) > 0 {
+				return "acme-" + args[0], nil
+			}
+			return "acme", nil
+		})
+		assert.Eq(t, "acme/acme-dev", httpfile.RenderVars("${$tenant}/${$tenant(dev)}", nil))
+
+		// the func can register other funcs
+		httpfile.RegisterVarFunc("lazy", func(args []string) (string, error) {
+			httpfile.RegisterVarFunc("lazy2", func(args []string) (string, error) { return "v2", nil })
+			return "v1", nil
+		})
+		assert.Eq(t, "v1-v2", httpfile.RenderVars("${$lazy}-${$lazy2}", nil))
+	})
+}
+
+func TestHTTPRequest_ApplyVars_dynamic(t *testing.T) {
+	req, err := httpfile.ParseRequest(`POST https://example.com/users/${id}?t=${$timestamp}
+X-Request-Id: ${$uuid}
+
+{"name": "${name}", "code": "${$randomString(6)}"}`)
+	assert.NoErr(t, err)
+
+	req.ApplyVars(map[string]string{"id": "23", "name": "inhere"})
+	assert.StrContains(t, req.URL, "https://example.com/users/23?t=")
+	assert.NotContains(t, req.URL, "${")
+	assert.Len(t, req.Headers.Get("X-Request-Id"), 36)
+	assert.StrContains(t, req.Body, `"name": "inhere"`)
+	assert.NotContains(t, req.Body, "${")
+
+	// the $file path is relative to the .http file dir
+	req, err = httpfile.ParseRequest("POST https://example.com/users\n\n{\"user\": ${$file(payload.json)}}")
+	assert.NoErr(t, err)
+	req.BaseDir = "testdata"
+	req.ApplyVars(nil)
+	assert.Eq(t, `{"user": {"name": "gookit"}}`, req.Body)
+}
@@ -0,0 +1,80 @@
+package httpfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DefaultEnvFile the default env file name, same as the IDE http client.
+const DefaultEnvFile = "http-client.env.json"
+
+// DefaultEnvName the env name used for imported variables
+const DefaultEnvName = "dev"
+
+// EnvFile is the IDE http client env file contents.
+//
+// Format:
+//
+//	{
+//		"dev": {"baseUrl": "http://localhost:8080"},
+//		"prod": {"baseUrl": "https://example.com"}
+//	}
+type EnvFile map[string]map[string]string
+
+// LoadEnvFile load the env file. the values will be converted to string.
+func LoadEnvFile(path string) (EnvFile, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]map[string]any
+	if err := json.Unmarshal(bs, &raw); err != nil {
+		return nil, fmt.Errorf("parse env file %q error: %w", path, err)
+	}
+
+	ef := make(EnvFile, len(raw))
+	for env, vars := range raw {
+		ef[env] = make(map[string]string, len(vars))
+		for key, val := range vars {
+			ef[env][key] = fmt.Sprint(val)
+		}
+	}
+	return ef, nil
+}
+
+// FindEnvFile find the DefaultEnvFile in the dir of the .http file. returns empty if not found.
+func FindEnvFile(httpFile string) string {
+	path := filepath.Join(filepath.Dir(httpFile), DefaultEnvFile)
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	return ""
+}
+
+// Vars get the variables of the env. returns nil if not exists.
+func (ef EnvFile) Vars(env string) map[string]string {
+	return ef[env]
+}
+
+// Envs get sorted env names
+func (ef EnvFile) Envs() []string {
+	names := make([]string, 0, len(ef))
+	for name := range ef {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Save the env file as indented JSON.
+func (ef EnvFile) Save(path string) error {
+	bs, err := json.MarshalIndent(ef, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(bs, '\n'), 0644)
+}
@@ -0,0 +1,187 @@
+package httpfile
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// String render the request to canonical .http text.
+//
+// Format:
+//
+//	### Name
+//	# comments
+//	METHOD URL
+//	Header: value
+//
+//	body
+func (req *HTTPRequest) String() string {
+	var sb strings.Builder
+	req.format(&sb, req.Name != "", 0)
+	return sb.String()
+}
+
+// WriteTo write the canonical .http text of the request to w. implements io.WriterTo
+func (req *HTTPRequest) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, req.String())
+	return int64(n), err
+}
+
+// format the request.
+//   - withSep: write the `###` line even if the name is empty.
+//   - skipComments: skip the first N comments, they are file level comments.
+func (req *HTTPRequest) format(sb *strings.Builder, withSep bool, skipComments int) {
+	if withSep || req.Name != "" {
+		sb.WriteString("###")
+		if req.Name != "" {
+			sb.WriteByte(' ')
+			sb.WriteString(req.Name)
+		}
+		sb.WriteByte('\n')
+	}
+
+	comments, bcs := req.Comments, req.bodyComments
+	if n := len(comments) - len(bcs); n >= 0 && isBodyComments(comments[n:], bcs) {
+		comments = comments[:n]
+	} else {
+		bcs = nil
+	}
+
+	for i, line := range comments {
+		if i >= skipComments {
+			sb.WriteString(strings.TrimSpace(line))
+			sb.WriteByte('\n')
+		}
+	}
+
+	sb.WriteString(strings.ToUpper(req.Method))
+	sb.WriteByte(' ')
+	sb.WriteString(req.URL)
+	sb.WriteByte('\n')
+
+	for _, h := range req.Headers {
+		sb.WriteString(h.Name)
+		sb.WriteString(": ")
+		sb.WriteString(h.Value)
+		sb.WriteByte('\n')
+	}
+
+	if body := mergeBodyComments(req.Body, bcs); body != "" {
+		sb.WriteByte('\n')
+		sb.WriteString(body)
+		sb.WriteByte('\n')
+	}
+}
+
+// isBodyComments check the comments are the body comments, they may be changed by user.
+func isBodyComments(comments []string, bcs []bodyComment) bool {
+	for i, bc := range bcs {
+		if comments[i] != bc.line {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeBodyComments insert the body comments back to the body lines at their positions.
+func mergeBodyComments(body string, bcs []bodyComment) string {
+	if len(bcs) == 0 {
+		return body
+	}
+
+	var lines []string
+	if body != "" {
+		lines = strings.Split(body, "\n")
+	}
+	// the trailing blank lines of body are trimmed on parse, add them back
+	for len(lines) < bcs[len(bcs)-1].pos {
+		lines = append(lines, "")
+	}
+
+	var i int
+	out := make([]string, 0, len(lines)+len(bcs))
+	for _, bc := range bcs {
+		for ; i < bc.pos; i++ {
+			out = append(out, lines[i])
+		}
+		out = append(out, bc.line)
+	}
+	return strings.Join(append(out, lines[i:]...), "\n")
+}
+
+// Format render the HTTPFile to canonical .http text.
+// It keeps the comments, names and header order of the requests.
+func (hf *HTTPFile) Format() string {
+	var sb strings.Builder
+	for _, line := range hf.Comments {
+		sb.WriteString(strings.TrimSpace(line))
+		sb.WriteByte('\n')
+	}
+
+	for i, req := range hf.Requests {
+		if i > 0 || len(hf.Comments) > 0 {
+			sb.WriteByte('\n')
+		}
+		req.format(&sb, i > 0, fileCommentsPrefix(req.Comments, hf.Comments))
+	}
+	return sb.String()
+}
+
+// WriteTo write the canonical .http text to w. implements io.WriterTo
+func (hf *HTTPFile) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, hf.Format())
+	return int64(n), err
+}
+
+// fileCommentsPrefix check the request comments start with the file comments, return the length.
+func fileCommentsPrefix(reqComments, fileComments []string) int {
+	if len(fileComments) == 0 || len(reqComments) < len(fileComments) {
+		return 0
+	}
+
+	for i, line := range fileComments {
+		if reqComments[i] != line {
+			return 0
+		}
+	}
+	return len(fileComments)
+}
+
+// NewRequestFromHTTP create an HTTPRequest from the http.Request. eg: built by greq.Builder
+//
+// Headers are sorted by name. The request body will be read and reset, so the
+// http.Request can still be sent.
+func NewRequestFromHTTP(r *http.Request) (*HTTPRequest, error) {
+	req := &HTTPRequest{
+		Method:   r.Method,
+		URL:      r.URL.String(),
+		Headers:  make(Headers, 0, len(r.Header)),
+		Comments: make([]string, 0),
+	}
+
+	names := make([]string, 0, len(r.Header))
+	for name := range r.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, val := range r.Header[name] {
+			req.Headers.Add(name, val)
+		}
+	}
+
+	if r.Body != nil && r.Body != http.NoBody {
+		bs, err := io.ReadAll(r.Body)
+		_ = r.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		req.Body = string(bs)
+		r.Body = io.NopCloser(bytes.NewReader(bs))
+	}
+	return req, nil
+}
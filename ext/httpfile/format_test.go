@@ -0,0 +1,134 @@
+package httpfile_test
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq/ext/httpfile"
+)
+
+func TestHTTPRequest_String(t *testing.T) {
+	req, err := httpfile.ParseRequest(`### create user
+# the comment
+post https://example.com/users
+X-Tag: a
+Content-Type: application/json
+X-Tag: b
+
+{"name": "inhere"}`)
+	assert.NoErr(t, err)
+	assert.Eq(t, []string{"a", "b"}, req.Headers.Values("x-tag"))
+
+	want := `### create user
+# the comment
+POST https://example.com/users
+X-Tag: a
+Content-Type: application/json
+X-Tag: b
+
+{"name": "inhere"}
+`
+	assert.Eq(t, want, req.String())
+
+	buf := &bytes.Buffer{}
+	n, err := req.WriteTo(buf)
+	assert.NoErr(t, err)
+	assert.Eq(t, int64(len(want)), n)
+	assert.Eq(t, want, buf.String())
+}
+
+func TestHTTPFile_Format(t *testing.T) {
+	contents := `# global comment
+
+### first
+GET https://example.com/api1
+Accept: */*
+
+###
+# no name
+POST https://example.com/api2
+Content-Type: text/plain
+
+line1
+
+line2
+`
+	hf, err := httpfile.ParseFileContent(contents)
+	assert.NoErr(t, err)
+	assert.Eq(t, []string{"# global comment"}, hf.Comments)
+	assert.Eq(t, contents, hf.Format())
+
+	// round-trip is stable
+	hf2, err := httpfile.ParseFileContent(hf.Format())
+	assert.NoErr(t, err)
+	assert.Eq(t, hf.Format(), hf2.Format())
+	assert.Len(t, hf2.Requests, 2)
+	assert.Eq(t, "line1\n\nline2", hf2.Requests[1].Body)
+
+	// format the testdata files
+	hf, err = httpfile.ParseHTTPFile("testdata/body-req.http")
+	assert.NoErr(t, err)
+	hf2, err = httpfile.ParseFileContent(hf.Format())
+	assert.NoErr(t, err)
+	for i, req := range hf.Requests {
+		assert.Eq(t, req.String(), hf2.Requests[i].String())
+	}
+}
+
+func TestHTTPFile_Format_bodyHashLines(t *testing.T) {
+	contents := `### a
+# the comment
+POST http://x/y
+Content-Type: text/x-sh
+
+#!/bin/sh
+# comment in script
+echo hi
+
+### b
+GET http://x/z
+`
+	hf, err := httpfile.ParseFileContent(contents)
+	assert.NoErr(t, err)
+	assert.Len(t, hf.Requests, 2)
+	// the # lines in body are comments, but format them back to the body
+	assert.Eq(t, []string{"# the comment", "#!/bin/sh", "# comment in script"}, hf.Requests[0].Comments)
+	assert.Eq(t, "echo hi", hf.Requests[0].Body)
+	assert.Eq(t, contents, hf.Format())
+
+	req, err := httpfile.ParseRequest(hf.Requests[0].String())
+	assert.NoErr(t, err)
+	assert.Eq(t, hf.Requests[0].Comments, req.Comments)
+	assert.Eq(t, hf.Requests[0].Body, req.Body)
+	assert.Eq(t, hf.Requests[0].String(), req.String())
+
+	// comments after the body lines and the blank lines
+	contents = "POST http://x/y\n\nline1\n# c1\nline2\n\n# c2\n"
+	req, err = httpfile.ParseRequest(contents)
+	assert.NoErr(t, err)
+	assert.Eq(t, "line1\nline2", req.Body)
+	assert.Eq(t, contents, req.String())
+
+	// the comments changed by user, write them before the request line
+	req.Comments = []string{"# new"}
+	assert.Eq(t, "# new\nPOST http://x/y\n\nline1\nline2\n", req.String())
+}
+
+func TestNewRequestFromHTTP(t *testing.T) {
+	r, err := http.NewRequest("PUT", "https://example.com/users/1?v=2", strings.NewReader("body"))
+	assert.NoErr(t, err)
+	r.Header.Set("X-B", "b")
+	r.Header.Set("X-A", "a")
+
+	req, err := httpfile.NewRequestFromHTTP(r)
+	assert.NoErr(t, err)
+	assert.Eq(t, "PUT https://example.com/users/1?v=2\nX-A: a\nX-B: b\n\nbody\n", req.String())
+
+	// body can be read again
+	buf := &bytes.Buffer{}
+	_, _ = buf.ReadFrom(r.Body)
+	assert.Eq(t, "body", buf.String())
+}
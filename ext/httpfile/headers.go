@@ -0,0 +1,128 @@
+package httpfile
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Header is one header line of the request. eg: `Content-Type: application/json`
+type Header struct {
+	Name  string
+	Value string
+}
+
+// Headers is an ordered list of request headers. It keeps the original
+// order and letter case of the names, and allows repeated names.
+type Headers []Header
+
+// NewHeaders create Headers from name-value pairs.
+//
+// Usage:
+//
+//	hs := httpfile.NewHeaders("Content-Type", "application/json", "Accept", "*/*")
+func NewHeaders(pairs ...string) Headers {
+	hs := make(Headers, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		hs = append(hs, Header{Name: pairs[i], Value: pairs[i+1]})
+	}
+	return hs
+}
+
+// Len get the number of header lines
+func (hs Headers) Len() int { return len(hs) }
+
+// Has check the header name exists. name is case-insensitive.
+func (hs Headers) Has(name string) bool {
+	return hs.index(name) >= 0
+}
+
+// Get the first value of the header name. name is case-insensitive.
+func (hs Headers) Get(name string) string {
+	if i := hs.index(name); i >= 0 {
+		return hs[i].Value
+	}
+	return ""
+}
+
+// Values get all values of the header name. name is case-insensitive.
+func (hs Headers) Values(name string) []string {
+	var vs []string
+	for _, h := range hs {
+		if strings.EqualFold(h.Name, name) {
+			vs = append(vs, h.Value)
+		}
+	}
+	return vs
+}
+
+// Add append a header line.
+func (hs *Headers) Add(name, value string) {
+	*hs = append(*hs, Header{Name: name, Value: value})
+}
+
+// Set the header value. will replace the first exists line and remove
+// others with same name, or append a new line if not exists.
+func (hs *Headers) Set(name, value string) {
+	i := hs.index(name)
+	if i < 0 {
+		hs.Add(name, value)
+		return
+	}
+
+	(*hs)[i].Value = value
+	// remove other lines with same name
+	others := (*hs)[i+1:]
+	kept := (*hs)[:i+1]
+	for _, h := range others {
+		if !strings.EqualFold(h.Name, name) {
+			kept = append(kept, h)
+		}
+	}
+	*hs = kept
+}
+
+// Del remove all lines of the header name.
+func (hs *Headers) Del(name string) {
+	kept := (*hs)[:0]
+	for _, h := range *hs {
+		if !strings.EqualFold(h.Name, name) {
+			kept = append(kept, h)
+		}
+	}
+	*hs = kept
+}
+
+// Clone the headers
+func (hs Headers) Clone() Headers {
+	if hs == nil {
+		return nil
+	}
+	return append(make(Headers, 0, len(hs)), hs...)
+}
+
+// ToMap convert to map[string]string. if name is repeated, the last value will be used.
+func (hs Headers) ToMap() map[string]string {
+	mp := make(map[string]string, len(hs))
+	for _, h := range hs {
+		mp[h.Name] = h.Value
+	}
+	return mp
+}
+
+// ToHTTP convert to http.Header, repeated names are kept as multi values.
+func (hs Headers) ToHTTP() http.Header {
+	hh := make(http.Header, len(hs))
+	for _, h := range hs {
+		hh.Add(h.Name, h.Value)
+	}
+	return hh
+}
+
+func (hs Headers) index(name string) int {
+	for i, h := range hs {
+		if strings.EqualFold(h.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,28 @@
+package httpfile_test
+
+import (
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq/ext/httpfile"
+)
+
+func TestHeaders(t *testing.T) {
+	hs := httpfile.NewHeaders("Accept", "*/*", "X-Tag", "a", "Content-Type", "text/plain")
+	hs.Add("x-tag", "b")
+	assert.Eq(t, 4, hs.Len())
+	assert.True(t, hs.Has("content-type"))
+	assert.Eq(t, "a", hs.Get("X-TAG"))
+	assert.Eq(t, []string{"a", "b"}, hs.Values("X-Tag"))
+	assert.Eq(t, []string{"a", "b"}, hs.ToHTTP()["X-Tag"])
+
+	hs.Set("X-Tag", "c")
+	assert.Eq(t, httpfile.NewHeaders("Accept", "*/*", "X-Tag", "c", "Content-Type", "text/plain"), hs)
+
+	cloned := hs.Clone()
+	hs.Set("New-Key", "v")
+	hs.Del("accept")
+	assert.Eq(t, httpfile.NewHeaders("X-Tag", "c", "Content-Type", "text/plain", "New-Key", "v"), hs)
+	assert.Eq(t, 3, cloned.Len())
+	assert.Eq(t, map[string]string{"X-Tag": "c", "Content-Type": "text/plain", "New-Key": "v"}, hs.ToMap())
+}
@@ -4,6 +4,7 @@ package httpfile
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/gookit/goutil/strutil"
@@ -14,7 +15,9 @@ import (
 // 文件内容格式:
 //    - 每个HTTP请求以方法、URL和空行开始
 //    - 头部键值对每行一个，格式为 "Key: Value"
-//    - 空行后为请求体（可选） `@filename` 可以指定请求体内容从文件中读取
+//    - 空行后为请求体（可选） `< ./file.json` 可以指定请求体内容从文件中读取，路径相对于 .http 文件所在目录
+//    - `<@ ./tmpl.json` 从文件读取请求体，并对文件内容进行变量替换
+//    - Content-Type 为 multipart/form-data 时，请求体按 boundary 解析为多个 part, part 内容同样支持 `< ./file.png`
 //    - 可以使用变量替换请求中的内容，格式为 `${var_name}`
 //    - 每个请求之间用 `空行+###开头的行` 分隔
 //    - 单个 # 开头的行是注释，会被忽略
@@ -22,6 +25,10 @@ type HTTPFile struct {
 	// FilePath is the path of the HTTP request file.
 	FilePath string
 	Contents string // the contents of the HTTP request file
+	// Comments file level comments, before the first request.
+	//
+	// NOTE: they are also added to the Comments of each request.
+	Comments []string
 	Requests []*HTTPRequest
 }
 
@@ -101,6 +108,11 @@ func (hf *HTTPFile) Parse() error {
 		return nil
 	}
 
+	var baseDir string
+	if hf.FilePath != "" {
+		baseDir = filepath.Dir(hf.FilePath)
+	}
+
 	rawLines := strings.Split(hf.Contents, "\n")
 	var currentReq *HTTPRequest
 	var inHeaders bool          // 标记是否在解析头部
@@ -128,7 +140,9 @@ func (hf *HTTPFile) Parse() error {
 
 		// 处理注释行（单个#开头）
 		if strings.HasPrefix(trimmedLine, "#") && !strings.HasPrefix(trimmedLine, "###") {
-			if currentReq != nil {
+			if currentReq != nil && inBody {
+				currentReq.addBodyComment(line)
+			} else if currentReq != nil {
 				currentReq.Comments = append(currentReq.Comments, line)
 			} else {
 				// 如果没有当前请求，将注释添加到全局注释中
@@ -149,8 +163,9 @@ func (hf *HTTPFile) Parse() error {
 			// 创建新请求
 			currentReq = &HTTPRequest{
 				Name:    strings.TrimSpace(strings.TrimPrefix(trimmedLine, "###")),
-				Headers: make(map[string]string),
+				Headers: make(Headers, 0),
 				Comments: make([]string, 0),
+				BaseDir:  baseDir,
 			}
 			// 将全局注释添加到当前请求
 			currentReq.Comments = append(currentReq.Comments, globalComments...)
@@ -163,8 +178,9 @@ func (hf *HTTPFile) Parse() error {
 		// 如果没有当前请求，创建一个
 		if currentReq == nil {
 			currentReq = &HTTPRequest{
-				Headers: make(map[string]string),
+				Headers: make(Headers, 0),
 				Comments: make([]string, 0),
+				BaseDir:  baseDir,
 			}
 			// 将全局注释添加到当前请求
 			currentReq.Comments = append(currentReq.Comments, globalComments...)
@@ -191,7 +207,7 @@ func (hf *HTTPFile) Parse() error {
 		if colonIndex := strings.Index(trimmedLine, ":"); colonIndex > 0 {
 			key := strings.TrimSpace(trimmedLine[:colonIndex])
 			value := strings.TrimSpace(trimmedLine[colonIndex+1:])
-			currentReq.Headers[key] = value
+			currentReq.Headers.Add(key, value)
 		}
 	}
 
@@ -202,5 +218,6 @@ func (hf *HTTPFile) Parse() error {
 		hf.Requests = append(hf.Requests, currentReq)
 	}
 
+	hf.Comments = globalComments
 	return nil
 }
@@ -24,8 +24,8 @@ Request body`,
 				{
 					Method: "GET",
 					URL:    "https://example.com/api",
-					Headers: map[string]string{
-						"X-Custom-Header": "custom-value",
+					Headers: httpfile.Headers{
+						{Name: "X-Custom-Header", Value: "custom-value"},
 					},
 					Body: "Request body",
 					Comments: []string{},
@@ -51,8 +51,8 @@ Content-Type: application/json
 					Name: "First Request",
 					Method: "GET",
 					URL:    "https://example.com/api1",
-					Headers: map[string]string{
-						"X-Header1": "value1",
+					Headers: httpfile.Headers{
+						{Name: "X-Header1", Value: "value1"},
 					},
 					Body: "Body1",
 					Comments: []string{},
@@ -61,8 +61,8 @@ Content-Type: application/json
 					Name: "Second Request",
 					Method: "POST",
 					URL:    "https://example.com/api2",
-					Headers: map[string]string{
-						"Content-Type": "application/json",
+					Headers: httpfile.Headers{
+						{Name: "Content-Type", Value: "application/json"},
 					},
 					Body: `{"key": "value"}`,
 					Comments: []string{},
@@ -85,8 +85,8 @@ Request body`,
 					Name: "Request with Comments",
 					Method: "GET",
 					URL:    "https://example.com/api",
-					Headers: map[string]string{
-						"X-Header": "value",
+					Headers: httpfile.Headers{
+						{Name: "X-Header", Value: "value"},
 					},
 					Body: "Request body",
 					Comments: []string{"# This is a comment", "# Header comment", "# Body comment"},
@@ -142,8 +142,8 @@ Body`,
 				{
 					Method: "GET",
 					URL:    "https://example.com/api",
-					Headers: map[string]string{
-						"X-Header": "value",
+					Headers: httpfile.Headers{
+						{Name: "X-Header", Value: "value"},
 					},
 					Body: "Body",
 					Comments: []string{},
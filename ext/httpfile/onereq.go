@@ -10,15 +10,37 @@ import (
 
 // HTTPRequest represents an HTTP request.
 //   - URL, Headers, Body 可以包含变量，格式为 `${var_name}`
+//   - 支持动态变量，每次渲染时重新求值. eg: `${$uuid}`, `${$randomInt(1,100)}` see RenderVars()
 type HTTPRequest struct {
 	// Name is the name of the HTTP request. parsed from ### line
 	Name     string
 	Comments []string
 	// Method is the HTTP method of the request.
-	Method  string
-	URL     string
-	Headers map[string]string
+	Method string
+	URL    string
+	// Headers ordered request headers, keep the original order and repeated names.
+	Headers Headers
 	Body    string
+
+	// BaseDir is used to resolve relative file paths in the body. eg: `< ./payload.json`
+	//  - HTTPFile.Parse will set it to the dir of HTTPFile.FilePath
+	BaseDir string
+	// vars from ApplyVars, use for render `<@ path` file contents.
+	vars map[string]string
+	// comments in the body, they are also in Comments. use for format them back to the body.
+	bodyComments []bodyComment
+}
+
+// bodyComment a comment line in the request body, pos is the body line index before it.
+type bodyComment struct {
+	pos  int
+	line string
+}
+
+// addBodyComment add a comment line in the body, record the position for format.
+func (req *HTTPRequest) addBodyComment(line string) {
+	req.Comments = append(req.Comments, line)
+	req.bodyComments = append(req.bodyComments, bodyComment{pos: strings.Count(req.Body, "\n"), line: line})
 }
 
 var rpl = textutil.NewVarReplacer("${,}").WithParseEnv().
@@ -30,17 +52,20 @@ var rpl = textutil.NewVarReplacer("${,}").WithParseEnv().
 		return varName, true
 	})
 
-// ApplyVars apply variables to the HTTP request.
+// ApplyVars apply variables and dynamic variables to the HTTP request.
+//
+// NOTE: the rendered values are written back to the request.
 func (req *HTTPRequest) ApplyVars(varMap map[string]string) {
+	req.vars = varMap
 	req.URL = req.URLString(varMap)
 	req.Body = req.BodyString(varMap)
-	req.Headers = req.HeadersMap(varMap)
+	req.Headers = req.RenderHeaders(varMap)
 }
 
 // URLString get the URL of the HTTP request.
 func (req *HTTPRequest) URLString(varMap map[string]string) string {
 	if strings.Contains(req.URL, "${") {
-		req.URL = rpl.RenderSimple(req.URL, varMap)
+		req.URL = renderVars(req.URL, varMap, req.BaseDir)
 	}
 	return req.URL
 }
@@ -48,27 +73,38 @@ func (req *HTTPRequest) URLString(varMap map[string]string) string {
 // BodyString get the body of the HTTP request.
 func (req *HTTPRequest) BodyString(varMap map[string]string) string {
 	if strings.Contains(req.Body, "${") {
-		req.Body = rpl.RenderSimple(req.Body, varMap)
+		req.Body = renderVars(req.Body, varMap, req.BaseDir)
 	}
 	return req.Body
 }
 
-// HeadersMap get the headers of the HTTP request.
-func (req *HTTPRequest) HeadersMap(varMap map[string]string) map[string]string {
+// RenderHeaders get the headers of the HTTP request with vars applied. keep the order.
+func (req *HTTPRequest) RenderHeaders(varMap map[string]string) Headers {
 	if len(req.Headers) == 0 {
 		return req.Headers
 	}
 
-	headers := make(map[string]string)
-	for k, v := range req.Headers {
-		if strings.Contains(v, "${") {
-			v = rpl.RenderSimple(v, varMap)
+	headers := make(Headers, 0, len(req.Headers))
+	for _, h := range req.Headers {
+		if strings.Contains(h.Value, "${") {
+			h.Value = renderVars(h.Value, varMap, req.BaseDir)
 		}
-		headers[k] = v
+		headers = append(headers, h)
 	}
 	return headers
 }
 
+// HeadersMap get the headers of the HTTP request with vars applied.
+// If a header name is repeated, the last value will be used.
+func (req *HTTPRequest) HeadersMap(varMap map[string]string) map[string]string {
+	return req.RenderHeaders(varMap).ToMap()
+}
+
+// HeaderValue get header value by key, the key is case-insensitive.
+func (req *HTTPRequest) HeaderValue(key string) string {
+	return req.Headers.Get(key)
+}
+
 // ParseRequestWithVars parse a HTTP request from content string.
 func ParseRequestWithVars(content string, varMap map[string]string) (*HTTPRequest, error) {
 	req, err := ParseRequest(content)
@@ -88,7 +124,7 @@ func ParseRequest(content string) (*HTTPRequest, error) {
 	}
 
 	req := &HTTPRequest{
-		Headers:  make(map[string]string),
+		Headers:  make(Headers, 0),
 		Comments: make([]string, 0),
 	}
 
@@ -118,7 +154,11 @@ func ParseRequest(content string) (*HTTPRequest, error) {
 
 		// 处理注释行（单个#开头）
 		if strings.HasPrefix(trimmedLine, "#") && !strings.HasPrefix(trimmedLine, "###") {
-			req.Comments = append(req.Comments, line)
+			if inBody {
+				req.addBodyComment(line)
+			} else {
+				req.Comments = append(req.Comments, line)
+			}
 			continue
 		}
 
@@ -151,7 +191,7 @@ func ParseRequest(content string) (*HTTPRequest, error) {
 					if colonIndex := strings.Index(trimmedLine, ":"); colonIndex > 0 {
 						key := strings.TrimSpace(trimmedLine[:colonIndex])
 						value := strings.TrimSpace(trimmedLine[colonIndex+1:])
-						req.Headers[key] = value
+						req.Headers.Add(key, value)
 						hasHeaders = true
 					}
 				}
@@ -164,7 +204,7 @@ func ParseRequest(content string) (*HTTPRequest, error) {
 			if colonIndex := strings.Index(trimmedLine, ":"); colonIndex > 0 {
 				key := strings.TrimSpace(trimmedLine[:colonIndex])
 				value := strings.TrimSpace(trimmedLine[colonIndex+1:])
-				req.Headers[key] = value
+				req.Headers.Add(key, value)
 				hasHeaders = true
 				// 如果没有请求行但有头部，则设置inHeaders为true
 				if !hasRequestLine {
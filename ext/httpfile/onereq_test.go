@@ -20,7 +20,7 @@ func TestParseOneRequest(t *testing.T) {
 			want: &httpfile.HTTPRequest{
 				Method:   "GET",
 				URL:      "https://example.com/api",
-				Headers:  make(map[string]string),
+				Headers:  httpfile.Headers{},
 				Comments: []string{},
 			},
 			wantErr: false,
@@ -35,9 +35,9 @@ Authorization: Bearer token
 			want: &httpfile.HTTPRequest{
 				Method: "POST",
 				URL:    "https://example.com/api",
-				Headers: map[string]string{
-					"Content-Type":  "application/json",
-					"Authorization": "Bearer token",
+				Headers: httpfile.Headers{
+					{Name: "Content-Type", Value: "application/json"},
+					{Name: "Authorization", Value: "Bearer token"},
 				},
 				Body:     `{"name": "test", "value": 123}`,
 				Comments: []string{},
@@ -55,8 +55,8 @@ X-Custom-Header: custom-value`,
 				Name:   "Request Name",
 				Method: "GET",
 				URL:    "https://example.com/api",
-				Headers: map[string]string{
-					"X-Custom-Header": "custom-value",
+				Headers: httpfile.Headers{
+					{Name: "X-Custom-Header", Value: "custom-value"},
 				},
 				Comments: []string{"# This is a comment", "# Another comment"},
 			},
@@ -73,8 +73,8 @@ Line 2`,
 			want: &httpfile.HTTPRequest{
 				Method: "POST",
 				URL:    "https://example.com/api",
-				Headers: map[string]string{
-					"Content-Type": "text/plain",
+				Headers: httpfile.Headers{
+					{Name: "Content-Type", Value: "text/plain"},
 				},
 				Body:     "Line 1\n\nLine 2",
 				Comments: []string{},
@@ -0,0 +1,471 @@
+package httpfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// openAPISpec is a minimal OpenAPI 3 document, only the fields used for generate requests.
+type openAPISpec struct {
+	OpenAPI string `json:"openapi"`
+	Info    struct {
+		Title string `json:"title"`
+	} `json:"info"`
+	Servers []struct {
+		URL       string `json:"url"`
+		Variables map[string]struct {
+			Default string `json:"default"`
+		} `json:"variables"`
+	} `json:"servers"`
+	Paths      map[string]*openAPIPathItem `json:"paths"`
+	Components struct {
+		Schemas       map[string]*openAPISchema      `json:"schemas"`
+		Parameters    map[string]*openAPIParameter   `json:"parameters"`
+		RequestBodies map[string]*openAPIRequestBody `json:"requestBodies"`
+	} `json:"components"`
+}
+
+type openAPIPathItem struct {
+	Parameters []*openAPIParameter `json:"parameters"`
+
+	Get     *openAPIOperation `json:"get"`
+	Put     *openAPIOperation `json:"put"`
+	Post    *openAPIOperation `json:"post"`
+	Delete  *openAPIOperation `json:"delete"`
+	Options *openAPIOperation `json:"options"`
+	Head    *openAPIOperation `json:"head"`
+	Patch   *openAPIOperation `json:"patch"`
+	Trace   *openAPIOperation `json:"trace"`
+}
+
+// operations in the fixed method order
+func (pi *openAPIPathItem) operations() ([]string, []*openAPIOperation) {
+	methods := []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS", "TRACE"}
+	all := []*openAPIOperation{pi.Get, pi.Post, pi.Put, pi.Patch, pi.Delete, pi.Head, pi.Options, pi.Trace}
+
+	var ms []string
+	var ops []*openAPIOperation
+	for i, op := range all {
+		if op != nil {
+			ms = append(ms, methods[i])
+			ops = append(ops, op)
+		}
+	}
+	return ms, ops
+}
+
+type openAPIOperation struct {
+	OperationID string              `json:"operationId"`
+	Summary     string              `json:"summary"`
+	Tags        []string            `json:"tags"`
+	Parameters  []*openAPIParameter `json:"parameters"`
+	RequestBody *openAPIRequestBody `json:"requestBody"`
+}
+
+type openAPIParameter struct {
+	Ref     string         `json:"$ref"`
+	Name    string         `json:"name"`
+	In      string         `json:"in"` // path, query, header, cookie
+	Example any            `json:"example"`
+	Schema  *openAPISchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Ref     string                       `json:"$ref"`
+	Content map[string]*openAPIMediaType `json:"content"`
+}
+
+type openAPIMediaType struct {
+	Example  any `json:"example"`
+	Examples map[string]struct {
+		Value any `json:"value"`
+	} `json:"examples"`
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPISchema struct {
+	Ref        string                    `json:"$ref"`
+	Type       string                    `json:"type"`
+	Format     string                    `json:"format"`
+	Example    any                       `json:"example"`
+	Default    any                       `json:"default"`
+	Enum       []any                     `json:"enum"`
+	Properties map[string]*openAPISchema `json:"properties"`
+	Items      *openAPISchema            `json:"items"`
+	AllOf      []*openAPISchema          `json:"allOf"`
+	OneOf      []*openAPISchema          `json:"oneOf"`
+	AnyOf      []*openAPISchema          `json:"anyOf"`
+}
+
+// ImportOpenAPI convert the OpenAPI 3 document(JSON or YAML) to HTTPFile.
+//
+// It generates one example request for each operation:
+//
+//   - the URL is `${baseUrl}/path`, the baseUrl var is from the first server
+//   - path, query and header params use placeholders, eg: `/users/${id}?limit=${limit}`
+//   - the body is built from the example or the schema of the preferred content type
+//
+// Returns the variables with the param examples, can be saved to an env file. see EnvFile
+func ImportOpenAPI(data []byte) (*HTTPFile, map[string]string, error) {
+	spec, err := parseOpenAPI(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	vars := map[string]string{"baseUrl": "http://localhost"}
+	if len(spec.Servers) > 0 {
+		srv := spec.Servers[0]
+		baseURL := srv.URL
+		for name, sv := range srv.Variables {
+			baseURL = strings.ReplaceAll(baseURL, "{"+name+"}", sv.Default)
+		}
+		vars["baseUrl"] = strings.TrimSuffix(baseURL, "/")
+	}
+
+	hf := &HTTPFile{}
+	if spec.Info.Title != "" {
+		hf.Comments = []string{"# " + spec.Info.Title}
+	}
+
+	paths := make([]string, 0, len(spec.Paths))
+	for path := range spec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		item := spec.Paths[path]
+		methods, ops := item.operations()
+		for i, op := range ops {
+			req := spec.buildRequest(methods[i], path, item, op, vars)
+			req.Comments = append(append(make([]string, 0), hf.Comments...), req.Comments...)
+			hf.Requests = append(hf.Requests, req)
+		}
+	}
+	return hf, vars, nil
+}
+
+func parseOpenAPI(data []byte) (*openAPISpec, error) {
+	data = bytes.TrimSpace(data)
+	// YAML: convert to JSON first, then decode to struct by json tags.
+	if len(data) > 0 && data[0] != '{' {
+		var raw any
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("parse openapi yaml error: %w", err)
+		}
+
+		bs, err := json.Marshal(normalizeYAML(raw))
+		if err != nil {
+			return nil, fmt.Errorf("parse openapi yaml error: %w", err)
+		}
+		data = bs
+	}
+
+	spec := &openAPISpec{}
+	if err := json.Unmarshal(data, spec); err != nil {
+		return nil, fmt.Errorf("parse openapi error: %w", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported openapi version %q, only 3.x is supported", spec.OpenAPI)
+	}
+	return spec, nil
+}
+
+// normalizeYAML convert map[any]any to map[string]any, eg: response code keys `200:`
+func normalizeYAML(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, sub := range val {
+			val[k] = normalizeYAML(sub)
+		}
+		return val
+	case map[any]any:
+		mp := make(map[string]any, len(val))
+		for k, sub := range val {
+			mp[fmt.Sprint(k)] = normalizeYAML(sub)
+		}
+		return mp
+	case []any:
+		for i, sub := range val {
+			val[i] = normalizeYAML(sub)
+		}
+		return val
+	}
+	return v
+}
+
+var pathParamReg = regexp.MustCompile(`\{([\w.-]+)\}`)
+
+func (s *openAPISpec) buildRequest(method, path string, item *openAPIPathItem, op *openAPIOperation, vars map[string]string) *HTTPRequest {
+	name := op.OperationID
+	if name == "" {
+		name = method + " " + path
+	}
+	if len(op.Tags) > 0 {
+		name = op.Tags[0] + folderSep + name
+	}
+
+	req := &HTTPRequest{
+		Name:     name,
+		Method:   method,
+		URL:      "${baseUrl}" + pathParamReg.ReplaceAllString(path, "$${$1}"),
+		Headers:  make(Headers, 0),
+		Comments: make([]string, 0),
+	}
+	if op.Summary != "" {
+		req.Comments = append(req.Comments, "# "+op.Summary)
+	}
+
+	// path level params can be overridden by operation params
+	params := make(map[string]*openAPIParameter)
+	var keys []string
+	for _, p := range append(append([]*openAPIParameter{}, item.Parameters...), op.Parameters...) {
+		p = s.resolveParam(p)
+		if p == nil || p.Name == "" {
+			continue
+		}
+
+		key := p.In + ":" + p.Name
+		if _, ok := params[key]; !ok {
+			keys = append(keys, key)
+		}
+		params[key] = p
+	}
+
+	var query []string
+	for _, key := range keys {
+		p := params[key]
+		if ex := s.paramExample(p); ex != nil {
+			if _, ok := vars[p.Name]; !ok {
+				vars[p.Name] = fmt.Sprint(ex)
+			}
+		}
+
+		switch p.In {
+		case "query":
+			query = append(query, url.QueryEscape(p.Name)+"=${"+p.Name+"}")
+		case "header":
+			req.Headers.Add(p.Name, "${"+p.Name+"}")
+		}
+	}
+	if len(query) > 0 {
+		req.URL += "?" + strings.Join(query, "&")
+	}
+
+	s.setBody(req, op.RequestBody)
+	return req
+}
+
+// preferred request content types
+var openAPIContentTypes = []string{"application/json", "application/x-www-form-urlencoded", "multipart/form-data"}
+
+func (s *openAPISpec) setBody(req *HTTPRequest, rb *openAPIRequestBody) {
+	if rb != nil && rb.Ref != "" {
+		rb = s.Components.RequestBodies[refName(rb.Ref)]
+	}
+	if rb == nil || len(rb.Content) == 0 {
+		return
+	}
+
+	cType := ""
+	for _, ct := range openAPIContentTypes {
+		if _, ok := rb.Content[ct]; ok {
+			cType = ct
+			break
+		}
+	}
+	if cType == "" {
+		// first by sorted name, for stable output
+		for ct := range rb.Content {
+			if cType == "" || ct < cType {
+				cType = ct
+			}
+		}
+	}
+
+	mt := rb.Content[cType]
+	// eg: "application/octet-stream": null
+	if mt == nil {
+		req.Headers.Add("Content-Type", cType)
+		return
+	}
+	example := mt.Example
+	if example == nil && len(mt.Examples) > 0 {
+		names := make([]string, 0, len(mt.Examples))
+		for name := range mt.Examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		example = mt.Examples[names[0]].Value
+	}
+	if example == nil && mt.Schema != nil {
+		example = s.schemaExample(mt.Schema, 0)
+	}
+
+	req.Headers.Add("Content-Type", cType)
+	switch {
+	case example == nil:
+		return
+	case strings.Contains(cType, "json"):
+		bs, _ := json.MarshalIndent(example, "", "  ")
+		req.Body = string(bs)
+	case cType == "application/x-www-form-urlencoded" || cType == "multipart/form-data":
+		obj, ok := example.(map[string]any)
+		if !ok {
+			return
+		}
+
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		if cType == "multipart/form-data" {
+			var sb strings.Builder
+			for _, key := range keys {
+				sb.WriteString("--" + multipartBoundary + "\n")
+				sb.WriteString(fmt.Sprintf("Content-Disposition: form-data; name=%q\n\n%v\n", key, obj[key]))
+			}
+			sb.WriteString("--" + multipartBoundary + "--")
+			req.Body = sb.String()
+			req.Headers.Set("Content-Type", cType+"; boundary="+multipartBoundary)
+			return
+		}
+
+		kvs := make([]string, 0, len(keys))
+		for _, key := range keys {
+			kvs = append(kvs, encodeFormKV(key, fmt.Sprint(obj[key])))
+		}
+		req.Body = strings.Join(kvs, "&")
+	default:
+		req.Body = fmt.Sprint(example)
+	}
+}
+
+func (s *openAPISpec) resolveParam(p *openAPIParameter) *openAPIParameter {
+	if p != nil && p.Ref != "" {
+		return s.Components.Parameters[refName(p.Ref)]
+	}
+	return p
+}
+
+func (s *openAPISpec) paramExample(p *openAPIParameter) any {
+	if p.Example != nil {
+		return p.Example
+	}
+	if p.Schema != nil {
+		sc := s.resolveSchema(p.Schema)
+		if sc.Example != nil {
+			return sc.Example
+		}
+		if sc.Default != nil {
+			return sc.Default
+		}
+		if len(sc.Enum) > 0 {
+			return sc.Enum[0]
+		}
+	}
+	return nil
+}
+
+func (s *openAPISpec) resolveSchema(sc *openAPISchema) *openAPISchema {
+	// limit the ref depth, avoid dead loop on bad documents
+	for i := 0; i < 10 && sc.Ref != ""; i++ {
+		ref, ok := s.Components.Schemas[refName(sc.Ref)]
+		if !ok || ref == nil {
+			return &openAPISchema{}
+		}
+		sc = ref
+	}
+	return sc
+}
+
+// maxSchemaDepth limit the nested level on generate example from schema
+const maxSchemaDepth = 6
+
+// schemaExample generate example value from the schema
+func (s *openAPISpec) schemaExample(sc *openAPISchema, depth int) any {
+	if depth > maxSchemaDepth {
+		return nil
+	}
+
+	sc = s.resolveSchema(sc)
+	if sc.Example != nil {
+		return sc.Example
+	}
+	if sc.Default != nil {
+		return sc.Default
+	}
+	if len(sc.Enum) > 0 {
+		return sc.Enum[0]
+	}
+
+	if len(sc.AllOf) > 0 {
+		obj := make(map[string]any)
+		for _, sub := range sc.AllOf {
+			if mp, ok := s.schemaExample(sub, depth+1).(map[string]any); ok {
+				for k, v := range mp {
+					obj[k] = v
+				}
+			}
+		}
+		return obj
+	}
+	if len(sc.OneOf) > 0 {
+		return s.schemaExample(sc.OneOf[0], depth+1)
+	}
+	if len(sc.AnyOf) > 0 {
+		return s.schemaExample(sc.AnyOf[0], depth+1)
+	}
+
+	switch sc.Type {
+	case "object", "":
+		if len(sc.Properties) == 0 {
+			if sc.Type == "" {
+				return nil
+			}
+			return map[string]any{}
+		}
+
+		obj := make(map[string]any, len(sc.Properties))
+		for name, prop := range sc.Properties {
+			obj[name] = s.schemaExample(prop, depth+1)
+		}
+		return obj
+	case "array":
+		if sc.Items == nil {
+			return []any{}
+		}
+		return []any{s.schemaExample(sc.Items, depth+1)}
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	case "string":
+		switch sc.Format {
+		case "date":
+			return "2024-01-01"
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		case "email":
+			return "user@example.com"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		}
+		return "string"
+	}
+	return nil
+}
+
+// refName get the name from local ref. eg: "#/components/schemas/User" => "User"
+func refName(ref string) string {
+	return ref[strings.LastIndexByte(ref, '/')+1:]
+}
@@ -0,0 +1,59 @@
+package httpfile_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq/ext/httpfile"
+)
+
+func TestImportOpenAPI(t *testing.T) {
+	bs, err := os.ReadFile("testdata/openapi.yaml")
+	assert.NoErr(t, err)
+
+	hf, vars, err := httpfile.ImportOpenAPI(bs)
+	assert.NoErr(t, err)
+	assert.Eq(t, "https://petstore.example.com/v1", vars["baseUrl"])
+	assert.Eq(t, "20", vars["limit"])
+	assert.Eq(t, "42", vars["petId"])
+	assert.Len(t, hf.Requests, 3)
+
+	req := hf.Requests[0]
+	assert.Eq(t, "pets / listPets", req.Name)
+	assert.Eq(t, "GET", req.Method)
+	assert.Eq(t, "${baseUrl}/pets?limit=${limit}", req.URL)
+	assert.Eq(t, "${X-Trace}", req.HeaderValue("X-Trace"))
+	assert.Contains(t, req.Comments, "# List all pets")
+
+	req = hf.FindByName("pets / createPet")
+	assert.Eq(t, "application/json", req.HeaderValue("Content-Type"))
+	assert.Eq(t, "{\n  \"age\": 0,\n  \"name\": \"kitty\",\n  \"tags\": [\n    \"string\"\n  ]\n}", req.Body)
+
+	req = hf.Requests[2]
+	assert.Eq(t, "DELETE /pets/{petId}", req.Name)
+	assert.Eq(t, "${baseUrl}/pets/${petId}", req.URL)
+
+	t.Run("json", func(t *testing.T) {
+		hf, vars, err := httpfile.ImportOpenAPI([]byte(`{"openapi": "3.1.0", "paths": {"/ping": {"get": {}}}}`))
+		assert.NoErr(t, err)
+		assert.Eq(t, "http://localhost", vars["baseUrl"])
+		assert.Len(t, hf.Requests, 1)
+		assert.Eq(t, "${baseUrl}/ping", hf.Requests[0].URL)
+	})
+
+	t.Run("null media type", func(t *testing.T) {
+		hf, _, err := httpfile.ImportOpenAPI([]byte(`{"openapi": "3.0.0", "paths": {"/upload": {"put": {
+			"requestBody": {"content": {"application/octet-stream": null}}
+		}}}}`))
+		assert.NoErr(t, err)
+		assert.Len(t, hf.Requests, 1)
+		assert.Eq(t, "application/octet-stream", hf.Requests[0].HeaderValue("Content-Type"))
+		assert.Empty(t, hf.Requests[0].Body)
+	})
+
+	t.Run("bad version", func(t *testing.T) {
+		_, _, err := httpfile.ImportOpenAPI([]byte(`swagger: "2.0"`))
+		assert.ErrMsgContains(t, err, "unsupported openapi version")
+	})
+}
@@ -0,0 +1,482 @@
+package httpfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gookit/goutil/netutil/httpreq"
+)
+
+// PostmanSchema the Postman collection v2.1 schema URL
+const PostmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// folderSep is the separator for join Postman folder names to the request name.
+//
+// eg: folder "users" + request "create" => "users / create"
+const folderSep = " / "
+
+// PostmanCollection is a Postman collection v2.1 document.
+//
+// Only the commonly used fields are defined, others will be ignored on import.
+type PostmanCollection struct {
+	Info     PostmanInfo       `json:"info"`
+	Item     []*PostmanItem    `json:"item"`
+	Auth     *PostmanAuth      `json:"auth,omitempty"`
+	Variable []PostmanVariable `json:"variable,omitempty"`
+}
+
+// PostmanInfo collection info
+type PostmanInfo struct {
+	PostmanID   string `json:"_postman_id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+// PostmanItem is a request or a folder(has sub items).
+type PostmanItem struct {
+	Name    string          `json:"name"`
+	Item    []*PostmanItem  `json:"item,omitempty"`
+	Request *PostmanRequest `json:"request,omitempty"`
+	Auth    *PostmanAuth    `json:"auth,omitempty"`
+}
+
+// IsFolder check the item is a folder
+func (pi *PostmanItem) IsFolder() bool { return pi.Request == nil }
+
+// PostmanRequest the request of an item
+type PostmanRequest struct {
+	Method      string          `json:"method"`
+	Header      []PostmanKV     `json:"header"`
+	URL         PostmanURL      `json:"url"`
+	Body        *PostmanBody    `json:"body,omitempty"`
+	Auth        *PostmanAuth    `json:"auth,omitempty"`
+	Description json.RawMessage `json:"description,omitempty"`
+}
+
+// PostmanKV is a key-value item. use for header, query, form fields.
+type PostmanKV struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Type     string `json:"type,omitempty"` // text, file
+	Src      string `json:"src,omitempty"`  // file path on type=file
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+// PostmanURL the request URL. it can be a string or an object in the collection file.
+type PostmanURL struct {
+	Raw   string      `json:"raw"`
+	Query []PostmanKV `json:"query,omitempty"`
+}
+
+// UnmarshalJSON allow the URL is a string or an object.
+func (pu *PostmanURL) UnmarshalJSON(bs []byte) error {
+	if len(bs) > 0 && bs[0] == '"' {
+		return json.Unmarshal(bs, &pu.Raw)
+	}
+
+	type plain PostmanURL
+	return json.Unmarshal(bs, (*plain)(pu))
+}
+
+// PostmanBody the request body
+type PostmanBody struct {
+	// Mode allow: raw, urlencoded, formdata, file
+	Mode       string      `json:"mode"`
+	Raw        string      `json:"raw,omitempty"`
+	URLEncoded []PostmanKV `json:"urlencoded,omitempty"`
+	FormData   []PostmanKV `json:"formdata,omitempty"`
+	File       *struct {
+		Src string `json:"src"`
+	} `json:"file,omitempty"`
+	Options *struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options,omitempty"`
+}
+
+// PostmanAuth request auth config. only bearer and basic are supported on import.
+type PostmanAuth struct {
+	Type   string      `json:"type"`
+	Bearer []PostmanKV `json:"bearer,omitempty"`
+	Basic  []PostmanKV `json:"basic,omitempty"`
+}
+
+// PostmanVariable collection variable
+type PostmanVariable struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+	Type  string `json:"type,omitempty"`
+}
+
+// ParsePostman parse the Postman collection v2.1 JSON contents.
+func ParsePostman(data []byte) (*PostmanCollection, error) {
+	pc := &PostmanCollection{}
+	if err := json.Unmarshal(data, pc); err != nil {
+		return nil, fmt.Errorf("parse postman collection error: %w", err)
+	}
+	return pc, nil
+}
+
+// ImportPostman parse Postman collection JSON contents and convert to HTTPFile.
+//
+// Returns the collection variables, can be saved to an env file. see EnvFile
+func ImportPostman(data []byte) (*HTTPFile, map[string]string, error) {
+	pc, err := ParsePostman(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hf, vars := pc.ToHTTPFile()
+	return hf, vars, nil
+}
+
+// ToHTTPFile convert the collection to HTTPFile and variables.
+//
+//   - folder names are joined to the request name as prefix. eg: "users / create"
+//   - variables `{{name}}` are converted to `${name}`
+//   - disabled headers and fields are skipped
+func (pc *PostmanCollection) ToHTTPFile() (*HTTPFile, map[string]string) {
+	hf := &HTTPFile{}
+	if pc.Info.Name != "" {
+		hf.Comments = []string{"# " + pc.Info.Name}
+	}
+
+	pc.walkItems(pc.Item, "", pc.Auth, func(req *HTTPRequest) {
+		req.Comments = append(append(make([]string, 0), hf.Comments...), req.Comments...)
+		hf.Requests = append(hf.Requests, req)
+	})
+
+	vars := make(map[string]string, len(pc.Variable))
+	for _, v := range pc.Variable {
+		if v.Value == nil {
+			vars[v.Key] = ""
+		} else {
+			vars[v.Key] = fmt.Sprint(v.Value)
+		}
+	}
+	return hf, vars
+}
+
+func (pc *PostmanCollection) walkItems(items []*PostmanItem, prefix string, auth *PostmanAuth, fn func(req *HTTPRequest)) {
+	for _, item := range items {
+		name := item.Name
+		if prefix != "" {
+			name = prefix + folderSep + name
+		}
+
+		itemAuth := auth
+		if item.Auth != nil {
+			itemAuth = item.Auth
+		}
+
+		if item.IsFolder() {
+			pc.walkItems(item.Item, name, itemAuth, fn)
+			continue
+		}
+		fn(postmanToRequest(name, item.Request, itemAuth))
+	}
+}
+
+func postmanToRequest(name string, pr *PostmanRequest, auth *PostmanAuth) *HTTPRequest {
+	req := &HTTPRequest{
+		Name:     name,
+		Method:   strings.ToUpper(strings.TrimSpace(pr.Method)),
+		URL:      fromPostmanVars(pr.URL.Raw),
+		Headers:  make(Headers, 0, len(pr.Header)),
+		Comments: make([]string, 0),
+	}
+	if req.Method == "" {
+		req.Method = "GET"
+	}
+
+	// raw URL is empty, build from query items
+	if req.URL == "" || !strings.Contains(req.URL, "?") {
+		var qs []string
+		for _, kv := range pr.URL.Query {
+			if !kv.Disabled {
+				qs = append(qs, encodeFormKV(kv.Key, kv.Value))
+			}
+		}
+		if len(qs) > 0 {
+			req.URL += "?" + fromPostmanVars(strings.Join(qs, "&"))
+		}
+	}
+
+	for _, kv := range pr.Header {
+		if !kv.Disabled {
+			req.Headers.Add(kv.Key, fromPostmanVars(kv.Value))
+		}
+	}
+
+	if pr.Auth != nil {
+		auth = pr.Auth
+	}
+	if val := auth.headerValue(); val != "" && !req.Headers.Has("Authorization") {
+		req.Headers.Add("Authorization", fromPostmanVars(val))
+	}
+
+	if pr.Body != nil {
+		setPostmanBody(req, pr.Body)
+	}
+	return req
+}
+
+// multipartBoundary is the boundary for generated multipart bodies.
+const multipartBoundary = "WebAppBoundary"
+
+func setPostmanBody(req *HTTPRequest, body *PostmanBody) {
+	setType := func(cType string) {
+		if !req.Headers.Has("Content-Type") {
+			req.Headers.Add("Content-Type", cType)
+		}
+	}
+
+	switch body.Mode {
+	case "raw":
+		req.Body = fromPostmanVars(body.Raw)
+		if body.Options != nil {
+			switch body.Options.Raw.Language {
+			case "json":
+				setType("application/json")
+			case "xml":
+				setType("application/xml")
+			}
+		}
+	case "urlencoded":
+		var kvs []string
+		for _, kv := range body.URLEncoded {
+			if !kv.Disabled {
+				kvs = append(kvs, encodeFormKV(kv.Key, kv.Value))
+			}
+		}
+		req.Body = fromPostmanVars(strings.Join(kvs, "&"))
+		setType("application/x-www-form-urlencoded")
+	case "formdata":
+		var sb strings.Builder
+		for _, kv := range body.FormData {
+			if kv.Disabled {
+				continue
+			}
+
+			sb.WriteString("--" + multipartBoundary + "\n")
+			if kv.Type == "file" {
+				sb.WriteString(fmt.Sprintf("Content-Disposition: form-data; name=%q; filename=%q\n\n", kv.Key, baseName(kv.Src)))
+				sb.WriteString("< " + kv.Src + "\n")
+			} else {
+				sb.WriteString(fmt.Sprintf("Content-Disposition: form-data; name=%q\n\n", kv.Key))
+				sb.WriteString(fromPostmanVars(kv.Value) + "\n")
+			}
+		}
+		sb.WriteString("--" + multipartBoundary + "--")
+		req.Body = sb.String()
+		req.Headers.Set("Content-Type", "multipart/form-data; boundary="+multipartBoundary)
+	case "file":
+		if body.File != nil && body.File.Src != "" {
+			req.Body = "< " + body.File.Src
+		}
+	}
+}
+
+func (pa *PostmanAuth) headerValue() string {
+	if pa == nil {
+		return ""
+	}
+
+	get := func(kvs []PostmanKV, key string) string {
+		for _, kv := range kvs {
+			if kv.Key == key {
+				return kv.Value
+			}
+		}
+		return ""
+	}
+
+	switch pa.Type {
+	case "bearer":
+		if token := get(pa.Bearer, "token"); token != "" {
+			return "Bearer " + token
+		}
+	case "basic":
+		username, password := get(pa.Basic, "username"), get(pa.Basic, "password")
+		// keep vars as is, the base64 encode is done by dynamic var on send.
+		if strings.Contains(username+password, "{{") {
+			return "Basic {{$base64(" + username + ":" + password + ")}}"
+		}
+		return httpreq.BuildBasicAuth(username, password)
+	}
+	return ""
+}
+
+// ExportPostman convert the HTTPFile to a Postman collection v2.1
+//
+//   - request names with " / " are converted to folders
+//   - variables `${name}` are converted to `{{name}}`
+//   - vars will be added as collection variables, can be nil.
+func ExportPostman(hf *HTTPFile, name string, vars map[string]string) *PostmanCollection {
+	pc := &PostmanCollection{
+		Info: PostmanInfo{Name: name, Schema: PostmanSchema},
+		Item: make([]*PostmanItem, 0, len(hf.Requests)),
+	}
+
+	for i, req := range hf.Requests {
+		names := strings.Split(req.Name, folderSep)
+		if req.Name == "" {
+			names = []string{fmt.Sprintf("request %d", i+1)}
+		}
+
+		items := &pc.Item
+		for _, folder := range names[:len(names)-1] {
+			items = &findOrAddFolder(items, folder).Item
+		}
+		*items = append(*items, &PostmanItem{
+			Name:    names[len(names)-1],
+			Request: requestToPostman(req),
+		})
+	}
+
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		pc.Variable = append(pc.Variable, PostmanVariable{Key: key, Value: vars[key], Type: "string"})
+	}
+	return pc
+}
+
+// JSON encode the collection to indented JSON.
+func (pc *PostmanCollection) JSON() ([]byte, error) {
+	return json.MarshalIndent(pc, "", "  ")
+}
+
+func findOrAddFolder(items *[]*PostmanItem, name string) *PostmanItem {
+	for _, item := range *items {
+		if item.IsFolder() && item.Name == name {
+			return item
+		}
+	}
+
+	folder := &PostmanItem{Name: name, Item: make([]*PostmanItem, 0)}
+	*items = append(*items, folder)
+	return folder
+}
+
+func requestToPostman(req *HTTPRequest) *PostmanRequest {
+	pr := &PostmanRequest{
+		Method: strings.ToUpper(req.Method),
+		Header: make([]PostmanKV, 0, len(req.Headers)),
+		URL:    PostmanURL{Raw: toPostmanVars(req.URL)},
+	}
+
+	if parts := req.MultipartParts(); parts != nil {
+		pr.Body = &PostmanBody{Mode: "formdata"}
+		for _, part := range parts {
+			_, params, _ := mime.ParseMediaType(part.Headers.Get("Content-Disposition"))
+			kv := PostmanKV{Key: params["name"], Type: "text", Value: toPostmanVars(part.Body)}
+			// keep the path as written in the file, not resolved by BaseDir
+			if path, _, ok := parseInclude(part.Body); ok {
+				kv.Type, kv.Value, kv.Src = "file", "", path
+			}
+			pr.Body.FormData = append(pr.Body.FormData, kv)
+		}
+	} else if path, withVars, ok := parseInclude(req.Body); ok && !withVars {
+		pr.Body = &PostmanBody{Mode: "file", File: &struct {
+			Src string `json:"src"`
+		}{Src: path}}
+	} else if req.Body != "" {
+		pr.Body = &PostmanBody{Mode: "raw", Raw: toPostmanVars(req.Body)}
+		if strings.Contains(req.HeaderValue("Content-Type"), "json") {
+			pr.Body.Options = &struct {
+				Raw struct {
+					Language string `json:"language"`
+				} `json:"raw"`
+			}{}
+			pr.Body.Options.Raw.Language = "json"
+		}
+	}
+
+	for _, h := range req.Headers {
+		// multipart boundary is generated by postman
+		if pr.Body != nil && pr.Body.Mode == "formdata" && strings.EqualFold(h.Name, "Content-Type") {
+			continue
+		}
+		pr.Header = append(pr.Header, PostmanKV{Key: h.Name, Value: toPostmanVars(h.Value)})
+	}
+	return pr
+}
+
+var (
+	postmanVarReg = regexp.MustCompile(`\{\{\s*(\$?[\w.-]+)\s*\}\}`)
+	// basic auth with vars: {{$base64(user:pass)}}
+	postmanB64Reg = regexp.MustCompile(`\{\{\s*\$base64\(([^)]*)\)\s*\}\}`)
+	greqVarReg    = regexp.MustCompile(`\$\{\s*(\$?[\w.-]+(?:\([^)]*\))?)\s*\}`)
+)
+
+// postman dynamic var => greq dynamic var
+var postmanDynVars = map[string]string{
+	"$guid":         "$uuid",
+	"$randomUUID":   "$uuid",
+	"$timestamp":    "$timestamp",
+	"$isoTimestamp": "$isoTimestamp",
+	"$randomInt":    "$randomInt",
+}
+
+// fromPostmanVars convert `{{name}}` to `${name}`
+func fromPostmanVars(s string) string {
+	if !strings.Contains(s, "{{") {
+		return s
+	}
+
+	s = postmanB64Reg.ReplaceAllString(s, "$${$$base64($1)}")
+	return postmanVarReg.ReplaceAllStringFunc(s, func(expr string) string {
+		name := postmanVarReg.FindStringSubmatch(expr)[1]
+		if dyn, ok := postmanDynVars[name]; ok {
+			name = dyn
+		}
+		return "${" + name + "}"
+	})
+}
+
+// toPostmanVars convert `${name}` to `{{name}}`
+func toPostmanVars(s string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+
+	return greqVarReg.ReplaceAllStringFunc(s, func(expr string) string {
+		name := greqVarReg.FindStringSubmatch(expr)[1]
+		for pmName, dyn := range postmanDynVars {
+			if name == dyn && pmName != "$randomUUID" {
+				name = pmName
+				break
+			}
+		}
+		return "{{" + name + "}}"
+	})
+}
+
+// encodeFormKV encode the form key-value, keep the vars as is.
+func encodeFormKV(key, value string) string {
+	enc := func(s string) string {
+		if strings.Contains(s, "{{") || strings.Contains(s, "${") {
+			return s
+		}
+		return url.QueryEscape(s)
+	}
+	return enc(key) + "=" + enc(value)
+}
+
+func baseName(path string) string {
+	if i := strings.LastIndexAny(path, `/\`); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
@@ -0,0 +1,138 @@
+package httpfile_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq/ext/httpfile"
+)
+
+func TestImportPostman(t *testing.T) {
+	bs, err := os.ReadFile("testdata/postman.json")
+	assert.NoErr(t, err)
+
+	hf, vars, err := httpfile.ImportPostman(bs)
+	assert.NoErr(t, err)
+	assert.Eq(t, "http://localhost:8080", vars["baseUrl"])
+	assert.Eq(t, "3", vars["retry"])
+	assert.Eq(t, []string{"# Demo API"}, hf.Comments)
+	assert.Len(t, hf.Requests, 3)
+
+	req := hf.FindByName("users / create user")
+	assert.NotNil(t, req)
+	assert.Eq(t, "POST", req.Method)
+	assert.Eq(t, "${baseUrl}/users", req.URL)
+	assert.Eq(t, "${$uuid}", req.HeaderValue("X-Request-Id"))
+	assert.False(t, req.Headers.Has("X-Debug"))
+	assert.Eq(t, "Bearer ${token}", req.HeaderValue("Authorization"))
+	assert.Eq(t, "application/json", req.HeaderValue("Content-Type"))
+	assert.Eq(t, `{"name": "${name}"}`, req.Body)
+
+	req = hf.FindByName("users / upload avatar")
+	assert.Eq(t, "${baseUrl}/users/avatar", req.URL)
+	parts := req.MultipartParts()
+	assert.Len(t, parts, 2)
+	assert.Eq(t, "1", parts[0].Body)
+	assert.Eq(t, "./logo.png", parts[1].FilePath)
+
+	req = hf.FindByName("login")
+	assert.Eq(t, "${baseUrl}/login?remember=1", req.URL)
+	assert.Eq(t, "Basic YWRtaW46MTIzNDU2", req.HeaderValue("Authorization"))
+	assert.Eq(t, "application/x-www-form-urlencoded", req.HeaderValue("Content-Type"))
+	assert.Eq(t, "scope=read+write", req.Body)
+
+	// formatted text can be parsed again
+	hf2, err := httpfile.ParseFileContent(hf.Format())
+	assert.NoErr(t, err)
+	assert.Len(t, hf2.Requests, 3)
+	assert.Eq(t, hf.Format(), hf2.Format())
+
+	t.Run("vars", func(t *testing.T) {
+		hf, _, err := httpfile.ImportPostman([]byte(`{
+  "info": {"name": "vars"},
+  "item": [{
+    "name": "login",
+    "request": {
+      "method": "POST",
+      "auth": {"type": "basic", "basic": [{"key": "username", "value": "{{user}}"}, {"key": "password", "value": "pwd"}]},
+      "url": "{{baseUrl}}/login",
+      "body": {"mode": "raw", "raw": "{\"expr\": \"f(x)}}\", \"name\": \"{{name}}\"}"}
+    }
+  }]
+}`))
+		assert.NoErr(t, err)
+		req := hf.Requests[0]
+		assert.Eq(t, "Basic ${$base64(${user}:pwd)}", req.HeaderValue("Authorization"))
+		// only the $base64 expressions are rewritten
+		assert.Eq(t, `{"expr": "f(x)}}", "name": "${name}"}`, req.Body)
+	})
+
+	t.Run("null var value", func(t *testing.T) {
+		_, vars, err := httpfile.ImportPostman([]byte(`{
+  "info": {"name": "vars"},
+  "item": [],
+  "variable": [{"key": "token", "value": null}, {"key": "port", "value": 8080}]
+}`))
+		assert.NoErr(t, err)
+		assert.Eq(t, map[string]string{"token": "", "port": "8080"}, vars)
+	})
+}
+
+func TestExportPostman(t *testing.T) {
+	hf, err := httpfile.ParseFileContent(`
+### users / create user
+POST ${baseUrl}/users
+Content-Type: application/json
+X-Request-Id: ${$uuid}
+
+{"name": "${name}"}
+
+### users / admin / list
+GET ${baseUrl}/users?role=admin
+
+### send file
+POST ${baseUrl}/files
+
+< ./payload.json
+`)
+	assert.NoErr(t, err)
+
+	pc := httpfile.ExportPostman(hf, "Demo", map[string]string{"baseUrl": "http://localhost"})
+	assert.Eq(t, httpfile.PostmanSchema, pc.Info.Schema)
+	assert.Len(t, pc.Item, 2)
+	assert.Len(t, pc.Variable, 1)
+
+	folder := pc.Item[0]
+	assert.True(t, folder.IsFolder())
+	assert.Eq(t, "users", folder.Name)
+	assert.Len(t, folder.Item, 2)
+
+	item := folder.Item[0]
+	assert.Eq(t, "create user", item.Name)
+	assert.Eq(t, "{{baseUrl}}/users", item.Request.URL.Raw)
+	assert.Eq(t, "{{$guid}}", item.Request.Header[1].Value)
+	assert.Eq(t, "raw", item.Request.Body.Mode)
+	assert.Eq(t, `{"name": "{{name}}"}`, item.Request.Body.Raw)
+	assert.Eq(t, "json", item.Request.Body.Options.Raw.Language)
+
+	assert.Eq(t, "admin", folder.Item[1].Name)
+	assert.Eq(t, "list", folder.Item[1].Item[0].Name)
+	assert.Eq(t, "file", pc.Item[1].Request.Body.Mode)
+
+	// round trip
+	bs, err := pc.JSON()
+	assert.NoErr(t, err)
+	hf2, vars, err := httpfile.ImportPostman(bs)
+	assert.NoErr(t, err)
+	assert.Eq(t, "http://localhost", vars["baseUrl"])
+	assert.Len(t, hf2.Requests, 3)
+	assert.Eq(t, "users / admin / list", hf2.Requests[1].Name)
+	assert.Eq(t, `{"name": "${name}"}`, hf2.Requests[0].Body)
+	assert.Eq(t, "${$uuid}", hf2.Requests[0].HeaderValue("X-Request-Id"))
+
+	var raw map[string]any
+	assert.NoErr(t, json.Unmarshal(bs, &raw))
+	assert.Contains(t, raw, "info")
+}
@@ -0,0 +1,187 @@
+// Package metrics provides a client metrics middleware for greq, with Prometheus text exposition.
+//
+// Usage:
+//
+//	mc := metrics.NewCollector()
+//	client := greq.New("https://api.example.com").Use(mc)
+//
+//	// snapshot
+//	m := mc.Snapshot()
+//	fmt.Println(m.TotalRequests(), m.InFlight)
+//
+//	// serve the Prometheus text format
+//	http.Handle("/metrics", mc.Handler())
+//
+// The middleware runs once per attempt, so each retry is counted as a request and a retry,
+// each hedged request is counted as a request and a hedge.
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gookit/greq"
+)
+
+// DefaultBuckets the default latency histogram buckets, in seconds.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// StatusError is the status class label for requests failed without response
+const StatusError = "error"
+
+// OptionFn is a function to configure the Collector
+type OptionFn func(c *Collector)
+
+// WithBuckets set the latency histogram buckets, in seconds. must be sorted in increasing order.
+func WithBuckets(buckets ...float64) OptionFn {
+	return func(c *Collector) {
+		c.buckets = buckets
+	}
+}
+
+// WithNamespace set the prefix of metric names. default is "greq"
+func WithNamespace(ns string) OptionFn {
+	return func(c *Collector) {
+		c.namespace = ns
+	}
+}
+
+// series key of the metrics
+type seriesKey struct {
+	method, host, status string
+}
+
+type histogram struct {
+	// counts of each bucket, not cumulative. the last one is +Inf
+	counts []int64
+	count  int64
+	sum    float64
+}
+
+// Collector is a middleware for collect the client metrics.
+type Collector struct {
+	namespace string
+	buckets   []float64
+
+	inFlight atomic.Int64
+	sent     atomic.Int64
+	received atomic.Int64
+
+	mu       sync.Mutex
+	requests map[seriesKey]int64
+	retries  map[seriesKey]int64
+	hedges   map[seriesKey]int64
+	latency  map[seriesKey]*histogram
+}
+
+// NewCollector create a metrics collector
+func NewCollector(optFns ...OptionFn) *Collector {
+	c := &Collector{
+		namespace: "greq",
+		buckets:   DefaultBuckets,
+	}
+	for _, fn := range optFns {
+		fn(c)
+	}
+
+	c.Reset()
+	return c
+}
+
+// Reset clear all collected metrics, except the in-flight gauge.
+func (c *Collector) Reset() {
+	c.mu.Lock()
+	c.requests = make(map[seriesKey]int64)
+	c.retries = make(map[seriesKey]int64)
+	c.hedges = make(map[seriesKey]int64)
+	c.latency = make(map[seriesKey]*histogram)
+	c.mu.Unlock()
+
+	c.sent.Store(0)
+	c.received.Store(0)
+}
+
+// Handle implements the greq.Middleware
+func (c *Collector) Handle(r *http.Request, next greq.HandleFunc) (*greq.Response, error) {
+	key := seriesKey{method: r.Method, host: r.URL.Host}
+	if st := greq.GetReqState(r); st != nil && (st.Attempt > 0 || st.Hedge > 0) {
+		c.mu.Lock()
+		if st.Hedge > 0 {
+			c.hedges[key]++
+		} else {
+			c.retries[key]++
+		}
+		c.mu.Unlock()
+	}
+
+	// count the sent body bytes
+	if r.ContentLength > 0 {
+		c.sent.Add(r.ContentLength)
+	} else if r.Body != nil && r.Body != http.NoBody {
+		body := r.Body
+		r.Body = &countReader{ReadCloser: body, n: &c.sent}
+		defer func() { r.Body = body }()
+	}
+
+	c.inFlight.Add(1)
+	start := time.Now()
+	resp, err := next(r)
+	cost := time.Since(start)
+	c.inFlight.Add(-1)
+
+	if resp != nil {
+		key.status = StatusClass(resp.StatusCode)
+		// count the received body bytes on read
+		if resp.Body != nil {
+			resp.Body = &countReader{ReadCloser: resp.Body, n: &c.received}
+		}
+	} else {
+		key.status = StatusError
+	}
+	c.observe(key, cost)
+	return resp, err
+}
+
+func (c *Collector) observe(key seriesKey, cost time.Duration) {
+	sec := cost.Seconds()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests[key]++
+
+	// latency is not split by status
+	key.status = ""
+	h := c.latency[key]
+	if h == nil {
+		h = &histogram{counts: make([]int64, len(c.buckets)+1)}
+		c.latency[key] = h
+	}
+
+	idx := sort.SearchFloat64s(c.buckets, sec)
+	h.counts[idx]++
+	h.count++
+	h.sum += sec
+}
+
+// StatusClass get the status class label of the status code. eg: 200 -> "2xx"
+func StatusClass(code int) string {
+	if code < 100 || code > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(code/100) + "xx"
+}
+
+type countReader struct {
+	io.ReadCloser
+	n *atomic.Int64
+}
+
+func (cr *countReader) Read(p []byte) (int, error) {
+	n, err := cr.ReadCloser.Read(p)
+	cr.n.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,169 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq"
+	"github.com/gookit/greq/ext/metrics"
+)
+
+func TestCollector_Handle(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/flaky" && atomic.AddInt32(&hits, 1) <= 2 {
+			w.WriteHeader(503)
+			return
+		}
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer ts.Close()
+
+	mc := metrics.NewCollector(metrics.WithBuckets(0.1, 1))
+	client := greq.New(ts.URL).Use(mc)
+
+	resp, err := client.PostDo("/users", greq.WithBody("name=inhere"))
+	assert.NoErr(t, err)
+	assert.Eq(t, "hello", resp.BodyString())
+
+	resp, err = client.GetDo("/flaky", greq.WithMaxRetries(3))
+	assert.NoErr(t, err)
+	assert.Eq(t, 200, resp.StatusCode)
+	resp.QuietCloseBody()
+
+	_, err = greq.New("http://127.0.0.1:1").Use(mc).GetDo("/")
+	assert.Err(t, err)
+
+	m := mc.Snapshot()
+	host := strings.TrimPrefix(ts.URL, "http://")
+	assert.Eq(t, int64(5), m.TotalRequests())
+	assert.Eq(t, int64(2), m.TotalRetries())
+	assert.Eq(t, int64(2), m.RequestCount("5xx"))
+	assert.Eq(t, int64(2), m.RequestCount("2xx"))
+	assert.Eq(t, int64(1), m.RequestCount(metrics.StatusError))
+	assert.Eq(t, int64(0), m.InFlight)
+	assert.Eq(t, int64(len("name=inhere")), m.BytesSent)
+	assert.Eq(t, int64(5), m.BytesReceived)
+
+	assert.Eq(t, metrics.Count{Method: "GET", Host: "127.0.0.1:1", Status: "error", Value: 1}, m.Requests[0])
+	assert.Eq(t, metrics.Count{Method: "GET", Host: host, Value: 2}, m.Retries[0])
+
+	assert.Len(t, m.Latency, 3)
+	h := m.Latency[1]
+	assert.Eq(t, "GET", h.Method)
+	assert.Eq(t, host, h.Host)
+	assert.Eq(t, int64(3), h.Count)
+	assert.Eq(t, []int64{3, 3}, h.Counts)
+	assert.True(t, h.Mean() < 100*time.Millisecond)
+
+	mc.Reset()
+	m = mc.Snapshot()
+	assert.Eq(t, int64(0), m.TotalRequests())
+	assert.Empty(t, m.Latency)
+}
+
+func TestCollector_hedges(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			time.Sleep(100 * time.Millisecond)
+		}
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer ts.Close()
+
+	mc := metrics.NewCollector()
+	resp, err := greq.New(ts.URL).Use(mc).WithHedging(20*time.Millisecond, 1).GetDo("/hedge")
+	assert.NoErr(t, err)
+	assert.Eq(t, "hello", resp.BodyString())
+
+	// wait the slow one done
+	time.Sleep(150 * time.Millisecond)
+	m := mc.Snapshot()
+	assert.Eq(t, int64(2), m.TotalRequests())
+	assert.Eq(t, int64(1), m.TotalHedges())
+	assert.Eq(t, int64(0), m.TotalRetries())
+
+	var sb strings.Builder
+	assert.NoErr(t, mc.WritePrometheus(&sb))
+	assert.StrContains(t, sb.String(), `greq_hedges_total{method="GET",host="`+strings.TrimPrefix(ts.URL, "http://")+`"} 1`)
+}
+
+func TestCollector_inFlight(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+	defer ts.Close()
+
+	mc := metrics.NewCollector()
+	done := make(chan struct{})
+	go func() {
+		_, _ = greq.New(ts.URL).Use(mc).GetDo("/slow")
+		close(done)
+	}()
+
+	<-started
+	assert.Eq(t, int64(1), mc.Snapshot().InFlight)
+	close(release)
+	<-done
+	assert.Eq(t, int64(0), mc.Snapshot().InFlight)
+}
+
+func TestCollector_Handler(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(404)
+	}))
+	defer ts.Close()
+
+	mc := metrics.NewCollector(metrics.WithNamespace("api"), metrics.WithBuckets(0.5))
+	_, err := greq.New(ts.URL).Use(mc).GetDo("/none")
+	assert.NoErr(t, err)
+
+	w := httptest.NewRecorder()
+	mc.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.StrContains(t, w.Header().Get("Content-Type"), "text/plain; version=0.0.4")
+
+	host := strings.TrimPrefix(ts.URL, "http://")
+	text := w.Body.String()
+	for _, line := range []string{
+		"# TYPE api_requests_total counter",
+		`api_requests_total{method="GET",host="` + host + `",status="4xx"} 1`,
+		"# TYPE api_request_duration_seconds histogram",
+		`api_request_duration_seconds_bucket{method="GET",host="` + host + `",le="0.5"} 1`,
+		`api_request_duration_seconds_bucket{method="GET",host="` + host + `",le="+Inf"} 1`,
+		`api_request_duration_seconds_count{method="GET",host="` + host + `"} 1`,
+		"# TYPE api_requests_in_flight gauge",
+		"api_requests_in_flight 0",
+		"api_sent_bytes_total 0",
+		"api_received_bytes_total 0",
+	} {
+		assert.StrContains(t, text, line)
+	}
+	assert.NotContains(t, text, "api_retries_total{")
+}
+
+func TestStatusClass(t *testing.T) {
+	assert.Eq(t, "2xx", metrics.StatusClass(204))
+	assert.Eq(t, "5xx", metrics.StatusClass(599))
+	assert.Eq(t, "unknown", metrics.StatusClass(600))
+	// label escaping
+	mc := metrics.NewCollector()
+	_, _ = greq.New().Use(mc).Doer(doerFunc(func(r *http.Request) (*http.Response, error) {
+		return nil, &url.Error{Op: "Get", URL: r.URL.String(), Err: http.ErrHandlerTimeout}
+	})).GetDo(`http://a"b/`)
+	var sb strings.Builder
+	assert.NoErr(t, mc.WritePrometheus(&sb))
+	assert.StrContains(t, sb.String(), `host="a\"b"`)
+}
+
+type doerFunc func(r *http.Request) (*http.Response, error)
+
+func (fn doerFunc) Do(r *http.Request) (*http.Response, error) { return fn(r) }
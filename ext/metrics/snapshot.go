@@ -0,0 +1,222 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Count is a counter value of a series
+type Count struct {
+	Method string
+	Host   string
+	// Status class. eg: "2xx", "5xx", "error". empty for the retry and hedge counts.
+	Status string
+	Value  int64
+}
+
+// Histogram is a latency histogram of a series, in seconds.
+type Histogram struct {
+	Method string
+	Host   string
+	// Buckets upper bounds, not contains +Inf
+	Buckets []float64
+	// Counts cumulative counts of each bucket
+	Counts []int64
+	// Count total observations, it's the count of +Inf bucket.
+	Count int64
+	// Sum of the observed values, in seconds.
+	Sum float64
+}
+
+// Mean get the mean latency
+func (h *Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return time.Duration(h.Sum / float64(h.Count) * float64(time.Second))
+}
+
+// Metrics is a snapshot of the collected metrics.
+type Metrics struct {
+	// Requests count by method, host and status class
+	Requests []Count
+	// Retries count by method and host
+	Retries []Count
+	// Hedges count of the hedged requests by method and host
+	Hedges []Count
+	// Latency histograms by method and host
+	Latency []Histogram
+	// InFlight requests count
+	InFlight int64
+	// BytesSent request body bytes
+	BytesSent int64
+	// BytesReceived response body bytes, counted on the body read.
+	BytesReceived int64
+}
+
+// TotalRequests get the total requests(attempts) count
+func (m *Metrics) TotalRequests() int64 { return sumCounts(m.Requests) }
+
+// TotalRetries get the total retries count
+func (m *Metrics) TotalRetries() int64 { return sumCounts(m.Retries) }
+
+// TotalHedges get the total hedged requests count
+func (m *Metrics) TotalHedges() int64 { return sumCounts(m.Hedges) }
+
+// RequestCount get the requests count by status class. eg: "2xx". empty for all.
+func (m *Metrics) RequestCount(status string) (n int64) {
+	for _, c := range m.Requests {
+		if status == "" || c.Status == status {
+			n += c.Value
+		}
+	}
+	return n
+}
+
+func sumCounts(cs []Count) (n int64) {
+	for _, c := range cs {
+		n += c.Value
+	}
+	return n
+}
+
+// Snapshot get a snapshot of the collected metrics. the series are sorted by labels.
+func (c *Collector) Snapshot() *Metrics {
+	m := &Metrics{
+		InFlight:      c.inFlight.Load(),
+		BytesSent:     c.sent.Load(),
+		BytesReceived: c.received.Load(),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	m.Requests = toCounts(c.requests)
+	m.Retries = toCounts(c.retries)
+	m.Hedges = toCounts(c.hedges)
+	for key, h := range c.latency {
+		hs := Histogram{
+			Method:  key.method,
+			Host:    key.host,
+			Buckets: c.buckets,
+			Counts:  make([]int64, len(c.buckets)),
+			Count:   h.count,
+			Sum:     h.sum,
+		}
+
+		var cum int64
+		for i := range c.buckets {
+			cum += h.counts[i]
+			hs.Counts[i] = cum
+		}
+		m.Latency = append(m.Latency, hs)
+	}
+	sort.Slice(m.Latency, func(i, j int) bool {
+		a, b := m.Latency[i], m.Latency[j]
+		return a.Host+" "+a.Method < b.Host+" "+b.Method
+	})
+	return m
+}
+
+func toCounts(mp map[seriesKey]int64) []Count {
+	cs := make([]Count, 0, len(mp))
+	for key, n := range mp {
+		cs = append(cs, Count{Method: key.method, Host: key.host, Status: key.status, Value: n})
+	}
+
+	sort.Slice(cs, func(i, j int) bool {
+		a, b := cs[i], cs[j]
+		if a.Host != b.Host {
+			return a.Host < b.Host
+		}
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		return a.Status < b.Status
+	})
+	return cs
+}
+
+// Handler get a http.Handler for serve the metrics in Prometheus text format.
+func (c *Collector) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = c.WritePrometheus(w)
+	})
+}
+
+// WritePrometheus write the metrics in Prometheus text exposition format.
+func (c *Collector) WritePrometheus(w io.Writer) error {
+	m := c.Snapshot()
+	ns := c.namespace
+	if ns != "" {
+		ns += "_"
+	}
+
+	bw := bufio.NewWriter(w)
+	writeHeader(bw, ns+"requests_total", "counter", "Total number of HTTP request attempts.")
+	for _, cnt := range m.Requests {
+		fmt.Fprintf(bw, "%srequests_total%s %d\n", ns, labels("method", cnt.Method, "host", cnt.Host, "status", cnt.Status), cnt.Value)
+	}
+
+	writeHeader(bw, ns+"retries_total", "counter", "Total number of HTTP request retries.")
+	for _, cnt := range m.Retries {
+		fmt.Fprintf(bw, "%sretries_total%s %d\n", ns, labels("method", cnt.Method, "host", cnt.Host), cnt.Value)
+	}
+
+	writeHeader(bw, ns+"hedges_total", "counter", "Total number of hedged HTTP requests.")
+	for _, cnt := range m.Hedges {
+		fmt.Fprintf(bw, "%shedges_total%s %d\n", ns, labels("method", cnt.Method, "host", cnt.Host), cnt.Value)
+	}
+
+	name := ns + "request_duration_seconds"
+	writeHeader(bw, name, "histogram", "HTTP request attempt latency in seconds.")
+	for _, h := range m.Latency {
+		for i, le := range h.Buckets {
+			fmt.Fprintf(bw, "%s_bucket%s %d\n", name, labels("method", h.Method, "host", h.Host, "le", formatFloat(le)), h.Counts[i])
+		}
+		fmt.Fprintf(bw, "%s_bucket%s %d\n", name, labels("method", h.Method, "host", h.Host, "le", "+Inf"), h.Count)
+
+		lbs := labels("method", h.Method, "host", h.Host)
+		fmt.Fprintf(bw, "%s_sum%s %s\n", name, lbs, formatFloat(h.Sum))
+		fmt.Fprintf(bw, "%s_count%s %d\n", name, lbs, h.Count)
+	}
+
+	writeHeader(bw, ns+"requests_in_flight", "gauge", "Number of HTTP requests in flight.")
+	fmt.Fprintf(bw, "%srequests_in_flight %d\n", ns, m.InFlight)
+	writeHeader(bw, ns+"sent_bytes_total", "counter", "Total bytes of HTTP request bodies.")
+	fmt.Fprintf(bw, "%ssent_bytes_total %d\n", ns, m.BytesSent)
+	writeHeader(bw, ns+"received_bytes_total", "counter", "Total bytes of HTTP response bodies.")
+	fmt.Fprintf(bw, "%sreceived_bytes_total %d\n", ns, m.BytesReceived)
+	return bw.Flush()
+}
+
+func writeHeader(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels format the label pairs. eg: {method="GET",host="example.com"}
+func labels(kvs ...string) string {
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i := 0; i < len(kvs); i += 2 {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(kvs[i] + `="` + labelEscaper.Replace(kvs[i+1]) + `"`)
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,215 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+)
+
+// propagation header names
+const (
+	HeaderTraceparent = "traceparent"
+	HeaderTracestate  = "tracestate"
+
+	HeaderB3             = "b3"
+	HeaderB3TraceID      = "X-B3-TraceId"
+	HeaderB3SpanID       = "X-B3-SpanId"
+	HeaderB3ParentSpanID = "X-B3-ParentSpanId"
+	HeaderB3Sampled      = "X-B3-Sampled"
+)
+
+// ErrInvalidTraceparent is returned on parse an invalid traceparent value
+var ErrInvalidTraceparent = errors.New("tracing: invalid traceparent")
+
+// TraceID is a W3C trace ID
+type TraceID [16]byte
+
+// IsValid check the trace ID is not all zero
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+
+// String get the hex string
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanID is a W3C span(parent) ID
+type SpanID [8]byte
+
+// IsValid check the span ID is not all zero
+func (id SpanID) IsValid() bool { return id != SpanID{} }
+
+// String get the hex string
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// NewTraceID generate a random trace ID
+func NewTraceID() (id TraceID) {
+	for !id.IsValid() {
+		putUint64(id[:8], rand.Uint64())
+		putUint64(id[8:], rand.Uint64())
+	}
+	return id
+}
+
+// NewSpanID generate a random span ID
+func NewSpanID() (id SpanID) {
+	for !id.IsValid() {
+		putUint64(id[:], rand.Uint64())
+	}
+	return id
+}
+
+func putUint64(b []byte, v uint64) {
+	for i := 0; i < 8; i++ {
+		b[i] = byte(v >> (56 - 8*i))
+	}
+}
+
+// SpanContext is the propagated identity of a span
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+	// TraceState the W3C tracestate value, is propagated as is.
+	TraceState string
+}
+
+// IsValid check the trace ID and span ID are valid
+func (sc SpanContext) IsValid() bool { return sc.TraceID.IsValid() && sc.SpanID.IsValid() }
+
+// Traceparent format as the W3C traceparent header value.
+//
+// eg: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent parse the W3C traceparent header value
+func ParseTraceparent(s string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return sc, ErrInvalidTraceparent
+	}
+	// version 00 must have exactly 4 parts
+	if parts[0] == "00" && len(parts) != 4 {
+		return sc, ErrInvalidTraceparent
+	}
+
+	var flags [1]byte
+	if !decodeHex(sc.TraceID[:], parts[1]) || !decodeHex(sc.SpanID[:], parts[2]) || !decodeHex(flags[:], parts[3]) {
+		return sc, ErrInvalidTraceparent
+	}
+	if !sc.IsValid() {
+		return sc, ErrInvalidTraceparent
+	}
+
+	sc.Sampled = flags[0]&0x01 == 1
+	return sc, nil
+}
+
+func decodeHex(dst []byte, s string) bool {
+	if len(s) != len(dst)*2 || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
+
+// Extract the span context from the W3C or B3 headers. returns invalid SpanContext if not found.
+func Extract(h http.Header) SpanContext {
+	if tp := h.Get(HeaderTraceparent); tp != "" {
+		if sc, err := ParseTraceparent(tp); err == nil {
+			sc.TraceState = h.Get(HeaderTracestate)
+			return sc
+		}
+	}
+
+	// B3 single header: {TraceId}-{SpanId}-{SamplingState}-{ParentSpanId}
+	if b3 := h.Get(HeaderB3); b3 != "" {
+		parts := strings.Split(b3, "-")
+		if len(parts) >= 2 {
+			return parseB3(parts[0], parts[1], strings.Join(parts[2:3], ""))
+		}
+	}
+	if tid := h.Get(HeaderB3TraceID); tid != "" {
+		return parseB3(tid, h.Get(HeaderB3SpanID), h.Get(HeaderB3Sampled))
+	}
+	return SpanContext{}
+}
+
+func parseB3(traceID, spanID, sampled string) SpanContext {
+	var sc SpanContext
+	// 64-bit trace IDs are left-padded
+	if len(traceID) == 16 {
+		traceID = strings.Repeat("0", 16) + traceID
+	}
+	if !decodeHex(sc.TraceID[:], traceID) || !decodeHex(sc.SpanID[:], spanID) {
+		return SpanContext{}
+	}
+
+	// defer the sampling decision to the receiver when absent
+	sc.Sampled = sampled != "0"
+	if !sc.IsValid() {
+		return SpanContext{}
+	}
+	return sc
+}
+
+// Inject the span context to the W3C traceparent and tracestate headers.
+func Inject(h http.Header, sc SpanContext) {
+	h.Set(HeaderTraceparent, sc.Traceparent())
+	if sc.TraceState != "" {
+		h.Set(HeaderTracestate, sc.TraceState)
+	} else {
+		h.Del(HeaderTracestate)
+	}
+}
+
+// InjectB3 inject the span context to B3 headers, use single "b3" header or multi X-B3-* headers.
+func InjectB3(h http.Header, sc SpanContext, parent SpanID, single bool) {
+	sampled := "0"
+	if sc.Sampled {
+		sampled = "1"
+	}
+
+	if single {
+		val := sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + sampled
+		if parent.IsValid() {
+			val += "-" + parent.String()
+		}
+		h.Set(HeaderB3, val)
+		return
+	}
+
+	h.Set(HeaderB3TraceID, sc.TraceID.String())
+	h.Set(HeaderB3SpanID, sc.SpanID.String())
+	h.Set(HeaderB3Sampled, sampled)
+	if parent.IsValid() {
+		h.Set(HeaderB3ParentSpanID, parent.String())
+	} else {
+		h.Del(HeaderB3ParentSpanID)
+	}
+}
+
+type spanCtxKey struct{}
+
+// ContextWithSpanContext set the span context as the parent for requests with the ctx.
+//
+// Usage:
+//
+//	ctx := tracing.ContextWithSpanContext(ctx, parentSC)
+//	resp, err := client.GetDo("/users", greq.WithContext(ctx))
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanCtxKey{}, sc)
+}
+
+// SpanContextFromContext get the span context from ctx. returns invalid SpanContext if not found.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	sc, _ := ctx.Value(spanCtxKey{}).(SpanContext)
+	return sc
+}
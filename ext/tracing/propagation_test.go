@@ -0,0 +1,69 @@
+package tracing_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq/ext/tracing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	sc, err := tracing.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.NoErr(t, err)
+	assert.True(t, sc.IsValid())
+	assert.True(t, sc.Sampled)
+	assert.Eq(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Eq(t, "00f067aa0ba902b7", sc.SpanID.String())
+	assert.Eq(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", sc.Traceparent())
+
+	// future version can have more fields
+	sc, err = tracing.ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra")
+	assert.NoErr(t, err)
+	assert.False(t, sc.Sampled)
+
+	for _, s := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		_, err = tracing.ParseTraceparent(s)
+		assert.ErrIs(t, err, tracing.ErrInvalidTraceparent)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	h := http.Header{}
+	assert.False(t, tracing.Extract(h).IsValid())
+
+	h.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.Set("tracestate", "a=1")
+	sc := tracing.Extract(h)
+	assert.True(t, sc.IsValid())
+	assert.Eq(t, "a=1", sc.TraceState)
+
+	// b3 single, 64-bit trace ID
+	h = http.Header{}
+	h.Set("b3", "a3ce929d0e0e4736-00f067aa0ba902b7-0")
+	sc = tracing.Extract(h)
+	assert.True(t, sc.IsValid())
+	assert.False(t, sc.Sampled)
+	assert.Eq(t, "0000000000000000a3ce929d0e0e4736", sc.TraceID.String())
+
+	// b3 multi
+	h = http.Header{}
+	tracing.InjectB3(h, tracing.SpanContext{
+		TraceID: tracing.NewTraceID(),
+		SpanID:  tracing.NewSpanID(),
+		Sampled: true,
+	}, tracing.SpanID{}, false)
+	assert.Empty(t, h.Get("X-B3-ParentSpanId"))
+	assert.Eq(t, "1", h.Get("X-B3-Sampled"))
+	sc = tracing.Extract(h)
+	assert.True(t, sc.IsValid())
+	assert.Eq(t, h.Get("X-B3-TraceId"), sc.TraceID.String())
+}
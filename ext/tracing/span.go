@@ -0,0 +1,88 @@
+package tracing
+
+import (
+	"sync"
+	"time"
+)
+
+// span attribute keys, follow the OpenTelemetry semantic conventions.
+const (
+	AttrMethod      = "http.request.method"
+	AttrURL         = "url.full"
+	AttrStatusCode  = "http.response.status_code"
+	AttrResendCount = "http.request.resend_count"
+	AttrError       = "error.message"
+	// AttrCostTime the cost time(ms) of the attempt, same as Response.CostTime
+	AttrCostTime = "greq.cost_time_ms"
+	// AttrHedge the hedge number of the hedged request. see greq.Client.WithHedging
+	AttrHedge = "greq.hedge"
+)
+
+// SpanKindClient the kind of spans created by the Tracer
+const SpanKindClient = "client"
+
+// Span is a finished client span of a request attempt
+type Span struct {
+	Name string
+	Kind string
+	// SpanContext of the span. it's injected to the request headers.
+	SpanContext SpanContext
+	// Parent span context. invalid for the root span.
+	Parent SpanContext
+
+	StartTime time.Time
+	EndTime   time.Time
+	// Attributes of the span. see the Attr* constants.
+	Attributes map[string]any
+	// Err the request error, nil on success
+	Err error
+}
+
+// Duration of the span
+func (s *Span) Duration() time.Duration { return s.EndTime.Sub(s.StartTime) }
+
+// IsRoot check the span has no parent
+func (s *Span) IsRoot() bool { return !s.Parent.IsValid() }
+
+// SpanExporter export the finished spans. It can be used to bridge to OpenTelemetry or others.
+//
+// NOTE: ExportSpan is called in the request goroutine, don't block it.
+type SpanExporter interface {
+	ExportSpan(span *Span)
+}
+
+// ExporterFunc wrap a func as SpanExporter
+type ExporterFunc func(span *Span)
+
+// ExportSpan implements the SpanExporter
+func (fn ExporterFunc) ExportSpan(span *Span) { fn(span) }
+
+// InMemoryExporter collect the spans in memory, useful for testing.
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+// NewInMemoryExporter create a new InMemoryExporter
+func NewInMemoryExporter() *InMemoryExporter { return &InMemoryExporter{} }
+
+// ExportSpan implements the SpanExporter
+func (e *InMemoryExporter) ExportSpan(span *Span) {
+	e.mu.Lock()
+	e.spans = append(e.spans, span)
+	e.mu.Unlock()
+}
+
+// Spans get the exported spans
+func (e *InMemoryExporter) Spans() []*Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*Span(nil), e.spans...)
+}
+
+// Reset clear the exported spans
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	e.spans = nil
+	e.mu.Unlock()
+}
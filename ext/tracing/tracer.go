@@ -0,0 +1,146 @@
+// Package tracing provides a distributed tracing middleware for greq, with W3C traceparent propagation.
+//
+// It has no third-party dependencies, spans are exported by a SpanExporter,
+// implement it to bridge to OpenTelemetry, Zipkin, etc.
+//
+// Usage:
+//
+//	exporter := tracing.NewInMemoryExporter()
+//	client := greq.New("https://api.example.com").Use(tracing.NewTracer(exporter, tracing.WithB3(true)))
+//
+// Each attempt of a request is a client span. The retries are child spans of the first attempt.
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gookit/greq"
+)
+
+// OptionFn is a function to configure the Tracer
+type OptionFn func(t *Tracer)
+
+// WithB3 enable inject the B3 headers, in addition to the W3C headers.
+// single: use the single "b3" header, otherwise the multi X-B3-* headers.
+func WithB3(single bool) OptionFn {
+	return func(t *Tracer) {
+		t.b3 = true
+		t.b3Single = single
+	}
+}
+
+// WithSpanName set the func for build span name. default is "HTTP {METHOD}"
+func WithSpanName(fn func(r *http.Request) string) OptionFn {
+	return func(t *Tracer) {
+		t.spanName = fn
+	}
+}
+
+// WithParentFunc set the func for get parent span context from request context.
+// It can be used to bridge the span context of OpenTelemetry.
+//
+// default is SpanContextFromContext
+func WithParentFunc(fn func(ctx context.Context) SpanContext) OptionFn {
+	return func(t *Tracer) {
+		t.parentFn = fn
+	}
+}
+
+// Tracer is a middleware for create client span per request attempt,
+// and inject the trace headers to the request.
+type Tracer struct {
+	exporter SpanExporter
+	parentFn func(ctx context.Context) SpanContext
+	spanName func(r *http.Request) string
+
+	b3       bool
+	b3Single bool
+}
+
+// key for save the first attempt span context to greq.ReqState
+type firstSpanKey struct{}
+
+// NewTracer create a tracing middleware
+func NewTracer(exporter SpanExporter, optFns ...OptionFn) *Tracer {
+	t := &Tracer{
+		exporter: exporter,
+		parentFn: SpanContextFromContext,
+		spanName: func(r *http.Request) string { return "HTTP " + r.Method },
+	}
+	for _, fn := range optFns {
+		fn(t)
+	}
+	return t
+}
+
+// Handle implements the greq.Middleware
+func (t *Tracer) Handle(r *http.Request, next greq.HandleFunc) (*greq.Response, error) {
+	st := greq.GetReqState(r)
+	parent := t.parentOf(r, st)
+
+	sc := SpanContext{SpanID: NewSpanID(), Sampled: true}
+	if parent.IsValid() {
+		sc.TraceID, sc.Sampled, sc.TraceState = parent.TraceID, parent.Sampled, parent.TraceState
+	} else {
+		sc.TraceID = NewTraceID()
+	}
+	if st != nil && st.Attempt == 0 && st.Hedge == 0 {
+		st.SetValue(firstSpanKey{}, sc)
+	}
+
+	Inject(r.Header, sc)
+	if t.b3 {
+		InjectB3(r.Header, sc, parent.SpanID, t.b3Single)
+	}
+
+	span := &Span{
+		Name:        t.spanName(r),
+		Kind:        SpanKindClient,
+		SpanContext: sc,
+		Parent:      parent,
+		StartTime:   time.Now(),
+		Attributes: map[string]any{
+			AttrMethod: r.Method,
+			AttrURL:    r.URL.Redacted(),
+		},
+	}
+	if st != nil && st.Attempt > 0 {
+		span.Attributes[AttrResendCount] = st.Attempt
+	}
+	if st != nil && st.Hedge > 0 {
+		span.Attributes[AttrHedge] = st.Hedge
+	}
+
+	resp, err := next(r)
+	span.EndTime = time.Now()
+	span.Attributes[AttrCostTime] = span.EndTime.Sub(span.StartTime).Milliseconds()
+	if resp != nil {
+		span.Attributes[AttrStatusCode] = resp.StatusCode
+	}
+	if err != nil {
+		span.Err = err
+		span.Attributes[AttrError] = err.Error()
+	}
+
+	if sc.Sampled && t.exporter != nil {
+		t.exporter.ExportSpan(span)
+	}
+	return resp, err
+}
+
+// parentOf get the parent span context: the first attempt span for retries and hedges,
+// or from the context, or from the request headers.
+func (t *Tracer) parentOf(r *http.Request, st *greq.ReqState) SpanContext {
+	if st != nil && (st.Attempt > 0 || st.Hedge > 0) {
+		if sc, ok := st.Value(firstSpanKey{}).(SpanContext); ok {
+			return sc
+		}
+	}
+
+	if sc := t.parentFn(r.Context()); sc.IsValid() {
+		return sc
+	}
+	return Extract(r.Header)
+}
@@ -0,0 +1,154 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq"
+	"github.com/gookit/greq/ext/tracing"
+)
+
+func TestTracer_Handle(t *testing.T) {
+	var gotHeaders []http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = append(gotHeaders, r.Header.Clone())
+		w.WriteHeader(201)
+	}))
+	defer ts.Close()
+
+	exp := tracing.NewInMemoryExporter()
+	client := greq.New(ts.URL).Use(tracing.NewTracer(exp))
+
+	resp, err := client.PostDo("/users?a=1")
+	assert.NoErr(t, err)
+	assert.Eq(t, 201, resp.StatusCode)
+
+	spans := exp.Spans()
+	assert.Len(t, spans, 1)
+	span := spans[0]
+	assert.True(t, span.IsRoot())
+	assert.Eq(t, "HTTP POST", span.Name)
+	assert.Eq(t, tracing.SpanKindClient, span.Kind)
+	assert.Eq(t, "POST", span.Attributes[tracing.AttrMethod])
+	assert.Eq(t, ts.URL+"/users?a=1", span.Attributes[tracing.AttrURL])
+	assert.Eq(t, 201, span.Attributes[tracing.AttrStatusCode])
+	assert.NotNil(t, span.Attributes[tracing.AttrCostTime])
+	assert.True(t, span.Duration() >= 0)
+
+	// header injected
+	sc, err := tracing.ParseTraceparent(gotHeaders[0].Get("traceparent"))
+	assert.NoErr(t, err)
+	assert.Eq(t, span.SpanContext.TraceID, sc.TraceID)
+	assert.Eq(t, span.SpanContext.SpanID, sc.SpanID)
+	assert.True(t, sc.Sampled)
+	assert.Empty(t, gotHeaders[0].Get("b3"))
+
+	exp.Reset()
+	assert.Empty(t, exp.Spans())
+}
+
+func TestTracer_retries(t *testing.T) {
+	var parents []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parents = append(parents, r.Header.Get("traceparent"))
+		w.WriteHeader(503)
+	}))
+	defer ts.Close()
+
+	exp := tracing.NewInMemoryExporter()
+	client := greq.New(ts.URL).WithMaxRetries(2).Use(tracing.NewTracer(exp, tracing.WithB3(false)))
+
+	_, err := client.GetDo("/retry")
+	assert.NoErr(t, err)
+	assert.Len(t, parents, 3)
+
+	spans := exp.Spans()
+	assert.Len(t, spans, 3)
+	first := spans[0]
+	assert.True(t, first.IsRoot())
+	assert.Nil(t, first.Attributes[tracing.AttrResendCount])
+	for i, span := range spans[1:] {
+		assert.Eq(t, first.SpanContext.TraceID, span.SpanContext.TraceID)
+		assert.Eq(t, first.SpanContext.SpanID, span.Parent.SpanID)
+		assert.Eq(t, i+1, span.Attributes[tracing.AttrResendCount])
+		assert.Eq(t, 503, span.Attributes[tracing.AttrStatusCode])
+	}
+	assert.Eq(t, spans[2].SpanContext.Traceparent(), parents[2])
+}
+
+func TestTracer_hedges(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	defer ts.Close()
+
+	exp := tracing.NewInMemoryExporter()
+	client := greq.New(ts.URL).WithHedging(20*time.Millisecond, 1).Use(tracing.NewTracer(exp))
+	_, err := client.GetDo("/hedge")
+	assert.NoErr(t, err)
+
+	// wait the slow one done
+	time.Sleep(150 * time.Millisecond)
+	spans := exp.Spans()
+	assert.Len(t, spans, 2)
+	hedge, first := spans[0], spans[1]
+	assert.True(t, first.IsRoot())
+	assert.Eq(t, first.SpanContext.SpanID, hedge.Parent.SpanID)
+	assert.Eq(t, 1, hedge.Attributes[tracing.AttrHedge])
+	assert.Nil(t, first.Attributes[tracing.AttrHedge])
+}
+
+func TestTracer_parentAndError(t *testing.T) {
+	var b3 string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b3 = r.Header.Get("b3")
+	}))
+	defer ts.Close()
+
+	parent, err := tracing.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.NoErr(t, err)
+	parent.TraceState = "vendor=abc"
+	ctx := tracing.ContextWithSpanContext(context.Background(), parent)
+
+	var spans []*tracing.Span
+	exp := tracing.ExporterFunc(func(span *tracing.Span) { spans = append(spans, span) })
+	client := greq.New(ts.URL).Use(tracing.NewTracer(exp,
+		tracing.WithB3(true),
+		tracing.WithSpanName(func(r *http.Request) string { return r.Method + " " + r.URL.Path }),
+	))
+
+	_, err = client.GetDo("/users", greq.WithContext(ctx))
+	assert.NoErr(t, err)
+	assert.Len(t, spans, 1)
+	span := spans[0]
+	assert.Eq(t, "GET /users", span.Name)
+	assert.Eq(t, parent.TraceID, span.SpanContext.TraceID)
+	assert.Eq(t, parent.SpanID, span.Parent.SpanID)
+	assert.Eq(t, "vendor=abc", span.SpanContext.TraceState)
+	want := parent.TraceID.String() + "-" + span.SpanContext.SpanID.String() + "-1-" + parent.SpanID.String()
+	assert.Eq(t, want, b3)
+
+	// connection error
+	_, err = greq.New("http://127.0.0.1:1").Use(tracing.NewTracer(exp)).GetDo("/")
+	assert.Err(t, err)
+	assert.Len(t, spans, 2)
+	assert.Err(t, spans[1].Err)
+	assert.NotEmpty(t, spans[1].Attributes[tracing.AttrError])
+	assert.Nil(t, spans[1].Attributes[tracing.AttrStatusCode])
+
+	// not sampled parent: propagated but not exported
+	parent.Sampled = false
+	ctx = tracing.ContextWithSpanContext(context.Background(), parent)
+	_, err = client.GetDo("/users", greq.WithContext(ctx))
+	assert.NoErr(t, err)
+	assert.Len(t, spans, 2)
+	assert.StrContains(t, b3, "-0-")
+}
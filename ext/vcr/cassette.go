@@ -0,0 +1,120 @@
+package vcr
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CassetteVersion the version of cassette format
+const CassetteVersion = 1
+
+// Cassette is a list of recorded HTTP interactions.
+//
+// It is saved as YAML, or JSON if the file ext is ".json".
+type Cassette struct {
+	Version      int            `json:"version" yaml:"version"`
+	Interactions []*Interaction `json:"interactions" yaml:"interactions"`
+}
+
+// Interaction is a recorded request and response pair
+type Interaction struct {
+	Request    Request   `json:"request" yaml:"request"`
+	Response   Response  `json:"response" yaml:"response"`
+	RecordedAt time.Time `json:"recorded_at" yaml:"recorded_at"`
+}
+
+// Request recorded request info
+type Request struct {
+	Method  string      `json:"method" yaml:"method"`
+	URL     string      `json:"url" yaml:"url"`
+	Headers http.Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body    string      `json:"body,omitempty" yaml:"body,omitempty"`
+	// BodyEncoding is "base64" for binary body
+	BodyEncoding string `json:"body_encoding,omitempty" yaml:"body_encoding,omitempty"`
+}
+
+// BodyBytes get the decoded body contents
+func (r *Request) BodyBytes() ([]byte, error) { return decodeBody(r.Body, r.BodyEncoding) }
+
+// SetBody set the body contents, binary contents will be base64 encoded.
+func (r *Request) SetBody(bs []byte) { r.Body, r.BodyEncoding = encodeBody(bs) }
+
+// Response recorded response info
+type Response struct {
+	StatusCode int         `json:"status_code" yaml:"status_code"`
+	Headers    http.Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body       string      `json:"body,omitempty" yaml:"body,omitempty"`
+	// BodyEncoding is "base64" for binary body
+	BodyEncoding string `json:"body_encoding,omitempty" yaml:"body_encoding,omitempty"`
+}
+
+// BodyBytes get the decoded body contents
+func (r *Response) BodyBytes() ([]byte, error) { return decodeBody(r.Body, r.BodyEncoding) }
+
+// SetBody set the body contents, binary contents will be base64 encoded.
+func (r *Response) SetBody(bs []byte) { r.Body, r.BodyEncoding = encodeBody(bs) }
+
+func encodeBody(bs []byte) (text, encoding string) {
+	if utf8.Valid(bs) {
+		return string(bs), ""
+	}
+	return base64.StdEncoding.EncodeToString(bs), "base64"
+}
+
+func decodeBody(text, encoding string) ([]byte, error) {
+	if encoding == "base64" {
+		return base64.StdEncoding.DecodeString(text)
+	}
+	return []byte(text), nil
+}
+
+// LoadCassette load cassette from file. YAML or JSON by the file ext.
+func LoadCassette(path string) (*Cassette, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cassette{}
+	if isJSONFile(path) {
+		err = json.Unmarshal(bs, c)
+	} else {
+		err = yaml.Unmarshal(bs, c)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("vcr: parse cassette %q error: %w", path, err)
+	}
+	return c, nil
+}
+
+// Save the cassette to file. YAML or JSON by the file ext.
+func (c *Cassette) Save(path string) error {
+	var bs []byte
+	var err error
+	if isJSONFile(path) {
+		bs, err = json.MarshalIndent(c, "", "  ")
+	} else {
+		bs, err = yaml.Marshal(c)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, bs, 0644)
+}
+
+func isJSONFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
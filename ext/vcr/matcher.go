@@ -0,0 +1,81 @@
+package vcr
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// Matcher check the request is matched with the recorded request.
+// body is the read request body.
+type Matcher func(r *http.Request, body []byte, rec *Request) bool
+
+// DefaultMatchers match by method, URL, query(order-insensitive) and body(JSON-equivalent)
+var DefaultMatchers = []Matcher{MatchMethod, MatchURL, MatchQuery, MatchBody}
+
+// MatchMethod match the request method
+func MatchMethod(r *http.Request, _ []byte, rec *Request) bool {
+	return strings.EqualFold(r.Method, rec.Method)
+}
+
+// MatchURL match the scheme, host and path of the URL. not contains query.
+func MatchURL(r *http.Request, _ []byte, rec *Request) bool {
+	ru, err := url.Parse(rec.URL)
+	if err != nil {
+		return false
+	}
+	return r.URL.Scheme == ru.Scheme && strings.EqualFold(r.URL.Host, ru.Host) && r.URL.Path == ru.Path
+}
+
+// MatchQuery match the URL query, the order of params is ignored.
+func MatchQuery(r *http.Request, _ []byte, rec *Request) bool {
+	ru, err := url.Parse(rec.URL)
+	if err != nil {
+		return false
+	}
+
+	want, got := ru.Query(), r.URL.Query()
+	if len(want) == 0 && len(got) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(want, got)
+}
+
+// MatchBody match the request body. JSON bodies are compared by value, eg: the keys order is ignored.
+func MatchBody(_ *http.Request, body []byte, rec *Request) bool {
+	want, err := rec.BodyBytes()
+	if err != nil {
+		return false
+	}
+	if bytes.Equal(want, body) {
+		return true
+	}
+	return jsonEqual(want, body)
+}
+
+// MatchHeaders create a matcher for match the given header values.
+func MatchHeaders(names ...string) Matcher {
+	return func(r *http.Request, _ []byte, rec *Request) bool {
+		for _, name := range names {
+			if !reflect.DeepEqual(r.Header.Values(name), rec.Headers.Values(name)) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func jsonEqual(a, b []byte) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return false
+	}
+
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
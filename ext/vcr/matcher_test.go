@@ -0,0 +1,43 @@
+package vcr_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq/ext/vcr"
+)
+
+func TestMatchers(t *testing.T) {
+	rec := &vcr.Request{
+		Method:  "POST",
+		URL:     "https://example.com/api?a=1&b=2",
+		Headers: http.Header{"X-Version": {"2"}},
+	}
+	rec.SetBody([]byte(`{"a": 1, "b": [1, 2]}`))
+
+	req, err := http.NewRequest("post", "https://example.com/api?b=2&a=1", nil)
+	assert.NoErr(t, err)
+	req.Header.Set("X-Version", "2")
+
+	assert.True(t, vcr.MatchMethod(req, nil, rec))
+	assert.True(t, vcr.MatchURL(req, nil, rec))
+	assert.True(t, vcr.MatchQuery(req, nil, rec))
+	assert.True(t, vcr.MatchHeaders("X-Version")(req, nil, rec))
+
+	assert.True(t, vcr.MatchBody(req, []byte(`{"b":[1,2],"a":1}`), rec))
+	assert.False(t, vcr.MatchBody(req, []byte(`{"b":[2,1],"a":1}`), rec))
+	assert.False(t, vcr.MatchBody(req, []byte(`a=1`), rec))
+
+	req.URL.RawQuery = "a=1"
+	assert.False(t, vcr.MatchQuery(req, nil, rec))
+	req.URL.Path = "/other"
+	assert.False(t, vcr.MatchURL(req, nil, rec))
+	req.Header.Set("X-Version", "3")
+	assert.False(t, vcr.MatchHeaders("X-Version")(req, nil, rec))
+
+	// binary body
+	rec.SetBody([]byte{0xff, 0xfe})
+	assert.Eq(t, "base64", rec.BodyEncoding)
+	assert.True(t, vcr.MatchBody(req, []byte{0xff, 0xfe}, rec))
+}
@@ -0,0 +1,317 @@
+// Package vcr provides a cassette based httpreq.Doer for record and replay HTTP interactions in tests.
+//
+// Usage:
+//
+//	rec, err := vcr.New("testdata/github.yaml", vcr.WithMode(vcr.ModeRecordMissing))
+//	client := greq.New("https://api.github.com").Doer(rec)
+//
+// Modes:
+//
+//   - ModeReplay: only replay from the cassette, return error if not matched.
+//   - ModeRecord: always send the real request and record it, the cassette is overwritten.
+//   - ModeRecordMissing: replay if matched, otherwise send the real request and record it.
+//   - ModePassthrough: send the real request, not record.
+package vcr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gookit/goutil/netutil/httpreq"
+	"github.com/gookit/greq"
+)
+
+// Mode of the recorder
+type Mode int
+
+// recorder modes
+const (
+	ModeReplay Mode = iota
+	ModeRecord
+	ModeRecordMissing
+	ModePassthrough
+)
+
+// String get the mode name
+func (m Mode) String() string {
+	switch m {
+	case ModeReplay:
+		return "replay"
+	case ModeRecord:
+		return "record"
+	case ModeRecordMissing:
+		return "record-missing"
+	case ModePassthrough:
+		return "passthrough"
+	}
+	return "Mode(" + strconv.Itoa(int(m)) + ")"
+}
+
+// ParseMode parse mode from name. eg: "replay", "record", "record-missing", "passthrough"
+func ParseMode(name string) (Mode, error) {
+	for _, m := range []Mode{ModeReplay, ModeRecord, ModeRecordMissing, ModePassthrough} {
+		if strings.EqualFold(m.String(), name) {
+			return m, nil
+		}
+	}
+	return ModeReplay, fmt.Errorf("vcr: invalid mode %q", name)
+}
+
+// ErrNoInteraction is returned on no interaction matched in replay mode
+var ErrNoInteraction = errors.New("vcr: no interaction matched the request")
+
+// Scrubber modify the interaction before save to cassette. eg: remove secrets
+type Scrubber func(i *Interaction)
+
+// OptionFn is a function to configure the Recorder
+type OptionFn func(r *Recorder)
+
+// WithMode set the recorder mode. default is ModeReplay
+func WithMode(mode Mode) OptionFn {
+	return func(r *Recorder) {
+		r.mode = mode
+	}
+}
+
+// WithDoer set the real doer for send requests. default is greq.DefaultDoer
+func WithDoer(doer httpreq.Doer) OptionFn {
+	return func(r *Recorder) {
+		r.doer = doer
+	}
+}
+
+// WithMatchers set the request matchers. default is DefaultMatchers
+func WithMatchers(matchers ...Matcher) OptionFn {
+	return func(r *Recorder) {
+		r.matchers = matchers
+	}
+}
+
+// WithScrubbers add scrubbers for modify interactions before save.
+func WithScrubbers(fns ...Scrubber) OptionFn {
+	return func(r *Recorder) {
+		r.scrubbers = append(r.scrubbers, fns...)
+	}
+}
+
+// ScrubHeaders create a scrubber to redact the header values of request and response.
+func ScrubHeaders(names ...string) Scrubber {
+	return func(i *Interaction) {
+		for _, name := range names {
+			if i.Request.Headers.Get(name) != "" {
+				i.Request.Headers.Set(name, "REDACTED")
+			}
+			if i.Response.Headers.Get(name) != "" {
+				i.Response.Headers.Set(name, "REDACTED")
+			}
+		}
+	}
+}
+
+// Recorder is a httpreq.Doer to record and replay HTTP interactions with a cassette file.
+type Recorder struct {
+	mu       sync.Mutex
+	path     string
+	cassette *Cassette
+	// hits count of each interaction on replay
+	hits []int
+
+	mode      Mode
+	doer      httpreq.Doer
+	matchers  []Matcher
+	scrubbers []Scrubber
+}
+
+// New create a recorder with the cassette file.
+//
+// The cassette file is required in ModeReplay, and it will be created on record.
+func New(path string, optFns ...OptionFn) (*Recorder, error) {
+	r := &Recorder{
+		path:     path,
+		doer:     greq.DefaultDoer,
+		matchers: DefaultMatchers,
+		cassette: &Cassette{Version: CassetteVersion},
+	}
+	for _, fn := range optFns {
+		fn(r)
+	}
+
+	if r.mode == ModeReplay || r.mode == ModeRecordMissing {
+		c, err := LoadCassette(path)
+		if err == nil {
+			r.cassette = c
+		} else if r.mode == ModeReplay || !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	r.hits = make([]int, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// Mode get the recorder mode
+func (r *Recorder) Mode() Mode { return r.mode }
+
+// Cassette get the cassette. NOTE: don't modify it while sending requests.
+func (r *Recorder) Cassette() *Cassette { return r.cassette }
+
+// Save the cassette to the file. it's auto called after each recorded interaction.
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cassette.Save(r.path)
+}
+
+// Do implements the httpreq.Doer
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	if r.mode == ModePassthrough {
+		return r.doer.Do(req)
+	}
+
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.mode != ModeRecord {
+		if i := r.match(req, body); i != nil {
+			return i.toResponse(req)
+		}
+		if r.mode == ModeReplay {
+			return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, req.URL.String())
+		}
+	}
+	return r.record(req, body)
+}
+
+// record send the real request and add the interaction to cassette
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	resp, err := r.doer.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	// the upgraded connection can not be recorded and replayed
+	if resp.StatusCode == http.StatusSwitchingProtocols {
+		return resp, nil
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	i := &Interaction{
+		Request: Request{
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Headers: req.Header.Clone(),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Headers:    resp.Header.Clone(),
+		},
+		RecordedAt: time.Now(),
+	}
+	i.Request.SetBody(body)
+	i.Response.SetBody(respBody)
+	for _, fn := range r.scrubbers {
+		fn(i)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, i)
+	// mark as used, the same request will replay it in record-missing mode
+	r.hits = append(r.hits, 1)
+	if err := r.cassette.Save(r.path); err != nil {
+		return nil, fmt.Errorf("vcr: save cassette error: %w", err)
+	}
+	return resp, nil
+}
+
+// match find the first unused interaction, or the least used one.
+func (r *Recorder) match(req *http.Request, body []byte) *Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	found := -1
+	for idx, i := range r.cassette.Interactions {
+		if !r.matchOne(req, body, &i.Request) {
+			continue
+		}
+		if found < 0 || r.hits[idx] < r.hits[found] {
+			found = idx
+		}
+		if r.hits[idx] == 0 {
+			break
+		}
+	}
+
+	if found < 0 {
+		return nil
+	}
+	r.hits[found]++
+	return r.cassette.Interactions[found]
+}
+
+func (r *Recorder) matchOne(req *http.Request, body []byte, rec *Request) bool {
+	for _, m := range r.matchers {
+		if !m(req, body, rec) {
+			return false
+		}
+	}
+	return true
+}
+
+// readBody read the request body and reset it.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	bs, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(bs))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(bs)), nil
+	}
+	return bs, nil
+}
+
+func (i *Interaction) toResponse(req *http.Request) (*http.Response, error) {
+	body, err := i.Response.BodyBytes()
+	if err != nil {
+		return nil, fmt.Errorf("vcr: decode response body error: %w", err)
+	}
+
+	resp := &http.Response{
+		Status:        strconv.Itoa(i.Response.StatusCode) + " " + http.StatusText(i.Response.StatusCode),
+		StatusCode:    i.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        i.Response.Headers.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+	if resp.Header == nil {
+		resp.Header = make(http.Header)
+	}
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return resp, nil
+}
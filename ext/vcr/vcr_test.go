@@ -0,0 +1,132 @@
+package vcr_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq"
+	"github.com/gookit/greq/ext/vcr"
+)
+
+func newTestServer(t *testing.T, hits *int32) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(hits, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Token", "secret")
+		_, _ = w.Write([]byte(`{"path": "` + r.URL.Path + `", "hit": ` + string(rune('0'+n)) + `}`))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestRecorder_recordAndReplay(t *testing.T) {
+	for _, ext := range []string{".yaml", ".json"} {
+		t.Run(ext, func(t *testing.T) {
+			var hits int32
+			ts := newTestServer(t, &hits)
+			path := filepath.Join(t.TempDir(), "cassette"+ext)
+
+			rec, err := vcr.New(path, vcr.WithMode(vcr.ModeRecord), vcr.WithScrubbers(vcr.ScrubHeaders("X-Token")))
+			assert.NoErr(t, err)
+			client := greq.New(ts.URL).Doer(rec)
+
+			resp, err := client.PostDo("/users?a=1&b=2", greq.WithBody(`{"name": "inhere", "age": 3}`))
+			assert.NoErr(t, err)
+			// the real response is not scrubbed
+			assert.Eq(t, "secret", resp.Header.Get("X-Token"))
+			assert.Eq(t, `{"path": "/users", "hit": 1}`, resp.BodyString())
+			assert.FileExists(t, path)
+
+			// replay: query order and JSON keys order are ignored
+			rec, err = vcr.New(path)
+			assert.NoErr(t, err)
+			assert.Eq(t, vcr.ModeReplay, rec.Mode())
+			assert.Len(t, rec.Cassette().Interactions, 1)
+
+			client = greq.New(ts.URL).Doer(rec)
+			resp, err = client.PostDo("/users?b=2&a=1", greq.WithBody(`{"age": 3, "name": "inhere"}`))
+			assert.NoErr(t, err)
+			assert.Eq(t, 200, resp.StatusCode)
+			assert.Eq(t, "REDACTED", resp.Header.Get("X-Token"))
+			assert.Eq(t, `{"path": "/users", "hit": 1}`, resp.BodyString())
+			assert.Eq(t, int32(1), atomic.LoadInt32(&hits))
+
+			// not matched
+			_, err = client.PostDo("/users", greq.WithBody(`{"name": "other"}`))
+			assert.True(t, errors.Is(err, vcr.ErrNoInteraction))
+		})
+	}
+}
+
+func TestRecorder_recordMissing(t *testing.T) {
+	var hits int32
+	ts := newTestServer(t, &hits)
+	path := filepath.Join(t.TempDir(), "sub", "cassette.yaml")
+
+	rec, err := vcr.New(path, vcr.WithMode(vcr.ModeRecord))
+	assert.NoErr(t, err)
+	client := greq.New(ts.URL).Doer(rec)
+
+	// record mode always send the real request
+	_, err = client.GetDo("/a")
+	assert.NoErr(t, err)
+	_, err = client.GetDo("/a")
+	assert.NoErr(t, err)
+	assert.Eq(t, int32(2), atomic.LoadInt32(&hits))
+
+	rec, err = vcr.New(path, vcr.WithMode(vcr.ModeRecordMissing))
+	assert.NoErr(t, err)
+	client = greq.New(ts.URL).Doer(rec)
+
+	// replay in order
+	resp, err := client.GetDo("/a")
+	assert.NoErr(t, err)
+	assert.Eq(t, `{"path": "/a", "hit": 1}`, resp.BodyString())
+	resp, err = client.GetDo("/a")
+	assert.NoErr(t, err)
+	assert.Eq(t, `{"path": "/a", "hit": 2}`, resp.BodyString())
+
+	// missing, record it. then replay it
+	_, err = client.GetDo("/b")
+	assert.NoErr(t, err)
+	resp, err = client.GetDo("/b")
+	assert.NoErr(t, err)
+	assert.Eq(t, `{"path": "/b", "hit": 3}`, resp.BodyString())
+	assert.Eq(t, int32(3), atomic.LoadInt32(&hits))
+	assert.Len(t, rec.Cassette().Interactions, 3)
+}
+
+func TestRecorder_passthrough(t *testing.T) {
+	var hits int32
+	ts := newTestServer(t, &hits)
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+
+	rec, err := vcr.New(path, vcr.WithMode(vcr.ModePassthrough))
+	assert.NoErr(t, err)
+
+	_, err = greq.New(ts.URL).Doer(rec).GetDo("/a")
+	assert.NoErr(t, err)
+	assert.Eq(t, int32(1), atomic.LoadInt32(&hits))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	// replay require the cassette
+	_, err = vcr.New(path)
+	assert.Err(t, err)
+}
+
+func TestParseMode(t *testing.T) {
+	m, err := vcr.ParseMode("record-missing")
+	assert.NoErr(t, err)
+	assert.Eq(t, vcr.ModeRecordMissing, m)
+	assert.Eq(t, "record-missing", m.String())
+
+	_, err = vcr.ParseMode("invalid")
+	assert.Err(t, err)
+}
@@ -2,9 +2,14 @@ module github.com/gookit/greq
 
 go 1.23
 
-require github.com/gookit/goutil v0.7.5
+require (
+	github.com/gookit/goutil v0.7.5
+	gopkg.in/yaml.v3 v3.0.1
+	software.sslmate.com/src/go-pkcs12 v0.5.0
+)
 
 require (
+	golang.org/x/crypto v0.33.0 // indirect
 	golang.org/x/sync v0.11.0 // indirect
 	golang.org/x/sys v0.30.0 // indirect
 	golang.org/x/term v0.29.0 // indirect
@@ -2,11 +2,14 @@
 package greq
 
 import (
+	"context"
 	"encoding/json"
 	"encoding/xml"
+	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -111,15 +114,96 @@ func (mf MiddleFunc) Handle(r *http.Request, next HandleFunc) (*Response, error)
 	return mf(r, next)
 }
 
+// ReqState is shared by all attempts (initial + retries) of one request sent by the Client.
+// Middlewares can use it to keep state across the attempts.
+type ReqState struct {
+	// Attempt number of the current attempt, 0 is the first.
+	Attempt int
+	// Hedge number of the hedged request in the attempt, 0 is the primary. see Client.WithHedging
+	Hedge  int
+	values sync.Map
+	// the state of the primary request, the values are shared with it.
+	primary *ReqState
+}
+
+// Value get a value by key
+func (s *ReqState) Value(key any) any {
+	if s.primary != nil {
+		return s.primary.Value(key)
+	}
+	val, _ := s.values.Load(key)
+	return val
+}
+
+// SetValue set a value by key
+func (s *ReqState) SetValue(key, val any) {
+	if s.primary != nil {
+		s.primary.SetValue(key, val)
+		return
+	}
+	s.values.Store(key, val)
+}
+
+type reqStateKey struct{}
+
+// GetReqState get the ReqState of the request. returns nil if the request is not sent by Client.
+func GetReqState(r *http.Request) *ReqState {
+	st, _ := r.Context().Value(reqStateKey{}).(*ReqState)
+	return st
+}
+
+// withReqState bind a new ReqState to the request context
+func withReqState(r *http.Request) (*http.Request, *ReqState) {
+	st := &ReqState{}
+	return r.WithContext(context.WithValue(r.Context(), reqStateKey{}, st)), st
+}
+
 // wrap middlewares, and will wrap http.Response to Response
 func (h *Client) wrapMiddlewares() {
 	// set core handler
 	h.handler = func(r *http.Request) (*Response, error) {
-		rawResp, err := h.doer.Do(r)
+		if h.netPolicy != nil {
+			if err := h.netPolicy.checkURL(r.URL, h.dialer.viaSocket(r.URL.Hostname())); err != nil {
+				return nil, err
+			}
+			// the dialer only sees the proxy address, check the target host before send
+			if proxyFn := h.transportProxy(); proxyFn != nil {
+				r = r.WithContext(context.WithValue(r.Context(), proxyFuncKey{}, proxyFn))
+				if err := h.netPolicy.checkProxied(r); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		// only the socket set by WithUnixSocket can be dialed
+		if sockPath, ok := unixSocketPath(r.URL.Hostname()); ok && !h.dialer.viaSocket(r.URL.Hostname()) {
+			return nil, fmt.Errorf("greq: unix socket %s is not set by Client.WithUnixSocket", sockPath)
+		}
+
+		tt := newTimingTrace()
+		rawResp, err := h.doer.Do(tt.withTrace(r))
 		if err != nil {
 			return nil, err
 		}
-		return NewResponse(rawResp, h.RespDecoder), nil
+
+		tt.gotResponse(rawResp)
+		// capture the upgraded connection before the body is wrapped
+		if rawResp.StatusCode == http.StatusSwitchingProtocols {
+			if hd, ok := r.Context().Value(upgradeKey{}).(*upgradeHolder); ok {
+				hd.conn, _ = rawResp.Body.(io.ReadWriteCloser)
+			}
+		}
+		if h.AcceptEncoding != "" {
+			decompressBody(rawResp, h.MaxCompressionRatio)
+		}
+		limit := h.bodyLimit(r.Context())
+		if limit > 0 && rawResp.Body != nil {
+			rawResp.Body = &limitedBody{ReadCloser: rawResp.Body, limit: limit}
+		}
+		resp := NewResponse(rawResp, h.RespDecoder)
+		resp.trace = tt
+		resp.MaxLineSize = limit
+		return resp, nil
 	}
 
 	for _, m := range h.middles {
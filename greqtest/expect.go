@@ -0,0 +1,212 @@
+package greqtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// AnyTimes for Expectation.Times, allow any calls, including zero.
+const AnyTimes = -1
+
+// Expectation of a route. create by Mock.On()
+type Expectation struct {
+	// the mock owns it, its lock guards the calls
+	mock   *Mock
+	method string
+	path   string
+	// matchers with description
+	matchers []matcher
+
+	times int
+	calls int
+
+	status int
+	header http.Header
+	body   []byte
+	delay  time.Duration
+	err    error
+}
+
+type matcher struct {
+	desc string
+	fn   func(r *Request) bool
+}
+
+func newExpectation(method, pathPattern string) *Expectation {
+	return &Expectation{
+		method: strings.ToUpper(method),
+		path:   pathPattern,
+		times:  1,
+		status: http.StatusOK,
+		header: make(http.Header),
+	}
+}
+
+// WithHeader expect the request header value
+func (e *Expectation) WithHeader(key, value string) *Expectation {
+	return e.Match("header "+key+": "+value, func(r *Request) bool {
+		return r.Header.Get(key) == value
+	})
+}
+
+// WithQuery expect the URL query value
+func (e *Expectation) WithQuery(key, value string) *Expectation {
+	return e.Match("query "+key+"="+value, func(r *Request) bool {
+		return r.URL.Query().Get(key) == value
+	})
+}
+
+// WithBody expect the request body equals the string
+func (e *Expectation) WithBody(body string) *Expectation {
+	return e.Match("body "+body, func(r *Request) bool {
+		return string(r.Body) == body
+	})
+}
+
+// WithJSON expect the request body is JSON-equivalent to the value. eg: keys order is ignored.
+//
+// The value can be a JSON string, []byte or any value can be JSON encoded.
+func (e *Expectation) WithJSON(v any) *Expectation {
+	want, err := toJSONValue(v)
+	desc := fmt.Sprintf("json %v", v)
+	if err != nil {
+		return e.Match(desc, func(*Request) bool { return false })
+	}
+
+	return e.Match(desc, func(r *Request) bool {
+		var got any
+		if json.Unmarshal(r.Body, &got) != nil {
+			return false
+		}
+		return reflect.DeepEqual(want, got)
+	})
+}
+
+// Match add a custom matcher for the request
+func (e *Expectation) Match(desc string, fn func(r *Request) bool) *Expectation {
+	e.matchers = append(e.matchers, matcher{desc: desc, fn: fn})
+	return e
+}
+
+// Reply set the response status and body.
+//
+// The body can be: nil, string, []byte, or any value will be encoded as JSON.
+func (e *Expectation) Reply(status int, body any) *Expectation {
+	e.status = status
+	switch b := body.(type) {
+	case nil:
+		e.body = nil
+	case string:
+		e.body = []byte(b)
+	case []byte:
+		e.body = b
+	default:
+		bs, err := json.Marshal(b)
+		if err != nil {
+			panic(fmt.Sprintf("greqtest: encode reply body error: %v", err))
+		}
+		e.body = bs
+		if e.header.Get("Content-Type") == "" {
+			e.header.Set("Content-Type", "application/json")
+		}
+	}
+	return e
+}
+
+// ReplyHeader set the response header
+func (e *Expectation) ReplyHeader(key, value string) *Expectation {
+	e.header.Set(key, value)
+	return e
+}
+
+// ReplyError simulate a connection error, the request will fail with the err.
+//
+// On the mock server, the connection will be closed without response.
+func (e *Expectation) ReplyError(err error) *Expectation {
+	if err == nil {
+		err = ErrConnection
+	}
+	e.err = err
+	return e
+}
+
+// Delay the response, simulate latency. it respects the request context.
+func (e *Expectation) Delay(d time.Duration) *Expectation {
+	e.delay = d
+	return e
+}
+
+// Times set the expected call times. default is 1, use AnyTimes for no limit.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+// Once expect call once. it is the default.
+func (e *Expectation) Once() *Expectation { return e.Times(1) }
+
+// Calls get the matched call times. It is safe to call while the requests are handling.
+func (e *Expectation) Calls() int {
+	e.mock.mu.Lock()
+	defer e.mock.mu.Unlock()
+	return e.calls
+}
+
+// String get the description of the expectation
+func (e *Expectation) String() string {
+	var sb strings.Builder
+	sb.WriteString(e.method + " " + e.path)
+	for _, m := range e.matchers {
+		sb.WriteString(", " + m.desc)
+	}
+	return sb.String()
+}
+
+func (e *Expectation) exhausted() bool {
+	return e.times != AnyTimes && e.calls >= e.times
+}
+
+func (e *Expectation) unmet() bool {
+	return e.times != AnyTimes && e.calls < e.times
+}
+
+func (e *Expectation) match(r *Request) bool {
+	if e.method != "*" && e.method != r.Method {
+		return false
+	}
+	if ok, _ := path.Match(e.path, r.URL.Path); !ok {
+		return false
+	}
+
+	for _, m := range e.matchers {
+		if !m.fn(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func toJSONValue(v any) (any, error) {
+	var bs []byte
+	switch val := v.(type) {
+	case string:
+		bs = []byte(val)
+	case []byte:
+		bs = val
+	default:
+		var err error
+		if bs, err = json.Marshal(val); err != nil {
+			return nil, err
+		}
+	}
+
+	var out any
+	err := json.NewDecoder(bytes.NewReader(bs)).Decode(&out)
+	return out, err
+}
@@ -0,0 +1,280 @@
+// Package greqtest provides an in-process mock for testing code that uses greq.Client.
+//
+// The Mock can be used as the httpreq.Doer of a client, or as an httptest server.
+//
+// Usage:
+//
+//	mock := greqtest.NewMock(t)
+//	mock.On("POST", "/users").WithJSON(`{"name": "inhere"}`).Reply(201, map[string]any{"id": 1}).Times(2)
+//
+//	client := greq.New("https://api.example.com").Doer(mock)
+//	// or use the server: greq.New(mock.URL())
+//	resp, err := client.PostDo("/users", greq.WithBody(`{"name": "inhere"}`))
+//
+//	// unmet expectations are reported on test cleanup, or call mock.AssertExpectations()
+package greqtest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gookit/greq"
+)
+
+// ErrConnection is the default error for Expectation.ReplyError
+var ErrConnection = errors.New("greqtest: connection refused")
+
+// ErrUnexpectedRequest is returned when no expectation matched the request
+var ErrUnexpectedRequest = errors.New("greqtest: unexpected request")
+
+// TestingT is the interface of *testing.T used by the Mock
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Request is a recorded request received by the Mock
+type Request struct {
+	Method string
+	URL    *url.URL
+	Header http.Header
+	Body   []byte
+	// Time of the request received
+	Time time.Time
+}
+
+// BodyString get the body as string
+func (r *Request) BodyString() string { return string(r.Body) }
+
+// Mock is an in-process mock server with route expectations.
+// It implements the httpreq.Doer and http.Handler.
+type Mock struct {
+	t  TestingT
+	mu sync.Mutex
+
+	expects  []*Expectation
+	requests []*Request
+	server   *httptest.Server
+}
+
+// NewMock create a new Mock. If t has Cleanup(), the expectations will be
+// verified and the server will be closed on test cleanup.
+func NewMock(t TestingT) *Mock {
+	m := &Mock{t: t}
+	if ct, ok := t.(interface{ Cleanup(func()) }); ok {
+		ct.Cleanup(func() {
+			m.AssertExpectations()
+			m.Close()
+		})
+	}
+	return m
+}
+
+// On add an expectation for the method and path. path can be a pattern of path.Match, eg: "/users/*"
+//
+// The method "*" matches any method.
+func (m *Mock) On(method, pathPattern string) *Expectation {
+	e := newExpectation(method, pathPattern)
+	e.mock = m
+	m.mu.Lock()
+	m.expects = append(m.expects, e)
+	m.mu.Unlock()
+	return e
+}
+
+// Client create a greq.Client that use the mock as doer.
+func (m *Mock) Client() *greq.Client {
+	return greq.New("http://greqtest.local").Doer(m)
+}
+
+// URL get the mock server URL. The server is started on the first call.
+func (m *Mock) URL() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.server == nil {
+		m.server = httptest.NewServer(m)
+	}
+	return m.server.URL
+}
+
+// Close the mock server if started
+func (m *Mock) Close() {
+	m.mu.Lock()
+	srv := m.server
+	m.server = nil
+	m.mu.Unlock()
+
+	if srv != nil {
+		srv.Close()
+	}
+}
+
+// Requests get all recorded requests
+func (m *Mock) Requests() []*Request {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*Request(nil), m.requests...)
+}
+
+// LastRequest get the last recorded request. returns nil if no request.
+func (m *Mock) LastRequest() *Request {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.requests) == 0 {
+		return nil
+	}
+	return m.requests[len(m.requests)-1]
+}
+
+// Reset clear the expectations and recorded requests
+func (m *Mock) Reset() {
+	m.mu.Lock()
+	m.expects, m.requests = nil, nil
+	m.mu.Unlock()
+}
+
+// AssertExpectations report the unmet expectations by t.Errorf. returns false if has unmet.
+func (m *Mock) AssertExpectations() bool {
+	m.t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ok := true
+	for _, e := range m.expects {
+		if e.unmet() {
+			ok = false
+			m.t.Errorf("greqtest: unmet expectation: %s, want %d calls, got %d", e, e.times, e.calls)
+		}
+	}
+	return ok
+}
+
+// Do implements the httpreq.Doer
+func (m *Mock) Do(req *http.Request) (*http.Response, error) {
+	rr, err := newRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	e := m.handle(rr)
+	if e == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrUnexpectedRequest, req.Method, req.URL.String())
+	}
+	if err := wait(req, e.delay); err != nil {
+		return nil, err
+	}
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	resp := &http.Response{
+		Status:        strconv.Itoa(e.status) + " " + http.StatusText(e.status),
+		StatusCode:    e.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+	resp.Header.Set("Content-Length", strconv.Itoa(len(e.body)))
+	return resp, nil
+}
+
+// ServeHTTP implements the http.Handler
+func (m *Mock) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rr, err := newRequest(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	e := m.handle(rr)
+	if e == nil {
+		http.Error(w, ErrUnexpectedRequest.Error(), http.StatusNotImplemented)
+		return
+	}
+	if err := wait(req, e.delay); err != nil {
+		return
+	}
+
+	// simulate connection error: close the connection without response
+	if e.err != nil {
+		if hj, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hj.Hijack(); err == nil {
+				_ = conn.Close()
+				return
+			}
+		}
+		panic(http.ErrAbortHandler)
+	}
+
+	for k, vs := range e.header {
+		w.Header()[k] = vs
+	}
+	w.WriteHeader(e.status)
+	_, _ = w.Write(e.body)
+}
+
+// handle record the request and find the matched expectation
+func (m *Mock) handle(r *Request) *Expectation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests = append(m.requests, r)
+	for _, e := range m.expects {
+		if !e.exhausted() && e.match(r) {
+			e.calls++
+			return e
+		}
+	}
+
+	m.t.Helper()
+	m.t.Errorf("greqtest: unexpected request: %s %s", r.Method, r.URL.String())
+	return nil
+}
+
+func newRequest(req *http.Request) (*Request, error) {
+	r := &Request{
+		Method: strings.ToUpper(req.Method),
+		URL:    req.URL,
+		Header: req.Header.Clone(),
+		Time:   time.Now(),
+	}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		bs, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = bs
+	}
+	return r, nil
+}
+
+// wait for the delay, returns the context error if canceled
+func wait(req *http.Request, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
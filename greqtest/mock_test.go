@@ -0,0 +1,156 @@
+package greqtest_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq"
+	"github.com/gookit/greq/greqtest"
+)
+
+// fakeT collect the errors reported by the mock
+type fakeT struct {
+	errs []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errs = append(f.errs, fmt.Sprintf(format, args...))
+}
+
+func TestMock_doer(t *testing.T) {
+	mock := greqtest.NewMock(t)
+	mock.On("POST", "/users").
+		WithJSON(`{"name": "inhere", "age": 3}`).
+		WithHeader("X-Token", "abc").
+		Reply(201, map[string]any{"id": 1}).
+		Times(2)
+	mock.On("GET", "/users/*").Reply(200, "user info").ReplyHeader("X-Id", "23")
+
+	client := mock.Client()
+	for i := 0; i < 2; i++ {
+		resp, err := client.PostDo("/users",
+			greq.WithBody(`{"age": 3, "name": "inhere"}`),
+			greq.WithHeader("X-Token", "abc"),
+		)
+		assert.NoErr(t, err)
+		assert.Eq(t, 201, resp.StatusCode)
+		assert.Eq(t, `{"id":1}`, resp.BodyString())
+		assert.True(t, resp.IsJSONType())
+	}
+
+	resp, err := client.GetDo("/users/23")
+	assert.NoErr(t, err)
+	assert.Eq(t, "user info", resp.BodyString())
+	assert.Eq(t, "23", resp.Header.Get("X-Id"))
+
+	reqs := mock.Requests()
+	assert.Len(t, reqs, 3)
+	assert.Eq(t, "POST", reqs[0].Method)
+	assert.Eq(t, `{"age": 3, "name": "inhere"}`, reqs[0].BodyString())
+	assert.Eq(t, "/users/23", mock.LastRequest().URL.Path)
+	assert.True(t, mock.AssertExpectations())
+}
+
+func TestExpectation_Calls_concurrent(t *testing.T) {
+	mock := greqtest.NewMock(t)
+	e := mock.On("GET", "/ping").Reply(200, "pong").Times(greqtest.AnyTimes)
+	client := mock.Client()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.GetDo("/ping")
+			assert.NoErr(t, err)
+			_ = e.Calls()
+		}()
+	}
+	wg.Wait()
+	assert.Eq(t, 10, e.Calls())
+}
+
+func TestMock_server(t *testing.T) {
+	mock := greqtest.NewMock(t)
+	mock.On("GET", "/ping").WithQuery("a", "1").Reply(200, "pong")
+	mock.On("*", "/any").Reply(204, nil).Times(greqtest.AnyTimes)
+
+	client := greq.New(mock.URL())
+	resp, err := client.GetDo("/ping?a=1")
+	assert.NoErr(t, err)
+	assert.Eq(t, "pong", resp.BodyString())
+
+	resp, err = client.Send("DELETE", "/any")
+	assert.NoErr(t, err)
+	assert.Eq(t, 204, resp.StatusCode)
+}
+
+func TestMock_unmetAndUnexpected(t *testing.T) {
+	ft := &fakeT{}
+	mock := greqtest.NewMock(ft)
+	e := mock.On("POST", "/users").Times(2)
+
+	client := mock.Client()
+	_, err := client.PostDo("/users")
+	assert.NoErr(t, err)
+	assert.Eq(t, 1, e.Calls())
+
+	// unexpected request
+	_, err = client.GetDo("/not-found")
+	assert.Err(t, err)
+	assert.True(t, errors.Is(err, greqtest.ErrUnexpectedRequest))
+	assert.Len(t, ft.errs, 1)
+	assert.StrContains(t, ft.errs[0], "unexpected request: GET")
+
+	assert.False(t, mock.AssertExpectations())
+	assert.Len(t, ft.errs, 2)
+	assert.StrContains(t, ft.errs[1], "unmet expectation: POST /users, want 2 calls, got 1")
+
+	// exhausted, falls to unexpected
+	_, _ = client.PostDo("/users")
+	_, err = client.PostDo("/users")
+	assert.Err(t, err)
+
+	mock.Reset()
+	assert.Empty(t, mock.Requests())
+	assert.True(t, mock.AssertExpectations())
+}
+
+func TestMock_latencyAndError(t *testing.T) {
+	mock := greqtest.NewMock(t)
+	mock.On("GET", "/slow").Delay(50*time.Millisecond).Reply(200, "ok").Times(2)
+	mock.On("GET", "/down").ReplyError(nil)
+	mock.On("GET", "/reset").ReplyError(errors.New("connection reset"))
+
+	client := mock.Client()
+	start := time.Now()
+	resp, err := client.GetDo("/slow")
+	assert.NoErr(t, err)
+	assert.Eq(t, "ok", resp.BodyString())
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+
+	// timeout by request context
+	_, err = client.GetDo("/slow", greq.WithTimeout(10))
+	assert.Err(t, err)
+
+	_, err = client.GetDo("/down")
+	assert.True(t, errors.Is(err, greqtest.ErrConnection))
+	_, err = client.GetDo("/reset")
+	assert.ErrMsgContains(t, err, "connection reset")
+}
+
+func TestMock_serverConnError(t *testing.T) {
+	mock := greqtest.NewMock(t)
+	mock.On("GET", "/down").ReplyError(nil)
+
+	_, err := greq.New(mock.URL()).GetDo("/down")
+	assert.Err(t, err)
+	assert.Eq(t, http.MethodGet, mock.LastRequest().Method)
+}
@@ -0,0 +1,182 @@
+package greq
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WithHedging send hedged requests for reduce the tail latency.
+//
+// If the request has no response within the delay, a duplicate request is sent, up to maxExtra
+// duplicates for each attempt. The first succeeded response wins, the others are canceled and
+// their bodies are closed. A response is succeeded if no error and the status code < 500.
+//
+// Only the idempotent requests are hedged: GET, HEAD, OPTIONS, TRACE, PUT, DELETE methods,
+// or has the Idempotency-Key header. The request body must be re-readable by Request.GetBody.
+//
+// Each hedged request runs the middlewares, use GetReqState(r).Hedge to check it. 0 is the primary.
+// With a balancer, each hedged request is sent to a different endpoint if possible.
+func (h *Client) WithHedging(delay time.Duration, maxExtra int) *Client {
+	h.HedgeDelay = delay
+	h.MaxHedges = maxExtra
+	return h
+}
+
+// isHedgeable check the request can be sent multiple times
+func isHedgeable(r *http.Request) bool {
+	// the upgrade request can not be sent twice
+	if r.Header.Get("Upgrade") != "" {
+		return false
+	}
+	if r.Body != nil && r.Body != http.NoBody && r.GetBody == nil {
+		return false
+	}
+
+	switch r.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return r.Header.Get("Idempotency-Key") != "" || r.Header.Get("X-Idempotency-Key") != ""
+}
+
+type hedgeResult struct {
+	// hedge number, 0 is the primary
+	n    int
+	resp *Response
+	err  error
+}
+
+func (hr *hedgeResult) ok() bool {
+	return hr.err == nil && hr.resp.StatusCode < 500
+}
+
+// sendHedged send the request and the hedged requests by the core handler, returns the first succeeded result.
+// If all failed, returns the last failed result.
+//
+// The primary is sent first. If balanced, ep is the endpoint of the primary, the hedged requests bind
+// other endpoints by the origin req, and each result is reported to the balancer.
+func (h *Client) sendHedged(req, primary *http.Request, ep *Endpoint, st *ReqState, cfg retryCfg) (*Response, error) {
+	results := make(chan *hedgeResult, cfg.maxHedges+1)
+	cancels := make([]context.CancelFunc, 0, cfg.maxHedges+1)
+
+	send := func(n int) {
+		sendReq, sendEp := primary, ep
+		if n > 0 && ep != nil {
+			var err error
+			if sendEp, sendReq, err = h.bindEndpoint(req, st); err != nil {
+				cancels = append(cancels, func() {})
+				results <- &hedgeResult{n: n, err: err}
+				return
+			}
+			sendEp.inFlight.Add(1)
+		}
+
+		ctx, cancel := context.WithCancel(sendReq.Context())
+		cancels = append(cancels, cancel)
+		if n > 0 {
+			ctx = context.WithValue(ctx, reqStateKey{}, &ReqState{Attempt: st.Attempt, Hedge: n, primary: st})
+		}
+
+		// the middlewares may modify the headers concurrently
+		r := sendReq.WithContext(ctx)
+		r.Header = sendReq.Header.Clone()
+		if n > 0 {
+			if sendReq.Body != nil && sendReq.Body != http.NoBody {
+				body, err := sendReq.GetBody()
+				if err != nil {
+					if sendEp != nil {
+						sendEp.inFlight.Add(-1)
+					}
+					results <- &hedgeResult{n: n, err: err}
+					return
+				}
+				r.Body = body
+			}
+		}
+
+		go func() {
+			resp, err := h.handler(r)
+			if sendEp != nil {
+				h.endpointDone(sendEp, resp, err)
+			}
+			results <- &hedgeResult{n: n, resp: resp, err: err}
+		}()
+	}
+
+	// close the response body and cancel the request
+	discard := func(hr *hedgeResult) {
+		if hr.resp != nil && hr.resp.Body != nil {
+			_ = hr.resp.Body.Close()
+		}
+		cancels[hr.n]()
+	}
+
+	send(0)
+	pending := 1
+	timer := time.NewTimer(cfg.hedgeDelay)
+	defer timer.Stop()
+
+	var last *hedgeResult
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			if len(cancels) <= cfg.maxHedges {
+				send(len(cancels))
+				pending++
+				timer.Reset(cfg.hedgeDelay)
+			}
+		case hr := <-results:
+			pending--
+			if !hr.ok() {
+				if last != nil {
+					discard(last)
+				}
+				last = hr
+				continue
+			}
+
+			if last != nil {
+				discard(last)
+			}
+			// cancel the others, and close their bodies in the background
+			for i, cancel := range cancels {
+				if i != hr.n {
+					cancel()
+				}
+			}
+			if pending > 0 {
+				go func(n int) {
+					for ; n > 0; n-- {
+						discard(<-results)
+					}
+				}(pending)
+			}
+			return hr.keepContext(cancels[hr.n])
+		}
+	}
+	return last.keepContext(cancels[last.n])
+}
+
+// keepContext cancel the request context after the response body closed.
+func (hr *hedgeResult) keepContext(cancel context.CancelFunc) (*Response, error) {
+	if hr.resp == nil || hr.resp.Body == nil {
+		cancel()
+	} else {
+		hr.resp.Body = &cancelBody{ReadCloser: hr.resp.Body, cancel: cancel}
+	}
+	return hr.resp, hr.err
+}
+
+// cancelBody cancel the request context on close
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,150 @@
+package greq_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq"
+)
+
+// newSlowServer the first request is slow until canceled, the others respond the request body.
+func newSlowServer(t *testing.T) (*httptest.Server, *atomic.Int32, chan struct{}) {
+	var hits atomic.Int32
+	canceled := make(chan struct{}, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if hits.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+				select {
+				case canceled <- struct{}{}:
+				default:
+				}
+			case <-time.After(2 * time.Second):
+			}
+			return
+		}
+		_, _ = w.Write([]byte("ok:" + string(body)))
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &hits, canceled
+}
+
+func TestClient_WithHedging(t *testing.T) {
+	ts, hits, canceled := newSlowServer(t)
+
+	var mu sync.Mutex
+	var hedges []int
+	client := greq.New(ts.URL).WithHedging(30*time.Millisecond, 2).
+		Use(greq.MiddleFunc(func(r *http.Request, next greq.HandleFunc) (*greq.Response, error) {
+			mu.Lock()
+			hedges = append(hedges, greq.GetReqState(r).Hedge)
+			mu.Unlock()
+			return next(r)
+		}))
+
+	start := time.Now()
+	resp, err := client.GetDo("/hedge")
+	assert.NoErr(t, err)
+	assert.Eq(t, "ok:", resp.BodyString())
+	assert.True(t, time.Since(start) < time.Second)
+	assert.Eq(t, 1, greq.GetReqState(resp.Request).Hedge)
+	assert.Eq(t, int32(2), hits.Load())
+	mu.Lock()
+	assert.Eq(t, []int{0, 1}, hedges)
+	mu.Unlock()
+
+	// the slow one is canceled
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("the slow request is not canceled")
+	}
+}
+
+func TestClient_WithHedging_eligible(t *testing.T) {
+	ts, hits, _ := newSlowServer(t)
+	client := greq.New(ts.URL).WithHedging(30*time.Millisecond, 1)
+
+	// POST is not hedged
+	resp, err := client.PostDo("/post", greq.WithBody("data"), greq.WithTimeout(200))
+	assert.Err(t, err)
+	assert.Nil(t, resp)
+	assert.Eq(t, int32(1), hits.Load())
+
+	// disabled by the request option
+	hits.Store(0)
+	_, err = client.GetDo("/get", greq.WithHedging(-1, 0), greq.WithTimeout(200))
+	assert.Err(t, err)
+	assert.Eq(t, int32(1), hits.Load())
+
+	// has Idempotency-Key, the body is replayed
+	hits.Store(0)
+	resp, err = client.PostDo("/post", greq.WithBody("data"), greq.WithHeader("Idempotency-Key", "abc"))
+	assert.NoErr(t, err)
+	assert.Eq(t, "ok:data", resp.BodyString())
+	assert.Eq(t, int32(2), hits.Load())
+}
+
+func TestClient_WithHedging_failed(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			time.Sleep(50 * time.Millisecond)
+			w.WriteHeader(502)
+			return
+		}
+		w.WriteHeader(503)
+	}))
+	defer ts.Close()
+
+	// returns the last failed response
+	resp, err := greq.New(ts.URL).WithHedging(10*time.Millisecond, 1).GetDo("/")
+	assert.NoErr(t, err)
+	assert.Eq(t, 502, resp.StatusCode)
+	assert.Eq(t, int32(2), hits.Load())
+
+	// retry after all hedges failed
+	hits.Store(0)
+	resp, err = greq.New(ts.URL).WithHedging(10*time.Millisecond, 1).WithMaxRetries(1).GetDo("/")
+	assert.NoErr(t, err)
+	assert.Eq(t, 503, resp.StatusCode)
+	assert.Eq(t, int32(3), hits.Load())
+}
+
+func TestClient_WithHedging_balancer(t *testing.T) {
+	s1, hits, canceled := newSlowServer(t)
+	s2, _ := newEndpointServer(t, "s2")
+
+	b := greq.NewBalancer(greq.RoundRobin, s1.URL, s2.URL)
+	b.MaxFails = 1
+	client := greq.New().WithBalancer(b).WithHedging(30*time.Millisecond, 1)
+
+	// the hedged request is sent to the other endpoint
+	resp, err := client.GetDo("/hedge")
+	assert.NoErr(t, err)
+	assert.Eq(t, "s2:/hedge", resp.BodyString())
+	assert.Eq(t, s2.URL, resp.Endpoint)
+	assert.Eq(t, int32(1), hits.Load())
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("the slow request is not canceled")
+	}
+
+	// the in-flight is released, the canceled hedge is not counted as a failure
+	eps := b.Endpoints()
+	for i := 0; i < 50 && eps[0].InFlight() > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Eq(t, int64(0), eps[0].InFlight())
+	assert.Eq(t, int64(0), eps[1].InFlight())
+	assert.True(t, b.Healthy(eps[0]))
+}
@@ -1,5 +1,5 @@
 // Package bodyprovider implements the concrete BodyProvider variants used by
-// the greq client (reader, JSON, form, multipart). The greq.BodyProvider
+// the greq client (reader, file, JSON, form, multipart). The greq.BodyProvider
 // interface is structurally satisfied by every type in this package.
 package bodyprovider
 
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"mime/multipart"
+	"net/textproto"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -31,6 +32,23 @@ func (p Reader) ContentType() string { return "" }
 // Body returns the underlying reader.
 func (p Reader) Body() (io.Reader, error) { return p.body, nil }
 
+// Bytes is a body of the bytes, it can be sent multiple times.
+type Bytes struct {
+	body        []byte
+	contentType string
+}
+
+// NewBytes returns a Bytes provider. contentType may be empty.
+func NewBytes(body []byte, contentType string) Bytes {
+	return Bytes{body: body, contentType: contentType}
+}
+
+// ContentType returns the given Content-Type, may be empty.
+func (p Bytes) ContentType() string { return p.contentType }
+
+// Body returns a new reader of the bytes on each call.
+func (p Bytes) Body() (io.Reader, error) { return bytes.NewReader(p.body), nil }
+
 // JSON encodes a JSON tagged struct value as request body.
 type JSON struct {
 	payload any
@@ -146,3 +164,108 @@ func (p *Multipart) build() error {
 	p.body = buf
 	return nil
 }
+
+// File streams the contents of a file as body. The file is opened lazily
+// on each Body() call, and closed by the http transport after sending.
+type File struct {
+	path        string
+	contentType string
+}
+
+// NewFile returns a File provider. contentType may be empty.
+func NewFile(path, contentType string) File { return File{path: path, contentType: contentType} }
+
+// ContentType returns the given Content-Type, may be empty.
+func (p File) ContentType() string { return p.contentType }
+
+// Body opens the file and returns it as reader.
+func (p File) Body() (io.Reader, error) {
+	file, err := os.Open(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("open body file %s failed: %w", p.path, err)
+	}
+	return file, nil
+}
+
+// Part is one part of a MultipartStream body.
+type Part struct {
+	// Header of the part, eg: Content-Disposition, Content-Type
+	Header textproto.MIMEHeader
+	// Content inline content of the part. ignored when FilePath is not empty.
+	Content string
+	// FilePath stream the part content from the file.
+	FilePath string
+}
+
+// MultipartStream writes a multipart body with a fixed boundary and
+// explicit part headers. Contents are streamed through an io.Pipe, so
+// large file parts are never loaded into memory.
+//
+// Unlike Multipart, ContentType() is available before Body() is called.
+type MultipartStream struct {
+	boundary string
+	parts    []Part
+}
+
+// NewMultipartStream returns a MultipartStream provider.
+// If boundary is empty, a random one will be generated.
+func NewMultipartStream(boundary string, parts []Part) *MultipartStream {
+	if boundary == "" {
+		boundary = multipart.NewWriter(io.Discard).Boundary()
+	}
+	return &MultipartStream{boundary: boundary, parts: parts}
+}
+
+// Boundary returns the multipart boundary.
+func (p *MultipartStream) Boundary() string { return p.boundary }
+
+// ContentType returns the multipart Content-Type including the boundary.
+func (p *MultipartStream) ContentType() string {
+	return "multipart/form-data; boundary=" + p.boundary
+}
+
+// Body returns a reader that is fed by a background writer goroutine.
+// Any error when writing parts(eg: file not exists) is returned from Read.
+func (p *MultipartStream) Body() (io.Reader, error) {
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	if err := writer.SetBoundary(p.boundary); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		pw.CloseWithError(p.writeParts(writer))
+	}()
+	return pr, nil
+}
+
+func (p *MultipartStream) writeParts(writer *multipart.Writer) error {
+	for _, part := range p.parts {
+		pw, err := writer.CreatePart(part.Header)
+		if err != nil {
+			return fmt.Errorf("create multipart part failed: %w", err)
+		}
+
+		if part.FilePath == "" {
+			if _, err = io.WriteString(pw, part.Content); err != nil {
+				return err
+			}
+			continue
+		}
+
+		file, err := os.Open(part.FilePath)
+		if err != nil {
+			return fmt.Errorf("open file %s failed: %w", part.FilePath, err)
+		}
+		_, err = io.Copy(pw, file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("copy file %s failed: %w", part.FilePath, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("close multipart writer failed: %w", err)
+	}
+	return nil
+}
@@ -3,7 +3,11 @@ package bodyprovider
 import (
 	"encoding/json"
 	"io"
+	"mime/multipart"
+	"net/textproto"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -20,6 +24,19 @@ func TestReader(t *testing.T) {
 	assert.Eq(t, "hello", string(bs))
 }
 
+func TestBytes(t *testing.T) {
+	p := NewBytes([]byte("hello"), "text/plain")
+	assert.Eq(t, "text/plain", p.ContentType())
+
+	// can be read multiple times
+	for i := 0; i < 2; i++ {
+		r, err := p.Body()
+		assert.NoErr(t, err)
+		bs, _ := io.ReadAll(r)
+		assert.Eq(t, "hello", string(bs))
+	}
+}
+
 func TestJSON(t *testing.T) {
 	p := NewJSON(map[string]string{"name": "inhere", "age": "30"})
 	assert.Eq(t, "application/json; charset=utf-8", p.ContentType())
@@ -86,3 +103,71 @@ func TestMultipart_FieldsOnly(t *testing.T) {
 	// ContentType is populated after build, including boundary.
 	assert.Contains(t, p.ContentType(), "multipart/form-data; boundary=")
 }
+
+func TestFile(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "body.txt")
+	assert.NoErr(t, os.WriteFile(fpath, []byte("file body"), 0644))
+
+	p := NewFile(fpath, "text/plain")
+	assert.Eq(t, "text/plain", p.ContentType())
+
+	r, err := p.Body()
+	assert.NoErr(t, err)
+	bs, _ := io.ReadAll(r)
+	assert.Eq(t, "file body", string(bs))
+	assert.NoErr(t, r.(io.Closer).Close())
+
+	_, err = NewFile(fpath+".not-exist", "").Body()
+	assert.Err(t, err)
+}
+
+func TestMultipartStream(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "data.json")
+	assert.NoErr(t, os.WriteFile(fpath, []byte(`{"age": 30}`), 0644))
+
+	p := NewMultipartStream("TestBoundary", []Part{
+		{
+			Header:  textproto.MIMEHeader{"Content-Disposition": {`form-data; name="name"`}},
+			Content: "inhere",
+		},
+		{
+			Header: textproto.MIMEHeader{
+				"Content-Disposition": {`form-data; name="data"; filename="data.json"`},
+				"Content-Type":        {"application/json"},
+			},
+			FilePath: fpath,
+		},
+	})
+	assert.Eq(t, "TestBoundary", p.Boundary())
+	assert.Eq(t, "multipart/form-data; boundary=TestBoundary", p.ContentType())
+
+	r, err := p.Body()
+	assert.NoErr(t, err)
+
+	mr := multipart.NewReader(r, "TestBoundary")
+	part, err := mr.NextPart()
+	assert.NoErr(t, err)
+	assert.Eq(t, "name", part.FormName())
+	bs, _ := io.ReadAll(part)
+	assert.Eq(t, "inhere", string(bs))
+
+	part, err = mr.NextPart()
+	assert.NoErr(t, err)
+	assert.Eq(t, "data.json", part.FileName())
+	assert.Eq(t, "application/json", part.Header.Get("Content-Type"))
+	bs, _ = io.ReadAll(part)
+	assert.Eq(t, `{"age": 30}`, string(bs))
+
+	_, err = mr.NextPart()
+	assert.Eq(t, io.EOF, err)
+
+	t.Run("missing file", func(t *testing.T) {
+		p := NewMultipartStream("", []Part{{FilePath: fpath + ".not-exist"}})
+		assert.NotEmpty(t, p.Boundary())
+
+		r, err := p.Body()
+		assert.NoErr(t, err)
+		_, err = io.ReadAll(r)
+		assert.Err(t, err)
+	})
+}
@@ -0,0 +1,107 @@
+package greq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrBodyTooLarge is returned on read the response body, when the body size exceeds the limit,
+// or the compression ratio exceeds Client.MaxCompressionRatio.
+//
+// Check it by errors.Is(err, greq.ErrBodyTooLarge)
+var ErrBodyTooLarge = errors.New("greq: response body too large")
+
+// minRatioCheckSize the compression ratio is checked only after decoded bytes exceed it,
+// small payloads can have a high ratio naturally.
+const minRatioCheckSize = 64 * 1024
+
+// WithMaxResponseBytes set the max response body size for all requests. 0 is no limit.
+//
+// The limit is applied to the decoded size on the compression is enabled.
+func (h *Client) WithMaxResponseBytes(n int64) *Client {
+	h.MaxResponseBytes = n
+	return h
+}
+
+// WithMaxCompressionRatio set the max ratio of decoded size to compressed size. 0 is no limit.
+//
+// It's for protect from decompression bombs, only works on WithCompression() enabled.
+func (h *Client) WithMaxCompressionRatio(ratio float64) *Client {
+	h.MaxCompressionRatio = ratio
+	return h
+}
+
+type maxBodyKey struct{}
+
+// withMaxBodySize bind the per-request body size limit to context
+func withMaxBodySize(ctx context.Context, n int64) context.Context {
+	return context.WithValue(ctx, maxBodyKey{}, n)
+}
+
+// bodyLimit get the body size limit of the request. the per-request limit is preferred.
+func (h *Client) bodyLimit(ctx context.Context) int64 {
+	if n, ok := ctx.Value(maxBodyKey{}).(int64); ok && n > 0 {
+		return n
+	}
+	return h.MaxResponseBytes
+}
+
+// limitedBody return ErrBodyTooLarge after read more than limit bytes
+type limitedBody struct {
+	io.ReadCloser
+	limit int64
+	n     int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.n > b.limit {
+		return 0, b.tooLarge()
+	}
+
+	// read one more byte for check exceeds
+	if rest := b.limit - b.n + 1; int64(len(p)) > rest {
+		p = p[:rest]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if b.n > b.limit {
+		return n - int(b.n-b.limit), b.tooLarge()
+	}
+	return n, err
+}
+
+func (b *limitedBody) tooLarge() error {
+	return fmt.Errorf("%w: exceeds the limit of %d bytes", ErrBodyTooLarge, b.limit)
+}
+
+// countReader count the read bytes
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// ratioBody check the ratio of decoded size to the compressed size
+type ratioBody struct {
+	io.ReadCloser
+	// compressed counter
+	in  *countReader
+	out int64
+	max float64
+}
+
+func (b *ratioBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.out += int64(n)
+	if b.out > minRatioCheckSize && b.in.n > 0 && float64(b.out)/float64(b.in.n) > b.max {
+		return n, fmt.Errorf("%w: compression ratio exceeds %.0f", ErrBodyTooLarge, b.max)
+	}
+	return n, err
+}
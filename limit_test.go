@@ -0,0 +1,98 @@
+package greq_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq"
+)
+
+func TestClient_WithMaxResponseBytes(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+		body := strings.Repeat("a", size)
+		if r.URL.Query().Has("json") {
+			body = `"` + body + `"`
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer ts.Close()
+
+	client := greq.New(ts.URL).WithMaxResponseBytes(10)
+	assert.Eq(t, int64(10), client.Sub().MaxResponseBytes)
+
+	// exactly the limit
+	resp, err := client.GetDo("/?size=10")
+	assert.NoErr(t, err)
+	s, err := resp.BodyStringE()
+	assert.NoErr(t, err)
+	assert.Eq(t, 10, len(s))
+
+	resp, err = client.GetDo("/?size=11")
+	assert.NoErr(t, err)
+	_, err = resp.BodyStringE()
+	assert.True(t, errors.Is(err, greq.ErrBodyTooLarge))
+	assert.ErrMsgContains(t, err, "exceeds the limit of 10 bytes")
+
+	// decode also be limited
+	resp, err = client.GetDo("/?size=100&json")
+	assert.NoErr(t, err)
+	var v any
+	assert.True(t, errors.Is(resp.Decode(&v), greq.ErrBodyTooLarge))
+
+	// per-request limit
+	resp, err = client.GetDo("/?size=100", greq.WithMaxBodySize(100))
+	assert.NoErr(t, err)
+	assert.Eq(t, 100, len(resp.BodyString()))
+
+	resp, err = greq.New(ts.URL).GetDo("/?size=6", greq.WithMaxBodySize(5))
+	assert.NoErr(t, err)
+	_, err = resp.BodyStringE()
+	assert.True(t, errors.Is(err, greq.ErrBodyTooLarge))
+}
+
+func TestClient_decompressedLimits(t *testing.T) {
+	// 1MB zeros, compressed to about 1KB
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write(make([]byte, 1<<20))
+	_ = zw.Close()
+	bomb := buf.Bytes()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write(bomb)
+	}))
+	defer ts.Close()
+
+	// limit applies to the decoded size
+	client := greq.New(ts.URL).WithCompression().WithMaxResponseBytes(int64(len(bomb)) * 10)
+	resp, err := client.GetDo("/")
+	assert.NoErr(t, err)
+	_, err = resp.BodyStringE()
+	assert.True(t, errors.Is(err, greq.ErrBodyTooLarge))
+
+	// ratio limit
+	client = greq.New(ts.URL).WithCompression().WithMaxCompressionRatio(100)
+	assert.Eq(t, float64(100), client.Sub().MaxCompressionRatio)
+	resp, err = client.GetDo("/")
+	assert.NoErr(t, err)
+	_, err = resp.BodyStringE()
+	assert.True(t, errors.Is(err, greq.ErrBodyTooLarge))
+	assert.ErrMsgContains(t, err, "compression ratio exceeds 100")
+
+	// not exceeds
+	client.WithMaxCompressionRatio(10000)
+	resp, err = client.GetDo("/")
+	assert.NoErr(t, err)
+	s, err := resp.BodyStringE()
+	assert.NoErr(t, err)
+	assert.Eq(t, 1<<20, len(s))
+}
@@ -0,0 +1,270 @@
+package greq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ErrForbiddenDestination the request destination is blocked by the NetPolicy.
+//
+// Check it by errors.Is(err, greq.ErrForbiddenDestination)
+var ErrForbiddenDestination = errors.New("greq: forbidden destination")
+
+// NetPolicy network policy for the request destinations, use it to call the user supplied URLs(eg: webhooks).
+//
+// The loopback, link-local, private, cloud metadata and other special IP ranges are blocked by default,
+// unless allow-listed by AllowCIDRs.
+//
+// The host patterns are checked for each request and redirect, the IPs are checked at dial time
+// after DNS lookup, so DNS rebinding can't bypass it.
+//
+// NOTE: on use a proxy, the dial time IPs check is applied to the proxy address, allow-list it by AllowCIDRs.
+// The target host is resolved and checked before send, but the proxy resolves it again.
+type NetPolicy struct {
+	// AllowHosts host glob patterns, only the matched hosts are allowed if not empty.
+	// eg: "*.example.com", "api.example.com"
+	AllowHosts []string
+	// DenyHosts host glob patterns to deny, it takes precedence over AllowHosts.
+	DenyHosts []string
+	// AllowCIDRs the IP ranges allowed, even if in the blocked ranges.
+	// eg: netip.MustParsePrefix("10.1.0.0/16")
+	AllowCIDRs []netip.Prefix
+	// DenyCIDRs extra IP ranges to block.
+	DenyCIDRs []netip.Prefix
+}
+
+// blocked IP ranges by default
+var forbiddenRanges = []struct {
+	prefix netip.Prefix
+	name   string
+}{
+	{netip.MustParsePrefix("0.0.0.0/8"), "unspecified"},
+	{netip.MustParsePrefix("10.0.0.0/8"), "private"},
+	{netip.MustParsePrefix("100.100.100.200/32"), "metadata"}, // Alibaba Cloud
+	{netip.MustParsePrefix("100.64.0.0/10"), "shared"},
+	{netip.MustParsePrefix("127.0.0.0/8"), "loopback"},
+	{netip.MustParsePrefix("169.254.169.254/32"), "metadata"}, // AWS, GCP, Azure
+	{netip.MustParsePrefix("169.254.0.0/16"), "link-local"},
+	{netip.MustParsePrefix("172.16.0.0/12"), "private"},
+	{netip.MustParsePrefix("192.0.0.0/24"), "reserved"},
+	{netip.MustParsePrefix("192.168.0.0/16"), "private"},
+	{netip.MustParsePrefix("198.18.0.0/15"), "reserved"},
+	{netip.MustParsePrefix("224.0.0.0/4"), "multicast"},
+	{netip.MustParsePrefix("240.0.0.0/4"), "reserved"},
+	{netip.MustParsePrefix("::/128"), "unspecified"},
+	{netip.MustParsePrefix("::1/128"), "loopback"},
+	{netip.MustParsePrefix("fd00:ec2::254/128"), "metadata"}, // AWS IPv6
+	{netip.MustParsePrefix("fc00::/7"), "private"},
+	{netip.MustParsePrefix("fe80::/10"), "link-local"},
+	{netip.MustParsePrefix("ff00::/8"), "multicast"},
+}
+
+// IPv6 ranges that embed an IPv4 address, it's checked by the IPv4 rules.
+var (
+	nat64Prefix  = netip.MustParsePrefix("64:ff9b::/96") // NAT64, IPv4 in the last 32 bits
+	sixTo4Prefix = netip.MustParsePrefix("2002::/16")    // 6to4, IPv4 in the bits 16-48
+)
+
+// WithNetPolicy set the network policy for the request destinations. nil for remove it.
+//
+// Usage:
+//
+//	h.WithNetPolicy(&greq.NetPolicy{
+//		DenyHosts:  []string{"*.internal.example.com"},
+//		AllowCIDRs: []netip.Prefix{netip.MustParsePrefix("10.1.2.0/24")},
+//	})
+//
+// The blocked requests will return an error wrapping ErrForbiddenDestination.
+//
+// NOTE: the IPs check at dial time only works when the doer is *http.Client.
+func (h *Client) WithNetPolicy(p *NetPolicy) *Client {
+	var np *netPolicy
+	if p != nil {
+		np = &netPolicy{NetPolicy: *p}
+	}
+
+	if hc := h.ownHTTPClient(); hc != nil {
+		redirectFn := hc.CheckRedirect
+		if h.netPolicy != nil {
+			redirectFn = h.netPolicy.nextRedirect
+		}
+
+		if np != nil {
+			np.nextRedirect = redirectFn
+			hc.CheckRedirect = np.checkRedirect
+		} else {
+			hc.CheckRedirect = redirectFn
+		}
+	}
+
+	h.netPolicy = np
+	return h.configDialer(func(d *netDialer) {
+		d.policy = np
+	})
+}
+
+// netPolicy the NetPolicy bound to the client. it is readonly after set.
+type netPolicy struct {
+	NetPolicy
+	// the redirect check func before set the policy
+	nextRedirect func(req *http.Request, via []*http.Request) error
+}
+
+func (np *netPolicy) checkRedirect(req *http.Request, via []*http.Request) error {
+	if err := np.checkURL(req.URL, false); err != nil {
+		return err
+	}
+	if err := np.checkProxied(req); err != nil {
+		return err
+	}
+	if np.nextRedirect != nil {
+		return np.nextRedirect(req, via)
+	}
+
+	// same as the default policy of http.Client
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	return nil
+}
+
+// checkURL check the host of the URL by host patterns, and check the IP if the host is an IP.
+// viaSocket: the request is sent by the unix socket of Client.WithUnixSocket
+func (np *netPolicy) checkURL(u *url.URL, viaSocket bool) error {
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if _, ok := unixSocketPath(host); ok && !viaSocket {
+		return fmt.Errorf("%w: unix socket URL %q", ErrForbiddenDestination, u.String())
+	}
+
+	for _, pattern := range np.DenyHosts {
+		if matchHostGlob(pattern, host) {
+			return fmt.Errorf("%w: host %q is denied", ErrForbiddenDestination, host)
+		}
+	}
+	if len(np.AllowHosts) > 0 {
+		allowed := false
+		for _, pattern := range np.AllowHosts {
+			if matchHostGlob(pattern, host) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%w: host %q is not allowed", ErrForbiddenDestination, host)
+		}
+	}
+
+	if ip, err := netip.ParseAddr(host); err == nil && !viaSocket {
+		return np.checkIP(ip)
+	}
+	return nil
+}
+
+// proxyFuncKey the context key of the proxy func of the transport, bind on send for check the redirects.
+type proxyFuncKey struct{}
+
+// checkProxied lookup and check the IPs of the target host if the request is sent by a proxy.
+// The dialer only checks the proxy address in this case.
+func (np *netPolicy) checkProxied(req *http.Request) error {
+	proxyFn, _ := req.Context().Value(proxyFuncKey{}).(func(*http.Request) (*url.URL, error))
+	if proxyFn == nil {
+		return nil
+	}
+	// the proxy error is returned by the transport
+	if pu, err := proxyFn(req); err != nil || pu == nil {
+		return nil
+	}
+
+	host := strings.TrimSuffix(req.URL.Hostname(), ".")
+	if _, err := netip.ParseAddr(host); err == nil {
+		return nil // checked by checkURL
+	}
+	_, err := np.filterIPs(req.Context(), host, nil)
+	return err
+}
+
+// checkIP check the IP is not in the blocked ranges, or it's allow-listed.
+func (np *netPolicy) checkIP(ip netip.Addr) error {
+	ip = ip.Unmap().WithZone("")
+	for _, p := range np.AllowCIDRs {
+		if p.Contains(ip) {
+			return nil
+		}
+	}
+
+	for _, p := range np.DenyCIDRs {
+		if p.Contains(ip) {
+			return fmt.Errorf("%w: IP %s is denied", ErrForbiddenDestination, ip)
+		}
+	}
+	for _, r := range forbiddenRanges {
+		if r.prefix.Contains(ip) {
+			return fmt.Errorf("%w: IP %s is in the %s range", ErrForbiddenDestination, ip, r.name)
+		}
+	}
+
+	if v4, ok := embeddedIPv4(ip); ok {
+		if err := np.checkIP(v4); err != nil {
+			return fmt.Errorf("%w (embedded in IP %s)", err, ip)
+		}
+	}
+	return nil
+}
+
+// embeddedIPv4 returns the IPv4 address embedded in a NAT64 or 6to4 IPv6 address.
+func embeddedIPv4(ip netip.Addr) (netip.Addr, bool) {
+	bs := ip.As16()
+	switch {
+	case nat64Prefix.Contains(ip):
+		return netip.AddrFrom4([4]byte(bs[12:16])), true
+	case sixTo4Prefix.Contains(ip):
+		return netip.AddrFrom4([4]byte(bs[2:6])), true
+	}
+	return netip.Addr{}, false
+}
+
+// filterIPs lookup the host if ips is empty, then returns the allowed IPs.
+func (np *netPolicy) filterIPs(ctx context.Context, host string, ips []string) ([]string, error) {
+	if len(ips) == 0 {
+		var err error
+		if ips, err = net.DefaultResolver.LookupHost(ctx, host); err != nil {
+			return nil, err
+		}
+	}
+
+	var allowed []string
+	var lastErr error
+	for _, s := range ips {
+		ip, err := netip.ParseAddr(s)
+		if err != nil {
+			lastErr = fmt.Errorf("%w: invalid IP %q", ErrForbiddenDestination, s)
+			continue
+		}
+		if err = np.checkIP(ip); err != nil {
+			lastErr = err
+			continue
+		}
+		allowed = append(allowed, s)
+	}
+
+	if len(allowed) == 0 {
+		if lastErr == nil {
+			return nil, fmt.Errorf("dial %s: no addresses found", host)
+		}
+		return nil, fmt.Errorf("dial %s: %w", host, lastErr)
+	}
+	return allowed, nil
+}
+
+// matchHostGlob match the host by the glob pattern, "*" can match multi labels. eg: "*.example.com"
+func matchHostGlob(pattern, host string) bool {
+	ok, _ := path.Match(strings.ToLower(pattern), host)
+	return ok
+}
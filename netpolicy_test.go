@@ -0,0 +1,199 @@
+package greq_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq"
+)
+
+var loopback = []netip.Prefix{netip.MustParsePrefix("127.0.0.1/32")}
+
+func TestClient_WithNetPolicy(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if loc := r.URL.Query().Get("to"); loc != "" {
+			http.Redirect(w, r, loc, http.StatusFound)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	// loopback is blocked by default
+	client := greq.New().WithNetPolicy(&greq.NetPolicy{})
+	_, err := client.GetDo(ts.URL)
+	assert.True(t, errors.Is(err, greq.ErrForbiddenDestination))
+	assert.ErrMsgContains(t, err, "IP 127.0.0.1 is in the loopback range")
+
+	for _, u := range []string{
+		"http://169.254.169.254/latest/meta-data/",
+		"http://[::ffff:10.0.0.1]/",
+		"http://[fd00:ec2::254]/",
+		"http://192.168.1.1:8080/",
+		"http://0.0.0.0/",
+		"http://[64:ff9b::7f00:1]/",    // NAT64 of 127.0.0.1
+		"http://[64:ff9b::a9fe:a9fe]/", // NAT64 of 169.254.169.254
+		"http://[2002:7f00:1::1]/",     // 6to4 of 127.0.0.1
+		"http://[2002:a9fe:a9fe::1]/",  // 6to4 of 169.254.169.254
+	} {
+		_, err = client.GetDo(u)
+		assert.ErrIs(t, err, greq.ErrForbiddenDestination, u)
+	}
+	_, err = client.GetDo("http://[2002:a9fe:a9fe::1]/")
+	assert.ErrMsgContains(t, err, "IP 169.254.169.254 is in the metadata range")
+
+	// allow-listed
+	client = greq.New().WithNetPolicy(&greq.NetPolicy{AllowCIDRs: loopback})
+	resp, err := client.GetDo(ts.URL)
+	assert.NoErr(t, err)
+	assert.Eq(t, "ok", resp.BodyString())
+
+	// remove the policy
+	_, err = greq.New().WithNetPolicy(&greq.NetPolicy{}).WithNetPolicy(nil).GetDo(ts.URL)
+	assert.NoErr(t, err)
+
+	// host patterns
+	client = greq.New().WithNetPolicy(&greq.NetPolicy{
+		AllowHosts: []string{"*.example.com", "127.0.0.1"},
+		DenyHosts:  []string{"admin.example.com"},
+		AllowCIDRs: loopback,
+	})
+	_, err = client.GetDo("http://admin.example.com/")
+	assert.ErrMsgContains(t, err, `host "admin.example.com" is denied`)
+	_, err = client.GetDo("http://example.org/")
+	assert.ErrMsgContains(t, err, `host "example.org" is not allowed`)
+	_, err = client.GetDo(ts.URL)
+	assert.NoErr(t, err)
+
+	// unix socket URL
+	_, err = client.GetDo("unix:///var/run/docker.sock:/info")
+	assert.ErrIs(t, err, greq.ErrForbiddenDestination)
+}
+
+func TestClient_WithNetPolicy_redirect(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if loc := r.URL.Query().Get("to"); loc != "" {
+			http.Redirect(w, r, loc, http.StatusFound)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	client := greq.New().WithNetPolicy(&greq.NetPolicy{
+		DenyHosts:  []string{"*.corp"},
+		AllowCIDRs: loopback,
+	})
+
+	// allowed redirect
+	resp, err := client.GetDo(ts.URL + "/?to=/ok")
+	assert.NoErr(t, err)
+	assert.Eq(t, "ok", resp.BodyString())
+
+	for _, loc := range []string{"http://169.254.169.254/", "http://internal.corp/"} {
+		_, err = client.GetDo(ts.URL + "/?to=" + loc)
+		assert.ErrIs(t, err, greq.ErrForbiddenDestination, loc)
+	}
+
+	// still checked after change the redirect policy
+	client.WithRedirectPolicy(greq.FollowRedirects(3))
+	_, err = client.GetDo(ts.URL + "/?to=http://internal.corp/")
+	assert.ErrIs(t, err, greq.ErrForbiddenDestination)
+
+	// the redirect policy is kept
+	client = greq.New().WithRedirectPolicy(greq.NoRedirect).WithNetPolicy(&greq.NetPolicy{AllowCIDRs: loopback})
+	resp, err = client.GetDo(ts.URL + "/?to=/ok")
+	assert.NoErr(t, err)
+	assert.Eq(t, http.StatusFound, resp.StatusCode)
+}
+
+func TestClient_WithNetPolicy_dialTime(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+
+	// the DNS resolves the public host to the loopback IP
+	cache := greq.NewDNSCache(time.Minute, 0)
+	cache.LookupFunc = func(ctx context.Context, host string) ([]string, error) {
+		return []string{"127.0.0.1"}, nil
+	}
+
+	client := greq.New().WithDNSCache(cache).WithNetPolicy(&greq.NetPolicy{})
+	_, err := client.GetDo("http://rebind.example.com:" + port + "/")
+	assert.ErrIs(t, err, greq.ErrForbiddenDestination)
+	assert.ErrMsgContains(t, err, "loopback")
+
+	// pinned address and connect-to are also checked
+	_, err = greq.New().WithResolve("pinned.example.com", "10.1.1.1").
+		WithNetPolicy(&greq.NetPolicy{}).
+		GetDo("http://pinned.example.com/")
+	assert.ErrIs(t, err, greq.ErrForbiddenDestination)
+
+	_, err = greq.New().WithConnectTo("", "127.0.0.1:"+port).
+		WithNetPolicy(&greq.NetPolicy{}).
+		GetDo("http://example.com/")
+	assert.ErrIs(t, err, greq.ErrForbiddenDestination)
+
+	// DenyCIDRs
+	_, err = greq.New().WithResolve("public.example.com", "8.8.8.8").
+		WithNetPolicy(&greq.NetPolicy{DenyCIDRs: []netip.Prefix{netip.MustParsePrefix("8.8.8.0/24")}}).
+		GetDo("http://public.example.com/")
+	assert.ErrMsgContains(t, err, "IP 8.8.8.8 is denied")
+
+	resp, err := client.Sub().WithNetPolicy(&greq.NetPolicy{AllowCIDRs: loopback}).
+		GetDo("http://rebind.example.com:" + port + "/")
+	assert.NoErr(t, err)
+	assert.Eq(t, "ok", resp.BodyString())
+}
+
+func TestClient_WithNetPolicy_proxy(t *testing.T) {
+	// the proxy listen on another loopback IP, so it can be allow-listed alone
+	ln, err := net.Listen("tcp", "127.0.0.2:0")
+	if err != nil {
+		t.Skip("listen on 127.0.0.2:", err)
+	}
+	proxy := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if loc := r.URL.Query().Get("to"); loc != "" {
+			http.Redirect(w, r, loc, http.StatusFound)
+			return
+		}
+		_, _ = w.Write([]byte("proxied " + r.URL.Host))
+	}))
+	_ = proxy.Listener.Close()
+	proxy.Listener = ln
+	proxy.Start()
+	defer proxy.Close()
+
+	proxyNet := []netip.Prefix{netip.MustParsePrefix("127.0.0.2/32")}
+	client := greq.New().WithProxy(proxy.URL).WithNetPolicy(&greq.NetPolicy{AllowCIDRs: proxyNet})
+
+	// the host resolves to the loopback IP
+	_, err = client.GetDo("http://localhost/")
+	assert.ErrIs(t, err, greq.ErrForbiddenDestination)
+	assert.ErrMsgContains(t, err, "IP 127.0.0.1 is in the loopback range")
+
+	// the redirect target is checked too
+	_, err = client.GetDo("http://127.0.0.2/?to=http://localhost/admin")
+	assert.ErrIs(t, err, greq.ErrForbiddenDestination)
+
+	resp, err := client.GetDo("http://127.0.0.2/")
+	assert.NoErr(t, err)
+	assert.Eq(t, "proxied 127.0.0.2", resp.BodyString())
+
+	// allow-listed the target
+	client = greq.New().WithProxy(proxy.URL).WithNetPolicy(&greq.NetPolicy{
+		AllowCIDRs: append(proxyNet, loopback...),
+	})
+	resp, err = client.GetDo("http://localhost/")
+	assert.NoErr(t, err)
+	assert.Eq(t, "proxied localhost", resp.BodyString())
+}
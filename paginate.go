@@ -0,0 +1,521 @@
+package greq
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	gourl "net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gookit/goutil/x/basefn"
+	"github.com/gookit/greq/internal/bodyprovider"
+)
+
+// ErrMaxPages the pagination is stopped by the max pages limit.
+var ErrMaxPages = errors.New("greq: reached the max pages")
+
+// ErrCrossOriginPage the next page URL is on another origin(scheme and host) of the first page.
+// Allow it by the PageCrossOrigin option.
+var ErrCrossOriginPage = errors.New("greq: next page is cross-origin")
+
+// ErrRepeatedPage the page body is same as the previous page, eg: the server ignores the page param.
+var ErrRepeatedPage = errors.New("greq: page is same as the previous page")
+
+// DefaultMaxPages the default max pages of the Paginate, for avoid endless loop.
+var DefaultMaxPages = 1000
+
+// Page is a fetched page, the strategy use it to build the next page request.
+type Page struct {
+	// Num the page number, start from 1.
+	Num int
+	// Resp the page response, the body is read and closed.
+	Resp *Response
+	// Body contents of the response
+	Body []byte
+	// Count the number of items on the page
+	Count int
+}
+
+// PageRequest the URL and query params of the page request, the strategy can change them for next page.
+type PageRequest struct {
+	URL   string
+	Query gourl.Values
+}
+
+// PageStrategy for build the page requests.
+type PageStrategy interface {
+	// Next set the request for the next page by the previous page, returns false if no next page.
+	//
+	// It is called with prev=nil for the first page, the return value is ignored.
+	Next(prev *Page, req *PageRequest) bool
+}
+
+// PageFunc implements the PageStrategy interface
+type PageFunc func(prev *Page, req *PageRequest) bool
+
+// Next page request
+func (fn PageFunc) Next(prev *Page, req *PageRequest) bool {
+	return fn(prev, req)
+}
+
+// LinkPaging follow the next page URL in the RFC 8288 Link header: `Link: <https://api/items?page=2>; rel="next"`.
+// It stops when the Link header has no "next". The cross-origin next URL is refused, see PageCrossOrigin.
+func LinkPaging() PageStrategy {
+	return PageFunc(func(prev *Page, req *PageRequest) bool {
+		if prev == nil {
+			return true
+		}
+
+		next := parseLinkNext(prev.Resp.Header.Values("Link"))
+		if next == "" {
+			return false
+		}
+		if base := prev.Resp.Request; base != nil {
+			if u, err := base.URL.Parse(next); err == nil {
+				next = u.String()
+			}
+		}
+
+		// the query params are in the next URL
+		req.URL, req.Query = next, gourl.Values{}
+		return true
+	})
+}
+
+// CursorPaging get the cursor from the response body by the JSON path, and send it by the query param.
+// It stops when the cursor is empty or null, or the page is empty.
+//
+// Usage:
+//
+//	// body: {"data": [...], "meta": {"next_cursor": "abc"}}
+//	greq.CursorPaging("cursor", "$.meta.next_cursor")
+func CursorPaging(param, cursorPath string) PageStrategy {
+	return PageFunc(func(prev *Page, req *PageRequest) bool {
+		if prev == nil {
+			return true
+		}
+		if prev.Count == 0 {
+			return false
+		}
+
+		cursor := jsonPathString(prev.Body, cursorPath)
+		if cursor == "" {
+			return false
+		}
+		req.Query.Set(param, cursor)
+		return true
+	})
+}
+
+// OffsetPaging set the offset and limit query params, the offset is increased by the items of each page.
+// It stops when the items of the page is less than the limit. If limit <= 0, the limit param is not
+// sent, and it stops on an empty page.
+//
+// Usage:
+//
+//	greq.OffsetPaging("offset", "limit", 50)
+func OffsetPaging(offsetParam, limitParam string, limit int) PageStrategy {
+	return PageFunc(func(prev *Page, req *PageRequest) bool {
+		if prev == nil {
+			req.Query.Set(offsetParam, "0")
+			if limit > 0 {
+				req.Query.Set(limitParam, strconv.Itoa(limit))
+			}
+			return true
+		}
+		if prev.Count == 0 || prev.Count < limit {
+			return false
+		}
+
+		offset, _ := strconv.Atoi(req.Query.Get(offsetParam))
+		req.Query.Set(offsetParam, strconv.Itoa(offset+prev.Count))
+		return true
+	})
+}
+
+// PageNumPaging set the page number(start from 1) and page size query params.
+// It stops when the items of the page is less than the size. If size <= 0, the size param is not
+// sent, and it stops on an empty page.
+//
+// NOTE: if the server ignores the page param, Paginate stops with ErrRepeatedPage.
+//
+// Usage:
+//
+//	greq.PageNumPaging("page", "per_page", 100)
+func PageNumPaging(pageParam, sizeParam string, size int) PageStrategy {
+	return PageFunc(func(prev *Page, req *PageRequest) bool {
+		if prev == nil {
+			req.Query.Set(pageParam, "1")
+			if size > 0 {
+				req.Query.Set(sizeParam, strconv.Itoa(size))
+			}
+			return true
+		}
+		if prev.Count == 0 || prev.Count < size {
+			return false
+		}
+
+		req.Query.Set(pageParam, strconv.Itoa(prev.Num+1))
+		return true
+	})
+}
+
+// PageOption the option func for the Paginate
+type PageOption func(c *pageConfig)
+
+type pageConfig struct {
+	itemsPath string
+	maxPages  int
+	interval  time.Duration
+	// max retries on the rate limited response
+	retries int
+	// max wait time of the Retry-After
+	maxRetryWait time.Duration
+	// allow the next page URL on another origin
+	crossOrigin bool
+}
+
+// PageItems set the JSON path of the items array in the page body. default is the top-level array.
+func PageItems(path string) PageOption {
+	return func(c *pageConfig) { c.itemsPath = path }
+}
+
+// PageMax set the max pages to fetch, default is DefaultMaxPages. <= 0 for no limit.
+func PageMax(n int) PageOption {
+	return func(c *pageConfig) { c.maxPages = n }
+}
+
+// PageInterval set the min interval between the page requests, for avoid the rate limit.
+func PageInterval(d time.Duration) PageOption {
+	return func(c *pageConfig) { c.interval = d }
+}
+
+// PageRateLimitRetries set the max retries of a page on the 429 or 503 response, default is 3.
+// It waits the time by the Retry-After header, default is 1s.
+func PageRateLimitRetries(n int) PageOption {
+	return func(c *pageConfig) { c.retries = n }
+}
+
+// DefaultPageMaxRetryWait the default max wait time of the Retry-After on the rate limited response.
+var DefaultPageMaxRetryWait = 60 * time.Second
+
+// PageMaxRetryWait set the max wait time of the Retry-After, default is DefaultPageMaxRetryWait.
+// If the server asks for a longer time, the page fails with an error.
+func PageMaxRetryWait(d time.Duration) PageOption {
+	return func(c *pageConfig) { c.maxRetryWait = d }
+}
+
+// PageCrossOrigin allow to fetch the next page on another origin(scheme and host) of the first page.
+//
+// NOTE: the request headers, include the Authorization and Cookie, are sent to the other origin too.
+func PageCrossOrigin() PageOption {
+	return func(c *pageConfig) { c.crossOrigin = true }
+}
+
+// Paginate fetch all pages of the list API by the strategy, the items are decoded to T and yielded one by one.
+//
+// The req is the first page request, nil for GET the client base URL. The io.Reader body of the req is read
+// once and sent for each page. The client retry config is applied
+// to each page, and the rate limited response(429, 503) is retried after the Retry-After time.
+// Cancel the context of the req to stop it. A failed page yields an error and stops the iteration,
+// a bad item yields an error and the iteration can continue.
+//
+// Usage:
+//
+//	req := client.Get("/users").AddQuery("status", "active")
+//	for user, err := range greq.Paginate[User](client, req, greq.PageNumPaging("page", "per_page", 100), greq.PageItems("$.data")) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(user.Name)
+//	}
+func Paginate[T any](client *Client, req *Builder, strategy PageStrategy, opts ...PageOption) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		cfg := &pageConfig{maxPages: DefaultMaxPages, retries: 3, maxRetryWait: DefaultPageMaxRetryWait}
+		for _, fn := range opts {
+			fn(cfg)
+		}
+		if req == nil {
+			req = NewBuilder()
+		}
+		if client == nil {
+			client = basefn.OrValue(req.cli == nil, std, req.cli)
+		}
+
+		var zero T
+		segs, err := parseJSONPath(cfg.itemsPath)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+
+		ctx := req.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		tpl := *req.Options
+		if err = bufferPageBody(&tpl); err != nil {
+			yield(zero, fmt.Errorf("greq: read the page request body: %w", err))
+			return
+		}
+
+		// the origin of the first page, for check the next pages
+		var origin *gourl.URL
+		var prevBody []byte
+		pr := &PageRequest{URL: req.pathURL, Query: cloneValues(req.Query)}
+		strategy.Next(nil, pr)
+		for num := 1; ; num++ {
+			if cfg.maxPages > 0 && num > cfg.maxPages {
+				yield(zero, fmt.Errorf("%w: %d", ErrMaxPages, cfg.maxPages))
+				return
+			}
+			if num > 1 && cfg.interval > 0 {
+				if err = sleepCtx(ctx, cfg.interval); err != nil {
+					yield(zero, err)
+					return
+				}
+			}
+
+			page, err := fetchPage(ctx, client, &tpl, pr, cfg)
+			if err != nil {
+				yield(zero, fmt.Errorf("greq: fetch page %d: %w", num, err))
+				return
+			}
+			if num > 1 && bytes.Equal(page.Body, prevBody) {
+				yield(zero, fmt.Errorf("%w: page %d", ErrRepeatedPage, num))
+				return
+			}
+			prevBody = page.Body
+			page.Num = num
+			if origin == nil && page.Resp.Request != nil {
+				origin = page.Resp.Request.URL
+			}
+
+			var items []json.RawMessage
+			dec := json.NewDecoder(bytes.NewReader(page.Body))
+			if err = seekJSONPath(dec, segs); err == nil {
+				err = dec.Decode(&items)
+			}
+			if err != nil {
+				yield(zero, fmt.Errorf("greq: decode page %d items: %w", num, err))
+				return
+			}
+
+			page.Count = len(items)
+			for _, raw := range items {
+				var v T
+				if err = json.Unmarshal(raw, &v); err != nil {
+					if !yield(v, err) {
+						return
+					}
+				} else if !yield(v, nil) {
+					return
+				}
+			}
+
+			if !strategy.Next(page, pr) {
+				return
+			}
+			if !cfg.crossOrigin && !sameOrigin(origin, pr.URL) {
+				yield(zero, fmt.Errorf("%w: %s", ErrCrossOriginPage, pr.URL))
+				return
+			}
+		}
+	}
+}
+
+// bufferPageBody read the io.Reader body of the page request once, so it can be sent for each page.
+func bufferPageBody(opt *Options) error {
+	readAll := func(r io.Reader) ([]byte, error) {
+		if rc, ok := r.(io.Closer); ok {
+			defer rc.Close()
+		}
+		return io.ReadAll(r)
+	}
+
+	var err error
+	if r, ok := opt.Body.(io.Reader); ok {
+		if opt.Body, err = readAll(r); err != nil {
+			return err
+		}
+	}
+	if r, ok := opt.Data.(io.Reader); ok {
+		if opt.Data, err = readAll(r); err != nil {
+			return err
+		}
+	}
+
+	if p, ok := opt.Provider.(bodyprovider.Reader); ok {
+		r, err := p.Body()
+		if err != nil {
+			return err
+		}
+		bs, err := readAll(r)
+		if err != nil {
+			return err
+		}
+		opt.Provider = bodyprovider.NewBytes(bs, p.ContentType())
+	}
+	return nil
+}
+
+// fetchPage send the page request and read the body, retry on the rate limited response.
+func fetchPage(ctx context.Context, client *Client, tpl *Options, pr *PageRequest, cfg *pageConfig) (*Page, error) {
+	for i := 0; ; i++ {
+		opt := *tpl
+		opt.Query = cloneValues(pr.Query)
+		if opt.Method == "" {
+			opt.Method = http.MethodGet
+		}
+
+		resp, err := client.SendWithOpt(pr.URL, &opt)
+		if err != nil {
+			if opt.TCancelFn != nil {
+				opt.TCancelFn()
+			}
+			return nil, err
+		}
+
+		limited := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+		if limited && i < cfg.retries {
+			resp.QuietCloseBody()
+			if opt.TCancelFn != nil {
+				opt.TCancelFn()
+			}
+
+			wait := retryAfter(resp.Header, time.Second)
+			if wait > cfg.maxRetryWait {
+				return nil, fmt.Errorf("status %d: Retry-After %s exceeds the max wait %s", resp.StatusCode, wait, cfg.maxRetryWait)
+			}
+			if err = sleepCtx(ctx, wait); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.QuietCloseBody()
+		if opt.TCancelFn != nil {
+			opt.TCancelFn()
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !resp.IsSuccessful() {
+			return nil, fmt.Errorf("status %d", resp.StatusCode)
+		}
+		return &Page{Resp: resp, Body: body}, nil
+	}
+}
+
+// sameOrigin check the page URL has the same scheme and host as the origin. the relative URL is same origin.
+func sameOrigin(origin *gourl.URL, pageURL string) bool {
+	u, err := gourl.Parse(pageURL)
+	if origin == nil || err != nil || u.Host == "" {
+		return true
+	}
+	return strings.EqualFold(u.Scheme, origin.Scheme) && strings.EqualFold(u.Host, origin.Host)
+}
+
+// parseLinkNext find the URL of rel="next" in the Link header values. see RFC 8288
+func parseLinkNext(values []string) string {
+	for _, value := range values {
+		for value != "" {
+			start := strings.IndexByte(value, '<')
+			end := strings.IndexByte(value, '>')
+			if start < 0 || end < start {
+				break
+			}
+
+			link := value[start+1 : end]
+			params := value[end+1:]
+			// the params end at the next link
+			if i := strings.IndexByte(params, '<'); i >= 0 {
+				params, value = params[:i], params[i:]
+			} else {
+				value = ""
+			}
+
+			for _, param := range strings.Split(params, ";") {
+				key, val, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(key), "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(val), `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return link
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// jsonPathString get the string or number value at the path from the JSON body, returns "" if not found.
+func jsonPathString(body []byte, path string) string {
+	segs, err := parseJSONPath(path)
+	if err != nil {
+		return ""
+	}
+
+	var data any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err = dec.Decode(&data); err != nil {
+		return ""
+	}
+
+	v, _ := lookupJSONPath(data, segs)
+	switch val := v.(type) {
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	}
+	return ""
+}
+
+// retryAfter parse the Retry-After header, it's seconds or an HTTP date.
+func retryAfter(h http.Header, def time.Duration) time.Duration {
+	val := h.Get("Retry-After")
+	if val == "" {
+		return def
+	}
+	if sec, err := strconv.Atoi(val); err == nil && sec >= 0 {
+		return time.Duration(sec) * time.Second
+	}
+	if t, err := http.ParseTime(val); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return def
+}
+
+// sleepCtx sleep the duration, returns the context error if it's done.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func cloneValues(vs gourl.Values) gourl.Values {
+	nvs := make(gourl.Values, len(vs))
+	for k, v := range vs {
+		nvs[k] = append([]string(nil), v...)
+	}
+	return nvs
+}
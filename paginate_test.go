@@ -0,0 +1,232 @@
+package greq_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq"
+)
+
+// newListServer serve 5 items with multiple pagination styles
+func newListServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	var reqs atomic.Int32
+	all := []streamItem{{1, "a"}, {2, "b"}, {3, "c"}, {4, "d"}, {5, "e"}}
+	slice := func(offset, limit int) []streamItem {
+		end := min(offset+limit, len(all))
+		if offset >= end {
+			return []streamItem{}
+		}
+		return all[offset:end]
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqs.Add(1)
+		q := r.URL.Query()
+		assert.Eq(t, "yes", q.Get("keep"))
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/link":
+			page, _ := strconv.Atoi(q.Get("page"))
+			page = max(page, 1)
+			if page < 3 {
+				w.Header().Set("Link", fmt.Sprintf(`</link?keep=yes&page=1>; rel="first", </link?keep=yes&page=%d>; rel="next last"`, page+1))
+			}
+			_ = json.NewEncoder(w).Encode(slice((page-1)*2, 2))
+		case "/cursor":
+			offset, _ := strconv.Atoi(q.Get("cursor"))
+			var next any
+			if offset+2 < len(all) {
+				next = strconv.Itoa(offset + 2)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": slice(offset, 2), "meta": map[string]any{"next": next}})
+		case "/offset":
+			offset, _ := strconv.Atoi(q.Get("offset"))
+			limit, _ := strconv.Atoi(q.Get("limit"))
+			if limit <= 0 {
+				limit = 2 // the server default
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"items": slice(offset, limit)})
+		case "/pages":
+			page, _ := strconv.Atoi(q.Get("page"))
+			size, _ := strconv.Atoi(q.Get("per_page"))
+			_ = json.NewEncoder(w).Encode(slice((page-1)*size, size))
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &reqs
+}
+
+func TestPaginate(t *testing.T) {
+	ts, reqs := newListServer(t)
+	client := greq.New(ts.URL)
+
+	tests := []struct {
+		path     string
+		strategy greq.PageStrategy
+		opts     []greq.PageOption
+		pages    int32
+	}{
+		{"/link", greq.LinkPaging(), nil, 3},
+		{"/cursor", greq.CursorPaging("cursor", "$.meta.next"), []greq.PageOption{greq.PageItems("$.data")}, 3},
+		{"/offset", greq.OffsetPaging("offset", "limit", 2), []greq.PageOption{greq.PageItems("items")}, 3},
+		// no limit param, the last page is empty
+		{"/offset", greq.OffsetPaging("offset", "limit", 0), []greq.PageOption{greq.PageItems("items")}, 4},
+		// the last page is empty
+		{"/pages", greq.PageNumPaging("page", "per_page", 5), nil, 2},
+	}
+	for _, tt := range tests {
+		reqs.Store(0)
+		req := client.Get(tt.path).AddQuery("keep", "yes")
+		items, errs := collectItems(greq.Paginate[streamItem](client, req, tt.strategy, tt.opts...))
+		assert.Empty(t, errs, tt.path)
+		assert.Len(t, items, 5, tt.path)
+		assert.Eq(t, streamItem{5, "e"}, items[4], tt.path)
+		assert.Eq(t, tt.pages, reqs.Load(), tt.path)
+	}
+
+	// max pages
+	req := client.Get("/link").AddQuery("keep", "yes")
+	items, errs := collectItems(greq.Paginate[streamItem](nil, req, greq.LinkPaging(), greq.PageMax(2)))
+	assert.Len(t, items, 4)
+	assert.Len(t, errs, 1)
+	assert.ErrIs(t, errs[0], greq.ErrMaxPages)
+
+	// stop early
+	reqs.Store(0)
+	for item, err := range greq.Paginate[streamItem](client, client.Get("/link").AddQuery("keep", "yes"), greq.LinkPaging()) {
+		assert.NoErr(t, err)
+		assert.Eq(t, 1, item.ID)
+		break
+	}
+	assert.Eq(t, int32(1), reqs.Load())
+}
+
+func TestPaginate_rateLimit(t *testing.T) {
+	var n atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch n.Add(1) {
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 4:
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			_, _ = fmt.Fprintf(w, `[{"id": %d}]`, n.Load())
+		}
+	}))
+	defer ts.Close()
+
+	client := greq.New(ts.URL)
+	items, errs := collectItems(greq.Paginate[streamItem](client, client.Get("/"), greq.PageNumPaging("page", "", 0)))
+	assert.Eq(t, []streamItem{{ID: 1}, {ID: 3}}, items)
+	assert.Len(t, errs, 1)
+	assert.ErrMsgContains(t, errs[0], "fetch page 3: status 400")
+
+	// the Retry-After exceeds the max wait
+	limited := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "86400")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer limited.Close()
+
+	start := time.Now()
+	_, errs = collectItems(greq.Paginate[streamItem](nil, greq.New(limited.URL).Get("/"), greq.PageNumPaging("page", "", 0)))
+	assert.Len(t, errs, 1)
+	assert.ErrMsgContains(t, errs[0], "Retry-After 24h0m0s exceeds the max wait 1m0s")
+	assert.True(t, time.Since(start) < time.Second)
+
+	_, errs = collectItems(greq.Paginate[streamItem](nil, greq.New(limited.URL).Get("/"), greq.PageNumPaging("page", "", 0),
+		greq.PageMaxRetryWait(time.Second)))
+	assert.ErrMsgContains(t, errs[0], "exceeds the max wait 1s")
+
+	// cancel by context
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n.Store(10)
+	var got int
+	for _, err := range greq.Paginate[streamItem](client, client.Get("/").WithOptionFn(greq.WithContext(ctx)), greq.PageNumPaging("page", "", 0)) {
+		if err != nil {
+			assert.ErrIs(t, err, context.Canceled)
+			break
+		}
+		if got++; got == 2 {
+			cancel()
+		}
+	}
+	assert.Eq(t, 2, got)
+}
+
+func TestPaginate_repeatedPage(t *testing.T) {
+	var reqs atomic.Int32
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqs.Add(1)
+		bs, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(bs))
+		// ignore the page param
+		_, _ = w.Write([]byte(`[{"id": 1}, {"id": 2}]`))
+	}))
+	defer ts.Close()
+
+	client := greq.New(ts.URL)
+	items, errs := collectItems(greq.Paginate[streamItem](client, client.Get("/"), greq.PageNumPaging("page", "", 0)))
+	assert.Len(t, items, 2)
+	assert.Len(t, errs, 1)
+	assert.ErrIs(t, errs[0], greq.ErrRepeatedPage)
+	assert.Eq(t, int32(2), reqs.Load())
+
+	// the server ignores the offset param
+	items, errs = collectItems(greq.Paginate[streamItem](client, client.Get("/"), greq.OffsetPaging("offset", "limit", 0)))
+	assert.Len(t, items, 2)
+	assert.ErrIs(t, errs[0], greq.ErrRepeatedPage)
+
+	// the reader body is sent for each page
+	for _, req := range []*greq.Builder{
+		client.Post("/").BodyReader(strings.NewReader(`{"q": "x"}`)),
+		client.Post("/").AnyBody(strings.NewReader(`{"q": "x"}`)),
+	} {
+		bodies = nil
+		_, errs = collectItems(greq.Paginate[streamItem](client, req, greq.PageNumPaging("page", "", 0)))
+		assert.ErrIs(t, errs[0], greq.ErrRepeatedPage)
+		assert.Eq(t, []string{`{"q": "x"}`, `{"q": "x"}`}, bodies)
+	}
+}
+
+func TestPaginate_crossOrigin(t *testing.T) {
+	var otherAuth atomic.Value
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otherAuth.Store(r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`[{"id": 2}]`))
+	}))
+	defer other.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", "<"+other.URL+"/items?page=2>; rel=\"next\"")
+		_, _ = w.Write([]byte(`[{"id": 1}]`))
+	}))
+	defer ts.Close()
+
+	client := greq.New(ts.URL).DefaultHeader("Authorization", "Bearer secret")
+	items, errs := collectItems(greq.Paginate[streamItem](client, client.Get("/items"), greq.LinkPaging()))
+	assert.Eq(t, []streamItem{{ID: 1}}, items)
+	assert.Len(t, errs, 1)
+	assert.ErrIs(t, errs[0], greq.ErrCrossOriginPage)
+	assert.Nil(t, otherAuth.Load())
+
+	// allowed by the option
+	items, errs = collectItems(greq.Paginate[streamItem](client, client.Get("/items"), greq.LinkPaging(), greq.PageCrossOrigin()))
+	assert.Eq(t, []streamItem{{ID: 1}, {ID: 2}}, items)
+	assert.Empty(t, errs)
+	assert.Eq(t, "Bearer secret", otherAuth.Load())
+}
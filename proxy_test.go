@@ -0,0 +1,216 @@
+package greq_test
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq"
+)
+
+// newHTTPProxy a forward proxy, responds with the proxied URL instead of forwarding.
+func newHTTPProxy(t *testing.T, name string) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Proxy", name)
+		w.Header().Set("X-Proxy-Auth", r.Header.Get("Proxy-Authorization"))
+		_, _ = w.Write([]byte(r.URL.String()))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestClient_WithProxy(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("direct"))
+	}))
+	defer target.Close()
+	p1 := newHTTPProxy(t, "p1")
+	p2 := newHTTPProxy(t, "p2")
+
+	client := greq.New().WithProxy("http://user:pass@" + p1.Listener.Addr().String())
+	resp, err := client.GetDo("http://example.com/path?a=1")
+	assert.NoErr(t, err)
+	assert.Eq(t, "p1", resp.Header.Get("X-Proxy"))
+	assert.Eq(t, "Basic "+base64.StdEncoding.EncodeToString([]byte("user:pass")), resp.Header.Get("X-Proxy-Auth"))
+	assert.Eq(t, "http://example.com/path?a=1", resp.BodyString())
+
+	// per-host proxy, no proxy
+	sub := client.Sub().
+		WithHostProxy("api.example.com", p2.URL).
+		WithHostProxy("10.0.0.0/8", "").
+		WithNoProxy("127.0.0.1, .local")
+
+	for host, want := range map[string]string{
+		"http://api.example.com":     "p2",
+		"http://v1.api.example.com":  "p2",
+		"http://example.com":         "p1",
+		"http://a.b.local:8080/path": "",
+	} {
+		resp, err = sub.GetDo(host, greq.WithTimeout(500))
+		if want == "" {
+			// connect directly
+			if err == nil {
+				assert.Empty(t, resp.Header.Get("X-Proxy"), host)
+			}
+			continue
+		}
+		assert.NoErr(t, err)
+		assert.Eq(t, want, resp.Header.Get("X-Proxy"), host)
+	}
+
+	resp, err = sub.GetDo(target.URL)
+	assert.NoErr(t, err)
+	assert.Eq(t, "direct", resp.BodyString())
+
+	// parent client is not affected
+	resp, err = client.GetDo(target.URL)
+	assert.NoErr(t, err)
+	assert.Eq(t, "p1", resp.Header.Get("X-Proxy"))
+
+	// clear
+	resp, err = client.WithProxy("").GetDo(target.URL)
+	assert.NoErr(t, err)
+	assert.Eq(t, "direct", resp.BodyString())
+
+	// invalid
+	_, err = greq.New().WithProxy("ftp://127.0.0.1:21").GetDo(target.URL)
+	assert.True(t, errors.Is(err, greq.ErrInvalidProxy))
+	_, err = greq.ParseProxyURL("http://")
+	assert.ErrIs(t, err, greq.ErrInvalidProxy)
+
+	u, err := greq.ParseProxyURL("127.0.0.1:3128")
+	assert.NoErr(t, err)
+	assert.Eq(t, "http://127.0.0.1:3128", u.String())
+}
+
+func TestClient_WithNoProxy(t *testing.T) {
+	p1 := newHTTPProxy(t, "p1")
+	// the not proxied hosts connect directly, it may fail without network
+	client := greq.New().WithProxy(p1.URL).WithNoProxy("example.com:8080", "*.example.org", "[::1]:80", "192.168.1.0/24")
+
+	tests := []struct {
+		url     string
+		proxied bool
+	}{
+		{"http://example.com", true},
+		{"http://example.com:8080", false},
+		{"http://example.org", true},
+		{"http://www.example.org", false},
+		{"http://192.168.1.10", false},
+		{"http://192.168.2.10", true},
+		{"http://[::1]:80/", false},
+	}
+	for _, tt := range tests {
+		resp, err := client.GetDo(tt.url, greq.WithTimeout(500))
+		if tt.proxied {
+			assert.NoErr(t, err, tt.url)
+			assert.Eq(t, "p1", resp.Header.Get("X-Proxy"), tt.url)
+		} else if err == nil {
+			assert.Empty(t, resp.Header.Get("X-Proxy"), tt.url)
+		}
+	}
+}
+
+func TestClient_WithProxy_socks5(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello " + r.Host))
+	}))
+	defer target.Close()
+
+	var gotUser, gotAddr string
+	proxyAddr := startSocks5(t, func(user, addr string) {
+		gotUser, gotAddr = user, addr
+	})
+
+	for _, scheme := range []string{"socks5", "socks5h"} {
+		client := greq.New().WithProxy(scheme + "://tom:secret@" + proxyAddr)
+		resp, err := client.GetDo(target.URL + "/hi")
+		assert.NoErr(t, err)
+		assert.Eq(t, "hello "+target.Listener.Addr().String(), resp.BodyString())
+		assert.Eq(t, "tom:secret", gotUser)
+		assert.Eq(t, target.Listener.Addr().String(), gotAddr)
+	}
+}
+
+// startSocks5 start a minimal SOCKS5 server(RFC 1928) with username/password auth(RFC 1929).
+func startSocks5(t *testing.T, onConnect func(user, addr string)) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoErr(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	handle := func(c net.Conn) error {
+		defer c.Close()
+		buf := make([]byte, 512)
+		// greeting: VER NMETHODS METHODS
+		if _, err := io.ReadFull(c, buf[:2]); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(c, buf[:buf[1]]); err != nil {
+			return err
+		}
+		_, _ = c.Write([]byte{5, 2}) // username/password
+
+		// auth: VER ULEN UNAME PLEN PASSWD
+		if _, err := io.ReadFull(c, buf[:2]); err != nil {
+			return err
+		}
+		uname := make([]byte, buf[1])
+		_, _ = io.ReadFull(c, uname)
+		_, _ = io.ReadFull(c, buf[:1])
+		passwd := make([]byte, buf[0])
+		_, _ = io.ReadFull(c, passwd)
+		_, _ = c.Write([]byte{1, 0})
+
+		// request: VER CMD RSV ATYP DST.ADDR DST.PORT
+		if _, err := io.ReadFull(c, buf[:4]); err != nil {
+			return err
+		}
+		var host string
+		switch buf[3] {
+		case 1:
+			_, _ = io.ReadFull(c, buf[:4])
+			host = net.IP(buf[:4]).String()
+		case 3:
+			_, _ = io.ReadFull(c, buf[:1])
+			name := make([]byte, buf[0])
+			_, _ = io.ReadFull(c, name)
+			host = string(name)
+		case 4:
+			_, _ = io.ReadFull(c, buf[:16])
+			host = net.IP(buf[:16]).String()
+		}
+		_, _ = io.ReadFull(c, buf[:2])
+		addr := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(buf[:2]))))
+		onConnect(string(uname)+":"+string(passwd), addr)
+
+		dst, err := net.Dial("tcp", addr)
+		if err != nil {
+			_, _ = c.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+			return err
+		}
+		defer dst.Close()
+		_, _ = c.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+
+		go func() { _, _ = io.Copy(dst, c) }()
+		_, err = io.Copy(c, dst)
+		return err
+	}
+
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() { _ = handle(c) }()
+		}
+	}()
+	return ln.Addr().String()
+}
@@ -0,0 +1,224 @@
+package greq
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrTooManyRedirects is returned when the redirects exceed RedirectPolicy.MaxRedirects
+var ErrTooManyRedirects = errors.New("greq: too many redirects")
+
+// RedirectPolicy config for follow redirects.
+//
+// The zero value will not follow any redirects, the 3xx response is returned as is.
+type RedirectPolicy struct {
+	// MaxRedirects max redirect hops to follow. <= 0: not follow redirects.
+	//
+	// Will return ErrTooManyRedirects when exceeded.
+	MaxRedirects int
+	// SameHostOnly only follow redirects to the same host as the first request.
+	// Redirects to other hosts will not be followed, the 3xx response is returned.
+	SameHostOnly bool
+	// KeepAuth keep the Authorization and Cookie headers on redirect to another host.
+	//
+	// Default will strip them when the host is changed.
+	KeepAuth bool
+	// KeepMethod keep the method and body on 301, 302 redirects.
+	//
+	// Default will rewrite the non GET/HEAD method to GET, same as browsers and curl.
+	// 303 always use GET, 307 and 308 always keep the method.
+	KeepMethod bool
+}
+
+// NoRedirect policy: not follow any redirects
+var NoRedirect = &RedirectPolicy{}
+
+// FollowRedirects create a policy to follow max redirects.
+func FollowRedirects(maxHops int) *RedirectPolicy {
+	return &RedirectPolicy{MaxRedirects: maxHops}
+}
+
+// sensitive headers will be stripped on redirect to another host
+var sensitiveHeaders = []string{"Authorization", "Www-Authenticate", "Cookie", "Cookie2"}
+
+// body headers will be stripped by http.Client on rewrite method to GET
+var bodyHeaders = []string{"Content-Type", "Content-Encoding", "Content-Language", "Content-Location"}
+
+// CheckRedirect implements the http.Client.CheckRedirect func.
+func (p *RedirectPolicy) CheckRedirect(req *http.Request, via []*http.Request) error {
+	if p.MaxRedirects <= 0 {
+		return http.ErrUseLastResponse
+	}
+	if len(via) > p.MaxRedirects {
+		return fmt.Errorf("%w: stopped after %d redirects", ErrTooManyRedirects, p.MaxRedirects)
+	}
+
+	first, last := via[0], via[len(via)-1]
+	crossHost := !strings.EqualFold(req.URL.Host, first.URL.Host)
+	if crossHost && p.SameHostOnly {
+		return http.ErrUseLastResponse
+	}
+
+	if p.KeepAuth {
+		copyHeaders(req.Header, first.Header, sensitiveHeaders)
+	} else if crossHost {
+		// http.Client keeps them on redirect to sub-domain, strip them on any host changed.
+		for _, name := range sensitiveHeaders {
+			req.Header.Del(name)
+		}
+	}
+
+	if p.KeepMethod && req.Method != last.Method && req.Response != nil {
+		code := req.Response.StatusCode
+		if code == http.StatusMovedPermanently || code == http.StatusFound {
+			return keepMethod(req, first, last.Method)
+		}
+	}
+	return nil
+}
+
+// keepMethod restore the method, body and body headers from the first request.
+func keepMethod(req, first *http.Request, method string) error {
+	if first.ContentLength != 0 || first.GetBody != nil {
+		if first.GetBody == nil {
+			return fmt.Errorf("greq: cannot keep method %s on redirect, the request body is not rewindable", method)
+		}
+
+		body, err := first.GetBody()
+		if err != nil {
+			return err
+		}
+		req.Body, req.GetBody, req.ContentLength = body, first.GetBody, first.ContentLength
+		copyHeaders(req.Header, first.Header, bodyHeaders)
+	}
+
+	req.Method = method
+	return nil
+}
+
+func copyHeaders(dst, src http.Header, names []string) {
+	for _, name := range names {
+		if vs, ok := src[name]; ok {
+			dst[name] = vs
+		}
+	}
+}
+
+// WithRedirectPolicy set the redirect policy. nil for use the default policy of http.Client:
+// follow max 10 redirects.
+//
+// Usage:
+//
+//	h.WithRedirectPolicy(greq.NoRedirect)
+//	h.WithRedirectPolicy(&greq.RedirectPolicy{MaxRedirects: 5, SameHostOnly: true})
+//
+// NOTE: only works when the doer is *http.Client.
+func (h *Client) WithRedirectPolicy(p *RedirectPolicy) *Client {
+	if hc := h.ownHTTPClient(); hc != nil {
+		var fn func(req *http.Request, via []*http.Request) error
+		if p != nil {
+			fn = p.CheckRedirect
+		}
+
+		// keep checking the redirect destination by the net policy
+		if h.netPolicy != nil {
+			np := *h.netPolicy
+			np.nextRedirect = fn
+			h.netPolicy = &np
+			fn = np.checkRedirect
+		}
+		hc.CheckRedirect = fn
+	}
+	return h
+}
+
+// WithInsecureSkipVerify skip verify the server TLS certificate. DO NOT use it in production.
+//
+// NOTE: only works when the doer is *http.Client.
+func (h *Client) WithInsecureSkipVerify(skip bool) *Client {
+	return h.ConfigTransport(func(ht *http.Transport) {
+		if ht.TLSClientConfig == nil {
+			ht.TLSClientConfig = &tls.Config{}
+		}
+		ht.TLSClientConfig.InsecureSkipVerify = skip
+	})
+}
+
+// ConfigTransport custom config the http.Transport of the doer.
+//
+// The transport is cloned before config, so it will not affect the parent client(see Sub)
+// and the http.DefaultTransport. Does nothing if the doer is not *http.Client.
+func (h *Client) ConfigTransport(fn func(ht *http.Transport)) *Client {
+	hc := h.ownHTTPClient()
+	if hc == nil {
+		return h
+	}
+
+	var ht *http.Transport
+	switch t := hc.Transport.(type) {
+	case nil:
+		ht = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		ht = t.Clone()
+	default:
+		return h
+	}
+
+	fn(ht)
+	hc.Transport = ht
+	return h
+}
+
+// ownHTTPClient copy the doer *http.Client and use it as the doer, so changes on it
+// will not affect the parent client(see Sub) and the http.DefaultClient.
+//
+// Returns nil if the doer is not *http.Client.
+func (h *Client) ownHTTPClient() *http.Client {
+	hc, ok := h.doer.(*http.Client)
+	if !ok {
+		return nil
+	}
+
+	cp := *hc
+	h.doer = &cp
+	return &cp
+}
+
+// Redirect is one hop of the redirect chain.
+type Redirect struct {
+	// Method and URL of the request which got the redirect response
+	Method string
+	URL    string
+	// StatusCode of the redirect response. eg: 301, 302
+	StatusCode int
+	// Location header value of the redirect response
+	Location string
+}
+
+// Redirects get the followed redirect chain of the request, in order. returns nil if no redirect.
+func (r *Response) Redirects() []Redirect {
+	if r.Response == nil || r.Request == nil {
+		return nil
+	}
+
+	var hops []Redirect
+	// http.Client links the redirect response to the next request: req.Response.Request is the previous request
+	for req := r.Request; req.Response != nil && req.Response.Request != nil; req = req.Response.Request {
+		prev := req.Response.Request
+		hops = append(hops, Redirect{
+			Method:     prev.Method,
+			URL:        prev.URL.String(),
+			StatusCode: req.Response.StatusCode,
+			Location:   req.Response.Header.Get("Location"),
+		})
+	}
+
+	// reverse to the request order
+	for i, j := 0, len(hops)-1; i < j; i, j = i+1, j-1 {
+		hops[i], hops[j] = hops[j], hops[i]
+	}
+	return hops
+}
@@ -0,0 +1,134 @@
+package greq_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq"
+)
+
+func newRedirectServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/r1", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/r2", http.StatusFound)
+	})
+	mux.HandleFunc("/r2", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/end", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/end", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Method", r.Method)
+		w.Header().Set("X-Auth", r.Header.Get("Authorization"))
+		_, _ = w.Write(body)
+	})
+
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestClient_WithRedirectPolicy(t *testing.T) {
+	ts := newRedirectServer(t)
+
+	t.Run("default follow", func(t *testing.T) {
+		resp, err := greq.New(ts.URL).GetDo("/r1")
+		assert.NoErr(t, err)
+		assert.Eq(t, 200, resp.StatusCode)
+
+		hops := resp.Redirects()
+		assert.Len(t, hops, 2)
+		assert.Eq(t, ts.URL+"/r1", hops[0].URL)
+		assert.Eq(t, 302, hops[0].StatusCode)
+		assert.Eq(t, "/r2", hops[0].Location)
+		assert.Eq(t, 301, hops[1].StatusCode)
+	})
+
+	t.Run("no redirect", func(t *testing.T) {
+		resp, err := greq.New(ts.URL).WithRedirectPolicy(greq.NoRedirect).GetDo("/r1")
+		assert.NoErr(t, err)
+		assert.Eq(t, 302, resp.StatusCode)
+		assert.Eq(t, "/r2", resp.Header.Get("Location"))
+		assert.Empty(t, resp.Redirects())
+	})
+
+	t.Run("max hops", func(t *testing.T) {
+		_, err := greq.New(ts.URL).WithRedirectPolicy(greq.FollowRedirects(1)).GetDo("/r1")
+		assert.Err(t, err)
+		assert.True(t, errors.Is(err, greq.ErrTooManyRedirects))
+
+		resp, err := greq.New(ts.URL).WithRedirectPolicy(greq.FollowRedirects(2)).GetDo("/r1")
+		assert.NoErr(t, err)
+		assert.Eq(t, 200, resp.StatusCode)
+	})
+
+	t.Run("rewrite method", func(t *testing.T) {
+		resp, err := greq.New(ts.URL).WithRedirectPolicy(greq.FollowRedirects(5)).
+			PostDo("/r1", greq.WithBody("data"))
+		assert.NoErr(t, err)
+		assert.Eq(t, "GET", resp.Header.Get("X-Method"))
+		assert.Eq(t, "", resp.BodyString())
+	})
+
+	t.Run("keep method", func(t *testing.T) {
+		p := &greq.RedirectPolicy{MaxRedirects: 5, KeepMethod: true}
+		resp, err := greq.New(ts.URL).WithRedirectPolicy(p).PostDo("/r1", greq.WithBody("data"))
+		assert.NoErr(t, err)
+		assert.Eq(t, "POST", resp.Header.Get("X-Method"))
+		assert.Eq(t, "data", resp.BodyString())
+		assert.Eq(t, "POST", resp.Redirects()[1].Method)
+	})
+
+	t.Run("sub client not affected", func(t *testing.T) {
+		parent := greq.New(ts.URL)
+		parent.Sub().WithRedirectPolicy(greq.NoRedirect)
+
+		resp, err := parent.GetDo("/r1")
+		assert.NoErr(t, err)
+		assert.Eq(t, 200, resp.StatusCode)
+	})
+}
+
+func TestRedirectPolicy_crossHost(t *testing.T) {
+	target := newRedirectServer(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL+"/end", http.StatusFound)
+	}))
+	defer ts.Close()
+
+	// strip auth on cross host by default
+	resp, err := greq.New(ts.URL).WithRedirectPolicy(greq.FollowRedirects(5)).
+		GetDo("/", greq.WithHeader("Authorization", "Bearer token"))
+	assert.NoErr(t, err)
+	assert.Eq(t, 200, resp.StatusCode)
+	assert.Eq(t, "", resp.Header.Get("X-Auth"))
+
+	// keep auth
+	resp, err = greq.New(ts.URL).WithRedirectPolicy(&greq.RedirectPolicy{MaxRedirects: 5, KeepAuth: true}).
+		GetDo("/", greq.WithHeader("Authorization", "Bearer token"))
+	assert.NoErr(t, err)
+	assert.Eq(t, "Bearer token", resp.Header.Get("X-Auth"))
+
+	// same host only
+	resp, err = greq.New(ts.URL).WithRedirectPolicy(&greq.RedirectPolicy{MaxRedirects: 5, SameHostOnly: true}).
+		GetDo("/")
+	assert.NoErr(t, err)
+	assert.Eq(t, 302, resp.StatusCode)
+}
+
+func TestClient_WithInsecureSkipVerify(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	_, err := greq.New(ts.URL).GetDo("/")
+	assert.Err(t, err)
+
+	resp, err := greq.New(ts.URL).WithInsecureSkipVerify(true).GetDo("/")
+	assert.NoErr(t, err)
+	assert.Eq(t, "ok", resp.BodyString())
+}
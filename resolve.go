@@ -0,0 +1,214 @@
+package greq
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WithResolve pin the host to the addresses without DNS lookup, like curl --resolve.
+//
+// hostPort can be "host:port" or "host" for any port. The addresses are tried in order.
+//
+//	h.WithResolve("api.example.com:443", "10.0.0.5", "10.0.0.6")
+//
+// NOTE: only works when the doer is *http.Client.
+func (h *Client) WithResolve(hostPort string, addrs ...string) *Client {
+	host, port := splitHostPortOpt(hostPort)
+	key := strings.ToLower(host) + ":" + port
+
+	return h.configDialer(func(d *netDialer) {
+		if len(addrs) == 0 {
+			delete(d.resolves, key)
+			return
+		}
+
+		ips := make([]string, len(addrs))
+		for i, addr := range addrs {
+			ips[i] = strings.Trim(strings.TrimSpace(addr), "[]")
+		}
+		d.resolves[key] = ips
+	})
+}
+
+// WithConnectTo connect to the target instead of the request host and port, like curl --connect-to.
+// The Host header and TLS SNI are not changed.
+//
+// from: "host:port", "host" for any port, ":port" for any host.
+// to: "host:port", "host" keep the port, ":port" keep the host.
+//
+//	h.WithConnectTo("api.example.com:443", "canary.internal:8443")
+//
+// NOTE: only works when the doer is *http.Client.
+func (h *Client) WithConnectTo(from, to string) *Client {
+	rule := connectRule{}
+	rule.fromHost, rule.fromPort = splitHostPortOpt(from)
+	rule.toHost, rule.toPort = splitHostPortOpt(to)
+
+	return h.configDialer(func(d *netDialer) {
+		d.connectTo = append(d.connectTo, rule)
+	})
+}
+
+// WithDNSCache lookup the hosts by the DNS cache. nil for disable it.
+//
+// NOTE: only works when the doer is *http.Client.
+func (h *Client) WithDNSCache(cache *DNSCache) *Client {
+	return h.configDialer(func(d *netDialer) {
+		d.dnsCache = cache
+	})
+}
+
+// configDialer modify a copy of the dialer and set it to the transport.
+func (h *Client) configDialer(fn func(d *netDialer)) *Client {
+	d := h.dialer.clone()
+	fn(d)
+	h.dialer = d
+	return h.ConfigTransport(func(ht *http.Transport) {
+		ht.DialContext = d.DialContext
+	})
+}
+
+// splitHostPortOpt split the host and the optional port. eg: "host", "host:80", ":80", "[::1]:80", "::1"
+func splitHostPortOpt(s string) (host, port string) {
+	s = strings.TrimSpace(s)
+	if h, p, err := net.SplitHostPort(s); err == nil {
+		return h, p
+	}
+	return strings.Trim(s, "[]"), ""
+}
+
+type connectRule struct {
+	fromHost, fromPort string
+	toHost, toPort     string
+}
+
+// netDialer dial the connections by the client network options. it is readonly after set to the transport.
+type netDialer struct {
+	dialer *net.Dialer
+	// dial the connections of the unixHosts to the unix socket
+	unixSocket string
+	unixHosts  []string
+	// key: "host:port", "host:" for any port
+	resolves  map[string][]string
+	connectTo []connectRule
+	dnsCache  *DNSCache
+	// check the IPs before dial
+	policy *netPolicy
+}
+
+func (d *netDialer) clone() *netDialer {
+	if d == nil {
+		return &netDialer{
+			dialer:   &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+			resolves: make(map[string][]string),
+		}
+	}
+
+	cp := *d
+	cp.resolves = make(map[string][]string, len(d.resolves))
+	for k, v := range d.resolves {
+		cp.resolves[k] = v
+	}
+	cp.connectTo = append([]connectRule(nil), d.connectTo...)
+	return &cp
+}
+
+// DialContext is the http.Transport.DialContext func
+func (d *netDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// unix socket
+	if d.viaSocket(host) {
+		return d.dialer.DialContext(ctx, "unix", d.unixSocket)
+	}
+
+	host, port = d.connectTarget(host, port)
+	ips, err := d.lookup(ctx, host, port)
+	if err != nil {
+		return nil, err
+	}
+	// check the resolved IPs and dial them, so DNS rebinding can't bypass it
+	if d.policy != nil {
+		if ips, err = d.policy.filterIPs(ctx, host, ips); err != nil {
+			return nil, err
+		}
+	}
+	if len(ips) == 0 {
+		return d.dialer.DialContext(ctx, network, net.JoinHostPort(host, port))
+	}
+
+	var errs []error
+	for _, ip := range ips {
+		conn, err := d.dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// viaSocket check the host is dialed by the unix socket of Client.WithUnixSocket
+func (d *netDialer) viaSocket(host string) bool {
+	if d == nil || d.unixSocket == "" {
+		return false
+	}
+
+	host = strings.TrimSuffix(host, ".")
+	for _, h := range d.unixHosts {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// connectTarget get the target by the connect-to rules. the first matched wins.
+func (d *netDialer) connectTarget(host, port string) (string, string) {
+	for _, r := range d.connectTo {
+		if (r.fromHost == "" || strings.EqualFold(r.fromHost, host)) && (r.fromPort == "" || r.fromPort == port) {
+			if r.toHost != "" {
+				host = r.toHost
+			}
+			if r.toPort != "" {
+				port = r.toPort
+			}
+			return host, port
+		}
+	}
+	return host, port
+}
+
+// lookup the IPs by the pinned addresses or the DNS cache. returns empty for dial by the host.
+func (d *netDialer) lookup(ctx context.Context, host, port string) ([]string, error) {
+	if net.ParseIP(host) != nil {
+		return []string{host}, nil
+	}
+
+	host = strings.ToLower(host)
+	if ips, ok := d.resolves[host+":"+port]; ok {
+		return ips, nil
+	}
+	if ips, ok := d.resolves[host+":"]; ok {
+		return ips, nil
+	}
+
+	if d.dnsCache != nil {
+		ips, err := d.dnsCache.LookupHost(ctx, host)
+		if err != nil {
+			return nil, &net.OpError{Op: "dial", Net: "tcp", Err: err}
+		}
+		return ips, nil
+	}
+	return nil, nil
+}
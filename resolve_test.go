@@ -0,0 +1,168 @@
+package greq_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq"
+)
+
+func newHostServer(t *testing.T) (*httptest.Server, string) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("host=" + r.Host))
+	}))
+	t.Cleanup(ts.Close)
+
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+	return ts, port
+}
+
+func TestClient_WithResolve(t *testing.T) {
+	_, port := newHostServer(t)
+
+	client := greq.New().WithResolve("api.example.com:"+port, "127.0.0.1")
+	resp, err := client.GetDo("http://api.example.com:" + port + "/")
+	assert.NoErr(t, err)
+	assert.Eq(t, "host=api.example.com:"+port, resp.BodyString())
+
+	// any port, try addresses in order
+	client = greq.New().WithResolve("API.example.com", "[::ffff:127.0.0.2]", "127.0.0.1")
+	resp, err = client.GetDo("http://api.example.com:" + port + "/")
+	assert.NoErr(t, err)
+	assert.Eq(t, "host=api.example.com:"+port, resp.BodyString())
+
+	// remove pinned
+	sub := client.Sub().WithResolve("api.example.com")
+	_, err = sub.GetDo("http://api.example.com:"+port+"/", greq.WithTimeout(500))
+	assert.Err(t, err)
+	// parent client is not affected
+	_, err = client.GetDo("http://api.example.com:" + port + "/")
+	assert.NoErr(t, err)
+}
+
+func TestClient_WithConnectTo(t *testing.T) {
+	_, port := newHostServer(t)
+
+	client := greq.New().
+		WithConnectTo("api.example.com:80", "127.0.0.1:"+port).
+		WithConnectTo(":8080", "127.0.0.1:"+port).
+		WithResolve("canary.local", "127.0.0.1").
+		WithConnectTo("www.example.com", "canary.local")
+
+	tests := map[string]string{
+		"http://api.example.com/":           "host=api.example.com",
+		"http://other.example.com:8080/":    "host=other.example.com:8080",
+		"http://www.example.com:" + port:    "host=www.example.com:" + port,
+		"http://API.EXAMPLE.COM/?upper=yes": "host=API.EXAMPLE.COM",
+	}
+	for u, want := range tests {
+		resp, err := client.GetDo(u)
+		assert.NoErr(t, err, u)
+		assert.Eq(t, want, resp.BodyString(), u)
+	}
+}
+
+func TestDNSCache(t *testing.T) {
+	_, port := newHostServer(t)
+
+	var calls atomic.Int32
+	cache := greq.NewDNSCache(time.Minute, 50*time.Millisecond)
+	cache.LookupFunc = func(ctx context.Context, host string) ([]string, error) {
+		calls.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		if host == "bad.example.com" {
+			return nil, errors.New("no such host")
+		}
+		return []string{"127.0.0.1"}, nil
+	}
+
+	client := greq.New().WithDNSCache(cache)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.GetDo("http://api.example.com:" + port + "/")
+			assert.NoErr(t, err)
+			assert.Eq(t, "host=api.example.com:"+port, resp.BodyString())
+		}()
+	}
+	wg.Wait()
+	assert.Eq(t, int32(1), calls.Load())
+
+	// negative caching
+	_, err := client.GetDo("http://bad.example.com/")
+	assert.ErrMsgContains(t, err, "no such host")
+	_, err = client.GetDo("http://bad.example.com/")
+	assert.Err(t, err)
+	assert.Eq(t, int32(2), calls.Load())
+
+	time.Sleep(60 * time.Millisecond)
+	_, err = client.GetDo("http://bad.example.com/")
+	assert.Err(t, err)
+	assert.Eq(t, int32(3), calls.Load())
+
+	// IP is not looked up
+	addrs, err := cache.LookupHost(context.Background(), "127.0.0.1")
+	assert.NoErr(t, err)
+	assert.Eq(t, []string{"127.0.0.1"}, addrs)
+	assert.Eq(t, int32(3), calls.Load())
+
+	cache.Remove("api.example.com")
+	_, err = cache.LookupHost(context.Background(), "api.example.com")
+	assert.NoErr(t, err)
+	assert.Eq(t, int32(4), calls.Load())
+
+	cache.Clear()
+	_, _ = cache.LookupHost(context.Background(), "api.example.com")
+	assert.Eq(t, int32(5), calls.Load())
+}
+
+func TestDNSCache_canceledLookup(t *testing.T) {
+	var calls atomic.Int32
+	started := make(chan struct{})
+	cache := greq.NewDNSCache(time.Minute, time.Minute)
+	cache.LookupFunc = func(ctx context.Context, host string) ([]string, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return []string{"127.0.0.1"}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error)
+	go func() {
+		_, err := cache.LookupHost(ctx, "api.example.com")
+		errCh <- err
+	}()
+	<-started
+
+	// the second caller waits the lookup of the first caller
+	type result struct {
+		addrs []string
+		err   error
+	}
+	resCh := make(chan result)
+	go func() {
+		addrs, err := cache.LookupHost(context.Background(), "api.example.com")
+		resCh <- result{addrs, err}
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	cancel()
+	assert.ErrIs(t, <-errCh, context.Canceled)
+	res := <-resCh
+	assert.NoErr(t, res.err)
+	assert.Eq(t, []string{"127.0.0.1"}, res.addrs)
+	assert.Eq(t, int32(2), calls.Load())
+}
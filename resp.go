@@ -17,8 +17,19 @@ type Response struct {
 	*http.Response
 	// CostTime for a request-response. unit: ms
 	CostTime int64
+	// Endpoint the base URL of the endpoint served the request. see Client.WithBalancer
+	Endpoint string
+	// MaxLineSize max size of a line on Lines() and DecodeStream() NDJSON.
+	// default is the response body size limit, or DefaultMaxLineSize if not limited.
+	MaxLineSize int64
 	// decoder for response, default will extends from Client.respDecoder
 	decoder RespDecoder
+	// trace for collect the phase timings
+	trace *timingTrace
+	// buffered body by the Expect assertions
+	buffered []byte
+	// error on read the body for buffered, reported by the later body assertions
+	bufErr error
 }
 
 // NewResponse create a new Response instance
@@ -0,0 +1,212 @@
+package greq
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// validateJSONSchema validate the decoded JSON value by the schema, returns the errors with the value path.
+//
+// It supports a common subset of the JSON Schema keywords, see RespExpect.JSONSchema
+func validateJSONSchema(schema, data any) []string {
+	sv, err := toJSONValue(schema)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid schema: %v", err)}
+	}
+
+	var errs []string
+	checkSchema(sv, data, "$", &errs)
+	return errs
+}
+
+func checkSchema(schema, v any, path string, errs *[]string) {
+	addErr := func(format string, args ...any) {
+		*errs = append(*errs, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	s, ok := schema.(map[string]any)
+	if !ok {
+		// the boolean schema
+		if allow, isBool := schema.(bool); !isBool {
+			addErr("invalid schema %v", schema)
+		} else if !allow {
+			addErr("not allowed")
+		}
+		return
+	}
+
+	if typ, ok := s["type"]; ok {
+		var types []string
+		switch t := typ.(type) {
+		case string:
+			types = []string{t}
+		case []any:
+			for _, item := range t {
+				types = append(types, fmt.Sprint(item))
+			}
+		}
+		if !matchAnyType(types, v) {
+			addErr("expect type %s, but got %s", strings.Join(types, "|"), jsonTypeOf(v))
+			return
+		}
+	}
+
+	if enum, ok := s["enum"].([]any); ok {
+		var found bool
+		for _, item := range enum {
+			if reflect.DeepEqual(item, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			addErr("value %s not in enum %s", jsonString(v), jsonString(enum))
+		}
+	}
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(c, v) {
+		addErr("expect const %s, but got %s", jsonString(c), jsonString(v))
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		checkObject(s, val, path, errs)
+	case []any:
+		if n, ok := s["minItems"].(float64); ok && float64(len(val)) < n {
+			addErr("expect at least %v items, but got %d", n, len(val))
+		}
+		if n, ok := s["maxItems"].(float64); ok && float64(len(val)) > n {
+			addErr("expect at most %v items, but got %d", n, len(val))
+		}
+		if items, ok := s["items"]; ok {
+			for i, item := range val {
+				checkSchema(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case string:
+		size := float64(utf8.RuneCountInString(val))
+		if n, ok := s["minLength"].(float64); ok && size < n {
+			addErr("expect min length %v, but got %v", n, size)
+		}
+		if n, ok := s["maxLength"].(float64); ok && size > n {
+			addErr("expect max length %v, but got %v", n, size)
+		}
+		if pattern, ok := s["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err != nil {
+				addErr("invalid pattern %q", pattern)
+			} else if !re.MatchString(val) {
+				addErr("%q not match the pattern %q", val, pattern)
+			}
+		}
+	case float64:
+		if n, ok := s["minimum"].(float64); ok && val < n {
+			addErr("expect minimum %v, but got %v", n, val)
+		}
+		if n, ok := s["maximum"].(float64); ok && val > n {
+			addErr("expect maximum %v, but got %v", n, val)
+		}
+		if n, ok := s["exclusiveMinimum"].(float64); ok && val <= n {
+			addErr("expect exclusive minimum %v, but got %v", n, val)
+		}
+		if n, ok := s["exclusiveMaximum"].(float64); ok && val >= n {
+			addErr("expect exclusive maximum %v, but got %v", n, val)
+		}
+	}
+
+	if all, ok := s["allOf"].([]any); ok {
+		for _, sub := range all {
+			checkSchema(sub, v, path, errs)
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]any); ok && countMatched(anyOf, v, path) == 0 {
+		addErr("not match any of the anyOf schemas")
+	}
+	if oneOf, ok := s["oneOf"].([]any); ok {
+		if n := countMatched(oneOf, v, path); n != 1 {
+			addErr("expect match one of the oneOf schemas, but matched %d", n)
+		}
+	}
+}
+
+func checkObject(s map[string]any, obj map[string]any, path string, errs *[]string) {
+	if required, ok := s["required"].([]any); ok {
+		for _, key := range required {
+			if _, ok := obj[fmt.Sprint(key)]; !ok {
+				*errs = append(*errs, fmt.Sprintf("%s: missing required property %q", path, key))
+			}
+		}
+	}
+
+	props, _ := s["properties"].(map[string]any)
+	additional, hasAdditional := s["additionalProperties"]
+
+	// check in order for stable errors
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		subPath := path + "." + key
+		if sub, ok := props[key]; ok {
+			checkSchema(sub, obj[key], subPath, errs)
+		} else if hasAdditional {
+			checkSchema(additional, obj[key], subPath, errs)
+		}
+	}
+}
+
+func countMatched(schemas []any, v any, path string) (n int) {
+	for _, sub := range schemas {
+		var errs []string
+		if checkSchema(sub, v, path, &errs); len(errs) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func matchAnyType(types []string, v any) bool {
+	got := jsonTypeOf(v)
+	for _, t := range types {
+		if t == got || t == "number" && got == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonTypeOf get the JSON schema type of the decoded value
+func jsonTypeOf(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if val == math.Trunc(val) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func jsonString(v any) string {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(bs)
+}
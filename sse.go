@@ -0,0 +1,273 @@
+package greq
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrSSEResponse the SSE response is not a valid event stream, the SSE will not reconnect.
+var ErrSSEResponse = errors.New("greq: invalid SSE response")
+
+// DefaultSSERetry the default reconnection time of the SSE stream, the server can change it by the retry field.
+var DefaultSSERetry = 3 * time.Second
+
+// Event is a Server-Sent Event
+type Event struct {
+	// ID the last event ID, it is kept until the stream changes it.
+	ID string
+	// Type of the event, default is "message"
+	Type string
+	// Data of the event, the multi data lines are joined by "\n"
+	Data string
+	// Retry the reconnection time set by the stream. 0 if not set
+	Retry time.Duration
+}
+
+// Events parse the response body as the Server-Sent Events stream incrementally, the body is closed on the end.
+//
+// Usage:
+//
+//	for ev, err := range resp.Events() {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(ev.Type, ev.Data)
+//	}
+func (r *Response) Events() iter.Seq2[*Event, error] {
+	return func(yield func(*Event, error) bool) {
+		defer r.QuietCloseBody()
+
+		er := newEventReader(r.Body, "")
+		for {
+			ev, err := er.next()
+			if err != nil {
+				if err != io.EOF {
+					yield(nil, err)
+				}
+				return
+			}
+			if !yield(ev, nil) {
+				return
+			}
+		}
+	}
+}
+
+// SSE send a GET request to consume the Server-Sent Events stream, the events are yielded by the iterator.
+//
+// It reconnects with the Last-Event-ID header after the stream dropped, the reconnection delay is
+// DefaultSSERetry or set by the server. The request errors are yielded then reconnect, break the loop
+// or cancel the context to stop it. It stops when the server responds 204 No Content, or the
+// status is not 200 or the content type is not "text/event-stream".
+//
+// NOTE: the Client.Timeout is not applied to the stream(see WithNoTimeout), set it by WithTimeout if need.
+//
+// Usage:
+//
+//	for ev, err := range client.SSE("/events", greq.WithContext(ctx)) {
+//		if err != nil {
+//			log.Println("SSE error:", err)
+//			continue
+//		}
+//		fmt.Println(ev.ID, ev.Type, ev.Data)
+//	}
+func (h *Client) SSE(url string, optFns ...OptionFn) iter.Seq2[*Event, error] {
+	return func(yield func(*Event, error) bool) {
+		var lastID string
+		delay := DefaultSSERetry
+
+		for {
+			opt := NewOpt2(optFns, http.MethodGet)
+			opt.NoTimeout = true
+			if opt.Header.Get("Accept") == "" {
+				opt.Header.Set("Accept", "text/event-stream")
+			}
+			opt.Header.Set("Cache-Control", "no-cache")
+			if lastID != "" {
+				opt.Header.Set("Last-Event-ID", lastID)
+			}
+
+			ctx := opt.Context
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			resp, err := h.SendWithOpt(url, opt)
+			if err == nil {
+				var stop bool
+				stop, err = readEvents(ctx, resp, &lastID, &delay, yield)
+				if stop {
+					return
+				}
+			}
+			if opt.TCancelFn != nil {
+				opt.TCancelFn()
+			}
+
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil && !yield(nil, err) {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+		}
+	}
+}
+
+// readEvents read the events from the response, returns stop=true if not need reconnect.
+func readEvents(ctx context.Context, resp *Response, lastID *string, delay *time.Duration, yield func(*Event, error) bool) (bool, error) {
+	defer resp.QuietCloseBody()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return true, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		yield(nil, fmt.Errorf("%w: status %d", ErrSSEResponse, resp.StatusCode))
+		return true, nil
+	}
+	if !resp.IsContentType("text/event-stream") {
+		yield(nil, fmt.Errorf("%w: content type %q", ErrSSEResponse, resp.ContentType()))
+		return true, nil
+	}
+
+	er := newEventReader(resp.Body, *lastID)
+	for {
+		ev, err := er.next()
+		*lastID = er.lastID
+		if er.retry > 0 {
+			*delay = er.retry
+		}
+
+		if err != nil {
+			if err == io.EOF {
+				return false, nil
+			}
+			return false, err
+		}
+		if ctx.Err() != nil || !yield(ev, nil) {
+			return true, nil
+		}
+	}
+}
+
+// eventReader parse the event stream. see https://html.spec.whatwg.org/multipage/server-sent-events.html
+type eventReader struct {
+	sc     *bufio.Scanner
+	lastID string
+	retry  time.Duration
+	first  bool
+	skipLF bool
+}
+
+func newEventReader(r io.Reader, lastID string) *eventReader {
+	er := &eventReader{sc: bufio.NewScanner(r), lastID: lastID, first: true}
+	er.sc.Buffer(make([]byte, 0, 4096), 1<<20)
+	er.sc.Split(er.scanLines)
+	return er
+}
+
+// next read the next event, returns io.EOF on the stream end.
+func (er *eventReader) next() (*Event, error) {
+	var typ string
+	var data strings.Builder
+	var hasData bool
+
+	for er.sc.Scan() {
+		line := er.sc.Bytes()
+		if er.first {
+			line = bytes.TrimPrefix(line, []byte("\xEF\xBB\xBF"))
+			er.first = false
+		}
+
+		// dispatch the event on blank line
+		if len(line) == 0 {
+			if !hasData {
+				typ = ""
+				continue
+			}
+			if typ == "" {
+				typ = "message"
+			}
+			return &Event{ID: er.lastID, Type: typ, Data: data.String(), Retry: er.retry}, nil
+		}
+		// comment line
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value := line, []byte(nil)
+		if i := bytes.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], bytes.TrimPrefix(line[i+1:], []byte(" "))
+		}
+
+		switch string(field) {
+		case "event":
+			typ = string(value)
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.Write(value)
+			hasData = true
+		case "id":
+			if bytes.IndexByte(value, 0) < 0 {
+				er.lastID = string(value)
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(string(value), 10, 63); err == nil {
+				er.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+
+	if err := er.sc.Err(); err != nil {
+		return nil, err
+	}
+	// the incomplete event at the end is discarded
+	return nil, io.EOF
+}
+
+// scanLines split the lines by "\r\n", "\n" or "\r"
+func (er *eventReader) scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	// the "\n" after "\r" of the last line
+	if er.skipLF && len(data) > 0 {
+		er.skipLF = false
+		if data[0] == '\n' {
+			return 1, nil, nil
+		}
+	}
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\r' {
+			if i+1 < len(data) && data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+			// not wait the next data, the line may be the end of an event
+			er.skipLF = i+1 == len(data)
+		}
+		return i + 1, data[:i], nil
+	}
+
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,141 @@
+package greq_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq"
+)
+
+func TestResponse_Events(t *testing.T) {
+	stream := "\xEF\xBB\xBF: comment\n" +
+		"data: first\n\n" +
+		"event: update\r\nid: 1\r\ndata: line1\r\ndata:line2\r\nretry: 1500\r\n\r\n" +
+		"event: ignored\rid: 2\r\r" +
+		"data\n\n" +
+		"data: incomplete"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(stream))
+	}))
+	defer ts.Close()
+
+	resp, err := greq.GetDo(ts.URL)
+	assert.NoErr(t, err)
+
+	var events []greq.Event
+	for ev, err := range resp.Events() {
+		assert.NoErr(t, err)
+		events = append(events, *ev)
+	}
+	assert.Eq(t, []greq.Event{
+		{Type: "message", Data: "first"},
+		{ID: "1", Type: "update", Data: "line1\nline2", Retry: 1500 * time.Millisecond},
+		{ID: "2", Type: "message", Data: "", Retry: 1500 * time.Millisecond},
+	}, events)
+}
+
+func TestClient_SSE(t *testing.T) {
+	var conns atomic.Int32
+	var lastIDs []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := conns.Add(1)
+		lastIDs = append(lastIDs, r.Header.Get("Last-Event-ID"))
+		assert.Eq(t, "text/event-stream", r.Header.Get("Accept"))
+		if n == 3 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		if n == 1 {
+			_, _ = fmt.Fprint(w, "retry: 10\n\nid: 1\ndata: a\n\nid: 2\ndata: b\n\n")
+		} else {
+			_, _ = fmt.Fprint(w, "id: 3\ndata: c\n\n")
+		}
+	}))
+	defer ts.Close()
+
+	var got []string
+	start := time.Now()
+	for ev, err := range greq.New(ts.URL).SSE("/events") {
+		assert.NoErr(t, err)
+		got = append(got, ev.ID+":"+ev.Data)
+	}
+	assert.Eq(t, []string{"1:a", "2:b", "3:c"}, got)
+	assert.Eq(t, []string{"", "2", "3"}, lastIDs)
+	// the retry set by server
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestClient_SSE_stop(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bad" {
+			w.WriteHeader(500)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; ; i++ {
+			if _, err := fmt.Fprintf(w, "data: %d\n\n", i); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}))
+	defer ts.Close()
+	client := greq.New(ts.URL)
+
+	// cancel by context
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var n int
+	for ev, err := range client.SSE("/live", greq.WithContext(ctx)) {
+		assert.NoErr(t, err)
+		assert.Eq(t, fmt.Sprint(n), ev.Data)
+		if n++; n == 3 {
+			cancel()
+		}
+	}
+	assert.Eq(t, 3, n)
+
+	// break the loop
+	n = 0
+	for range client.SSE("/live") {
+		if n++; n == 2 {
+			break
+		}
+	}
+	assert.Eq(t, 2, n)
+
+	// the client timeout is not applied to the stream
+	n = 0
+	for _, err := range greq.New(ts.URL).DefaultTimeout(30).SSE("/live") {
+		assert.NoErr(t, err)
+		if n++; n == 6 {
+			break
+		}
+	}
+	assert.Eq(t, 6, n)
+
+	// invalid response
+	n = 0
+	for ev, err := range client.SSE("/bad") {
+		assert.Nil(t, ev)
+		assert.ErrIs(t, err, greq.ErrSSEResponse)
+		assert.ErrMsgContains(t, err, "status 500")
+		n++
+	}
+	assert.Eq(t, 1, n)
+}
@@ -0,0 +1,351 @@
+package greq
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+)
+
+// ErrLineTooLong the line of the response body exceeds the Response.MaxLineSize.
+//
+// Check it by errors.Is(err, greq.ErrLineTooLong)
+var ErrLineTooLong = errors.New("greq: response line too long")
+
+// DefaultMaxLineSize the default max line size of Lines() and DecodeStream() NDJSON,
+// when the response body size is not limited.
+var DefaultMaxLineSize int64 = 4 << 20
+
+// lineLimit get the max line size of the response
+func (r *Response) lineLimit() int64 {
+	if r.MaxLineSize > 0 {
+		return r.MaxLineSize
+	}
+	return DefaultMaxLineSize
+}
+
+// readLine read a line with the size limit, the line ending is kept.
+// The line ending is not counted in the size.
+func readLine(br *bufio.Reader, limit int64) ([]byte, error) {
+	var line []byte
+	for {
+		frag, err := br.ReadSlice('\n')
+		size := int64(len(line) + len(frag))
+		if err == nil {
+			size -= int64(len(frag) - len(bytes.TrimRight(frag, "\r\n")))
+		}
+		if size > limit {
+			return nil, fmt.Errorf("%w: exceeds the limit of %d bytes", ErrLineTooLong, limit)
+		}
+
+		line = append(line, frag...)
+		if err != bufio.ErrBufferFull {
+			return line, err
+		}
+	}
+}
+
+// Lines iterate the response body by lines, the line endings "\n" or "\r\n" are trimmed.
+// The body is closed on the iteration stopped.
+//
+// A line longer than Response.MaxLineSize yields an error wrapping ErrLineTooLong and stops the iteration.
+//
+// Usage:
+//
+//	for line, err := range resp.Lines() {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(line)
+//	}
+func (r *Response) Lines() iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		defer r.QuietCloseBody()
+
+		br := bufio.NewReader(r.Body)
+		limit := r.lineLimit()
+		for {
+			bs, err := readLine(br, limit)
+			if len(bs) > 0 {
+				line := strings.TrimSuffix(strings.TrimSuffix(string(bs), "\n"), "\r")
+				if !yield(line, nil) {
+					return
+				}
+			}
+			if err != nil {
+				if err != io.EOF {
+					yield("", err)
+				}
+				return
+			}
+		}
+	}
+}
+
+// DecodeStream decode the response body to T one by one in bounded memory, the body is closed on the iteration stopped.
+//
+// The body can be NDJSON(JSON Lines) or a top-level JSON array. NDJSON is detected by the content type
+// "application/x-ndjson", "application/jsonl" or the body is not start with "[". A bad NDJSON line yields an
+// error and the iteration can continue, other errors stop the iteration. eg: an NDJSON line longer than
+// Response.MaxLineSize yields an error wrapping ErrLineTooLong.
+//
+// Usage:
+//
+//	for user, err := range greq.DecodeStream[User](resp) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(user.Name)
+//	}
+func DecodeStream[T any](resp *Response) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		defer resp.QuietCloseBody()
+
+		br := bufio.NewReader(resp.Body)
+		if !isNDJSON(resp.ContentType()) && peekNonSpace(br) == '[' {
+			decodeArray(json.NewDecoder(br), yield)
+			return
+		}
+
+		limit := resp.lineLimit()
+		for {
+			line, err := readLine(br, limit)
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				var v T
+				if err1 := json.Unmarshal(line, &v); err1 != nil {
+					err1 = fmt.Errorf("greq: decode NDJSON line: %w", err1)
+					if !yield(v, err1) {
+						return
+					}
+				} else if !yield(v, nil) {
+					return
+				}
+			}
+
+			if err != nil {
+				if err != io.EOF {
+					var zero T
+					yield(zero, err)
+				}
+				return
+			}
+		}
+	}
+}
+
+// DecodeStreamAt decode the elements of the nested JSON array at the path to T one by one in bounded memory,
+// the body is closed on the iteration stopped.
+//
+// The path format: "$.data.items", "data.items", "$.pages[0].items", `$["data"]["items"]`.
+// "" or "$" is the top-level array.
+//
+// Usage:
+//
+//	// body: {"total": 2, "data": {"items": [{"id": 1}, {"id": 2}]}}
+//	for item, err := range greq.DecodeStreamAt[Item](resp, "$.data.items") {
+//		...
+//	}
+func DecodeStreamAt[T any](resp *Response, path string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		defer resp.QuietCloseBody()
+
+		var zero T
+		segs, err := parseJSONPath(path)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+
+		dec := json.NewDecoder(resp.Body)
+		if err = seekJSONPath(dec, segs); err != nil {
+			yield(zero, fmt.Errorf("greq: JSON path %q: %w", path, err))
+			return
+		}
+		decodeArray(dec, yield)
+	}
+}
+
+// decodeArray decode the array elements from the decoder
+func decodeArray[T any](dec *json.Decoder, yield func(T, error) bool) {
+	var zero T
+	if err := expectDelim(dec, '['); err != nil {
+		yield(zero, err)
+		return
+	}
+
+	for dec.More() {
+		var v T
+		if err := dec.Decode(&v); err != nil {
+			yield(zero, err)
+			return
+		}
+		if !yield(v, nil) {
+			return
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		yield(zero, err)
+	}
+}
+
+func isNDJSON(contentType string) bool {
+	return strings.Contains(contentType, "ndjson") || strings.Contains(contentType, "jsonl")
+}
+
+// peekNonSpace peek the first non-space byte. returns 0 if not found
+func peekNonSpace(br *bufio.Reader) byte {
+	for i := 1; ; i++ {
+		buf, err := br.Peek(i)
+		if len(buf) < i {
+			return 0
+		}
+		if c := buf[i-1]; c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+			return c
+		}
+		if err != nil {
+			return 0
+		}
+	}
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return fmt.Errorf("expect %q, but got %v", want, tok)
+	}
+	return nil
+}
+
+// seekJSONPath move the decoder to the value at the path
+func seekJSONPath(dec *json.Decoder, segs []any) error {
+	for _, seg := range segs {
+		switch key := seg.(type) {
+		case string:
+			if err := expectDelim(dec, '{'); err != nil {
+				return err
+			}
+			for {
+				if !dec.More() {
+					return fmt.Errorf("key %q not found", key)
+				}
+				tok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				if tok == key {
+					break
+				}
+				if err = skipJSONValue(dec); err != nil {
+					return err
+				}
+			}
+		case int:
+			if err := expectDelim(dec, '['); err != nil {
+				return err
+			}
+			for i := 0; i < key; i++ {
+				if !dec.More() {
+					return fmt.Errorf("index %d out of range", key)
+				}
+				if err := skipJSONValue(dec); err != nil {
+					return err
+				}
+			}
+			if !dec.More() {
+				return fmt.Errorf("index %d out of range", key)
+			}
+		}
+	}
+	return nil
+}
+
+// skipJSONValue skip the next value by tokens, not load the whole value.
+func skipJSONValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if d, ok := tok.(json.Delim); ok {
+			if d == '{' || d == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// parseJSONPath parse the JSON path to segments: string for object key, int for array index.
+//
+// The path format: "$.data.items", "data.items[0]", `$["a.b"][1]`. "" or "$" is the root.
+func parseJSONPath(path string) ([]any, error) {
+	p := strings.TrimPrefix(strings.TrimSpace(path), "$")
+	var segs []any
+	for len(p) > 0 {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			continue
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("greq: invalid JSON path %q", path)
+			}
+			inner := strings.TrimSpace(p[1:end])
+			if n, err := strconv.Atoi(inner); err == nil && n >= 0 {
+				segs = append(segs, n)
+			} else if key, err := strconv.Unquote(inner); err == nil {
+				segs = append(segs, key)
+			} else if len(inner) > 1 && inner[0] == '\'' && inner[len(inner)-1] == '\'' {
+				segs = append(segs, inner[1:len(inner)-1])
+			} else {
+				return nil, fmt.Errorf("greq: invalid JSON path %q", path)
+			}
+			p = p[end+1:]
+		default:
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			segs = append(segs, p[:end])
+			p = p[end:]
+		}
+	}
+	return segs, nil
+}
+
+// lookupJSONPath get the value at the path segments from the decoded JSON value.
+func lookupJSONPath(v any, segs []any) (any, bool) {
+	for _, seg := range segs {
+		switch key := seg.(type) {
+		case string:
+			m, ok := v.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if v, ok = m[key]; !ok {
+				return nil, false
+			}
+		case int:
+			arr, ok := v.([]any)
+			if !ok || key >= len(arr) {
+				return nil, false
+			}
+			v = arr[key]
+		}
+	}
+	return v, true
+}
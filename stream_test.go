@@ -0,0 +1,184 @@
+package greq_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq"
+)
+
+type streamItem struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// closeRecorder record the body is closed
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func mockStreamResp(t *testing.T, contentType, body string) (*greq.Response, *closeRecorder) {
+	rc := &closeRecorder{Reader: strings.NewReader(body)}
+	resp, err := greq.New().Doer(httpDoerFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{"Content-Type": {contentType}},
+			Body:       rc,
+			Request:    r,
+		}, nil
+	})).GetDo("http://mock.local/")
+	assert.NoErr(t, err)
+	return resp, rc
+}
+
+func collectItems(seq func(func(streamItem, error) bool)) ([]streamItem, []error) {
+	var items []streamItem
+	var errs []error
+	for item, err := range seq {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, errs
+}
+
+func TestResponse_Lines(t *testing.T) {
+	resp, rc := mockStreamResp(t, "text/plain", "line1\r\nline2\n\nline4")
+	var lines []string
+	for line, err := range resp.Lines() {
+		assert.NoErr(t, err)
+		lines = append(lines, line)
+	}
+	assert.Eq(t, []string{"line1", "line2", "", "line4"}, lines)
+	assert.True(t, rc.closed)
+
+	// stop early
+	resp, rc = mockStreamResp(t, "text/plain", "a\nb\nc\n")
+	for line := range resp.Lines() {
+		assert.Eq(t, "a", line)
+		break
+	}
+	assert.True(t, rc.closed)
+}
+
+func TestDecodeStream(t *testing.T) {
+	// NDJSON, bad line is skipped
+	resp, rc := mockStreamResp(t, "application/x-ndjson", `{"id":1,"name":"a"}
+
+{"id":2,"name":"b"}
+{bad}
+{"id":3,"name":"c"}`)
+	items, errs := collectItems(greq.DecodeStream[streamItem](resp))
+	assert.Eq(t, []streamItem{{1, "a"}, {2, "b"}, {3, "c"}}, items)
+	assert.Len(t, errs, 1)
+	assert.ErrMsgContains(t, errs[0], "decode NDJSON line")
+	assert.True(t, rc.closed)
+
+	// top-level array
+	resp, _ = mockStreamResp(t, "application/json", ` [{"id":1,"name":"a"}, {"id":2,"name":"b"}]`)
+	items, errs = collectItems(greq.DecodeStream[streamItem](resp))
+	assert.Empty(t, errs)
+	assert.Eq(t, []streamItem{{1, "a"}, {2, "b"}}, items)
+
+	// stop early
+	resp, rc = mockStreamResp(t, "application/json", `[{"id":1}, {"id":2}, {"id":3}]`)
+	for item, err := range greq.DecodeStream[streamItem](resp) {
+		assert.NoErr(t, err)
+		assert.Eq(t, 1, item.ID)
+		break
+	}
+	assert.True(t, rc.closed)
+
+	// invalid array element
+	resp, _ = mockStreamResp(t, "application/json", `[{"id":1}, {"id":"x"}]`)
+	items, errs = collectItems(greq.DecodeStream[streamItem](resp))
+	assert.Len(t, items, 1)
+	assert.Len(t, errs, 1)
+}
+
+func TestDecodeStream_lineLimit(t *testing.T) {
+	// default is DefaultMaxLineSize
+	resp, _ := mockStreamResp(t, "application/x-ndjson", "{\"id\":1}\n")
+	assert.Eq(t, int64(0), resp.MaxLineSize)
+	items, errs := collectItems(greq.DecodeStream[streamItem](resp))
+	assert.Empty(t, errs)
+	assert.Len(t, items, 1)
+
+	long := `{"id":2,"name":"` + strings.Repeat("b", 100) + `"}`
+	resp, rc := mockStreamResp(t, "application/x-ndjson", "{\"id\":1}\r\n"+long+"\n{\"id\":3}")
+	resp.MaxLineSize = 50
+	items, errs = collectItems(greq.DecodeStream[streamItem](resp))
+	assert.Eq(t, []streamItem{{ID: 1}}, items)
+	assert.Len(t, errs, 1)
+	assert.ErrIs(t, errs[0], greq.ErrLineTooLong)
+	assert.ErrMsgContains(t, errs[0], "exceeds the limit of 50 bytes")
+	assert.True(t, rc.closed)
+
+	// the line ending is not counted
+	resp, _ = mockStreamResp(t, "text/plain", "12345\r\n123456\n")
+	resp.MaxLineSize = 5
+	var lines []string
+	for line, err := range resp.Lines() {
+		if err != nil {
+			assert.ErrIs(t, err, greq.ErrLineTooLong)
+			break
+		}
+		lines = append(lines, line)
+	}
+	assert.Eq(t, []string{"12345"}, lines)
+
+	// default from the body size limit
+	client := greq.New().WithMaxResponseBytes(1024).Doer(httpDoerFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("{}")), Request: r}, nil
+	}))
+	resp, err := client.GetDo("http://mock.local/")
+	assert.NoErr(t, err)
+	assert.Eq(t, int64(1024), resp.MaxLineSize)
+
+	resp, err = client.GetDo("http://mock.local/", greq.WithMaxBodySize(100))
+	assert.NoErr(t, err)
+	assert.Eq(t, int64(100), resp.MaxLineSize)
+}
+
+func TestDecodeStreamAt(t *testing.T) {
+	body := `{"total": 2, "meta": {"items": [0], "next": null},
+		"data": {"pages": [{"items": []}, {"items": [{"id":1,"name":"a"}, {"id":2,"name":"b"}]}]}}`
+
+	for _, path := range []string{"$.data.pages[1].items", `data["pages"][1]['items']`} {
+		resp, rc := mockStreamResp(t, "application/json", body)
+		items, errs := collectItems(greq.DecodeStreamAt[streamItem](resp, path))
+		assert.Empty(t, errs, path)
+		assert.Eq(t, []streamItem{{1, "a"}, {2, "b"}}, items)
+		assert.True(t, rc.closed)
+	}
+
+	// top-level
+	resp, _ := mockStreamResp(t, "application/json", `[{"id":1}]`)
+	items, errs := collectItems(greq.DecodeStreamAt[streamItem](resp, "$"))
+	assert.Empty(t, errs)
+	assert.Len(t, items, 1)
+
+	tests := map[string]string{
+		"$.data.none":     `key "none" not found`,
+		"$.data.pages[5]": "index 5 out of range",
+		"$.total":         `expect "["`,
+		"$.data[":         "invalid JSON path",
+	}
+	for path, want := range tests {
+		resp, _ = mockStreamResp(t, "application/json", body)
+		_, errs = collectItems(greq.DecodeStreamAt[streamItem](resp, path))
+		assert.Len(t, errs, 1, path)
+		assert.ErrMsgContains(t, errs[0], want, path)
+	}
+}
@@ -0,0 +1,18 @@
+#!/bin/sh
+# generate the TLS fixtures for tls_test.go
+set -e
+cd "$(dirname "$0")"
+
+openssl req -x509 -newkey ec -pkeyopt ec_paramgen_curve:P-256 -nodes -days 36500 \
+  -subj "/CN=greq test CA" -keyout ca-key.pem -out ca.pem
+openssl req -newkey ec -pkeyopt ec_paramgen_curve:P-256 -nodes \
+  -subj "/CN=greq client" -keyout client-key.pem -out client.csr
+openssl x509 -req -in client.csr -CA ca.pem -CAkey ca-key.pem -CAcreateserial -days 36500 \
+  -extfile /dev/stdin -out client.pem <<EXT
+extendedKeyUsage = clientAuth
+EXT
+
+# legacy algorithms(3DES, SHA-1) and the default algorithms of OpenSSL 3.x(AES-256, SHA-256)
+openssl pkcs12 -export -legacy -in client.pem -inkey client-key.pem -passout pass:secret -out client.p12
+openssl pkcs12 -export -in client.pem -inkey client-key.pem -passout pass:secret -out client-aes.p12
+rm -f client.csr ca.srl ca-key.pem
@@ -0,0 +1,181 @@
+package greq
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// TimePoints the raw time points of a request attempt, zero if not happened.
+type TimePoints struct {
+	Start        time.Time
+	GetConn      time.Time
+	DNSStart     time.Time
+	DNSDone      time.Time
+	ConnectStart time.Time
+	ConnectDone  time.Time
+	TLSStart     time.Time
+	TLSDone      time.Time
+	GotConn      time.Time
+	WroteRequest time.Time
+	FirstByte    time.Time
+	// BodyDone the time of the response body read to EOF or closed.
+	BodyDone time.Time
+}
+
+// Timings of a request attempt, collected by the net/http/httptrace.
+//
+// The phase durations are zero if not happened, eg: DNS lookup and connect on a reused connection.
+// On redirects, the phases are of the last hop, Total is from the first request start.
+type Timings struct {
+	DNSLookup    time.Duration
+	TCPConnect   time.Duration
+	TLSHandshake time.Duration
+	// ServerProcessing from the request written to the first response byte
+	ServerProcessing time.Duration
+	// TTFB time to first response byte, from the request start.
+	TTFB time.Duration
+	// ContentTransfer from the first response byte to the body read done.
+	ContentTransfer time.Duration
+	// Total from the request start to the body read done.
+	// If the body is not read done, it's same as TTFB.
+	Total time.Duration
+
+	// Reused whether the connection was reused
+	Reused bool
+	// RemoteAddr the remote address of the connection. eg: "127.0.0.1:8080"
+	RemoteAddr string
+	// Points the raw time points
+	Points TimePoints
+}
+
+// Timings get the phase timings of the request. see Timings for details.
+//
+// NOTE: on a custom Doer without network(eg: mocks), only TTFB and Total are set.
+func (r *Response) Timings() Timings {
+	if r.trace == nil {
+		return Timings{}
+	}
+	return r.trace.timings()
+}
+
+// timingTrace collect the time points by httptrace
+type timingTrace struct {
+	mu     sync.Mutex
+	pt     TimePoints
+	reused bool
+	remote string
+	// respAt the time of doer returned, fallback of FirstByte
+	respAt time.Time
+}
+
+func newTimingTrace() *timingTrace {
+	return &timingTrace{pt: TimePoints{Start: time.Now()}}
+}
+
+func (tt *timingTrace) mark(t *time.Time) {
+	tt.mu.Lock()
+	// keep the first time, eg: multi dial on happy eyeballs
+	if t.IsZero() {
+		*t = time.Now()
+	}
+	tt.mu.Unlock()
+}
+
+// withTrace bind the client trace to the request context
+func (tt *timingTrace) withTrace(r *http.Request) *http.Request {
+	ct := &httptrace.ClientTrace{
+		GetConn: func(string) {
+			tt.mu.Lock()
+			// new hop on redirect: reset the phases, keep the start
+			if !tt.pt.GotConn.IsZero() {
+				tt.pt = TimePoints{Start: tt.pt.Start}
+			}
+			tt.pt.GetConn = time.Now()
+			tt.mu.Unlock()
+		},
+		DNSStart:          func(httptrace.DNSStartInfo) { tt.mark(&tt.pt.DNSStart) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { tt.mark(&tt.pt.DNSDone) },
+		ConnectStart:      func(string, string) { tt.mark(&tt.pt.ConnectStart) },
+		ConnectDone:       func(string, string, error) { tt.mark(&tt.pt.ConnectDone) },
+		TLSHandshakeStart: func() { tt.mark(&tt.pt.TLSStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { tt.mark(&tt.pt.TLSDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			tt.mark(&tt.pt.GotConn)
+			tt.mu.Lock()
+			tt.reused = info.Reused
+			if info.Conn != nil {
+				tt.remote = info.Conn.RemoteAddr().String()
+			}
+			tt.mu.Unlock()
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { tt.mark(&tt.pt.WroteRequest) },
+		GotFirstResponseByte: func() { tt.mark(&tt.pt.FirstByte) },
+	}
+	return r.WithContext(httptrace.WithClientTrace(r.Context(), ct))
+}
+
+// gotResponse mark the response returned, and wrap the body for mark the read done.
+func (tt *timingTrace) gotResponse(resp *http.Response) {
+	tt.mark(&tt.respAt)
+	// keep the body as is on protocol switch, it's an io.ReadWriteCloser
+	if resp.Body == nil || resp.Body == http.NoBody || resp.StatusCode == http.StatusSwitchingProtocols {
+		tt.mark(&tt.pt.BodyDone)
+		return
+	}
+	resp.Body = &timingBody{ReadCloser: resp.Body, tt: tt}
+}
+
+func (tt *timingTrace) timings() Timings {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	pt := tt.pt
+	tm := Timings{Reused: tt.reused, RemoteAddr: tt.remote, Points: pt}
+	firstByte := pt.FirstByte
+	if firstByte.IsZero() {
+		firstByte = tt.respAt
+	}
+
+	tm.DNSLookup = sub(pt.DNSStart, pt.DNSDone)
+	tm.TCPConnect = sub(pt.ConnectStart, pt.ConnectDone)
+	tm.TLSHandshake = sub(pt.TLSStart, pt.TLSDone)
+	tm.ServerProcessing = sub(pt.WroteRequest, firstByte)
+	tm.TTFB = sub(pt.Start, firstByte)
+	tm.ContentTransfer = sub(firstByte, pt.BodyDone)
+	if pt.BodyDone.IsZero() {
+		tm.Total = tm.TTFB
+	} else {
+		tm.Total = sub(pt.Start, pt.BodyDone)
+	}
+	return tm
+}
+
+func sub(from, to time.Time) time.Duration {
+	if from.IsZero() || to.IsZero() {
+		return 0
+	}
+	return to.Sub(from)
+}
+
+// timingBody mark the body read done on EOF or close
+type timingBody struct {
+	io.ReadCloser
+	tt *timingTrace
+}
+
+func (b *timingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.tt.mark(&b.tt.pt.BodyDone)
+	}
+	return n, err
+}
+
+func (b *timingBody) Close() error {
+	b.tt.mark(&b.tt.pt.BodyDone)
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,68 @@
+package greq_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq"
+)
+
+func TestResponse_Timings(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(200)
+		w.(http.Flusher).Flush()
+		time.Sleep(10 * time.Millisecond)
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer ts.Close()
+
+	client := greq.New(ts.URL)
+	resp, err := client.GetDo("/hello")
+	assert.NoErr(t, err)
+
+	// body not read
+	tm := resp.Timings()
+	assert.False(t, tm.Reused)
+	assert.Eq(t, strings.TrimPrefix(ts.URL, "http://"), tm.RemoteAddr)
+	assert.True(t, tm.TTFB >= 20*time.Millisecond)
+	assert.Eq(t, tm.TTFB, tm.Total)
+	assert.Eq(t, time.Duration(0), tm.ContentTransfer)
+	assert.True(t, tm.TCPConnect > 0)
+	assert.True(t, tm.ServerProcessing > 0)
+	assert.Eq(t, time.Duration(0), tm.TLSHandshake)
+
+	assert.Eq(t, "hello", resp.BodyString())
+	tm = resp.Timings()
+	assert.True(t, tm.ContentTransfer >= 10*time.Millisecond)
+	assert.Eq(t, tm.TTFB+tm.ContentTransfer, tm.Total)
+	assert.False(t, tm.Points.BodyDone.IsZero())
+
+	// reused connection
+	resp, err = client.GetDo("/hello")
+	assert.NoErr(t, err)
+	resp.QuietCloseBody()
+	tm = resp.Timings()
+	assert.True(t, tm.Reused)
+	assert.Eq(t, time.Duration(0), tm.TCPConnect)
+
+	// without network
+	resp, err = greq.New().Doer(httpDoerFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 204, Body: http.NoBody, Request: r}, nil
+	})).GetDo("http://mock.local/")
+	assert.NoErr(t, err)
+	tm = resp.Timings()
+	assert.True(t, tm.TTFB > 0)
+	assert.True(t, tm.Total >= tm.TTFB)
+	assert.Empty(t, tm.RemoteAddr)
+
+	assert.Eq(t, greq.Timings{}, greq.NewResponse(&http.Response{}, nil).Timings())
+}
+
+type httpDoerFunc func(r *http.Request) (*http.Response, error)
+
+func (fn httpDoerFunc) Do(r *http.Request) (*http.Response, error) { return fn(r) }
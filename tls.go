@@ -0,0 +1,332 @@
+package greq
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// ErrPinMismatch the public key of the server certificate does not match any pinned key.
+//
+// Check it by errors.Is(err, greq.ErrPinMismatch)
+var ErrPinMismatch = errors.New("greq: TLS public key pin mismatch")
+
+// TLSOption config the tls.Config for Client.WithTLS
+type TLSOption func(c *tls.Config) error
+
+// WithTLS config the TLS of the transport by options. the options are applied on a copy of the current config.
+//
+// Usage:
+//
+//	h.WithTLS(
+//		greq.TLSClientCert("client.pem", "client-key.pem"),
+//		greq.TLSRootCAs("/etc/ssl/private-ca/"),
+//		greq.TLSMinVersion(tls.VersionTLS12),
+//		greq.TLSPinnedPubKeys("sha256//AbC...="),
+//	)
+//
+// If an option fails(eg: the cert file not exists), none of the options are applied and the error
+// will be returned on send request, until WithTLS is called again with valid options.
+//
+// The pins of TLSPinnedPubKeys replace the pins set by an earlier WithTLS call(eg: rotate the pins),
+// the earlier pins are kept if the options do not set new pins.
+//
+// NOTE: only works when the doer is *http.Client.
+func (h *Client) WithTLS(opts ...TLSOption) *Client {
+	var errs []error
+	h.ConfigTransport(func(ht *http.Transport) {
+		cfg := &tls.Config{}
+		if ht.TLSClientConfig != nil {
+			cfg = ht.TLSClientConfig.Clone()
+		}
+		// remove the pin verifier, it is installed again after the options applied
+		if h.pins != nil {
+			cfg.VerifyConnection = h.pins.base
+		}
+
+		slot := &pinSlot{}
+		pinSlots.Store(cfg, slot)
+		for _, opt := range opts {
+			if err := opt(cfg); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		pinSlots.Delete(cfg)
+
+		// keep the current config on error
+		if len(errs) > 0 {
+			return
+		}
+
+		hashes := slot.hashes
+		if hashes == nil && h.pins != nil {
+			hashes = h.pins.hashes
+		}
+		if hashes != nil {
+			h.pins = &pinVerifier{base: cfg.VerifyConnection, hashes: hashes}
+			cfg.VerifyConnection = h.pins.verify
+		}
+		ht.TLSClientConfig = cfg
+	})
+
+	if len(errs) > 0 {
+		h.tlsErr = fmt.Errorf("greq: invalid TLS config: %w", errors.Join(errs...))
+	} else {
+		h.tlsErr = nil
+	}
+	return h
+}
+
+// TLSClientCert load the client certificate and private key from PEM files.
+// keyFile can be empty if the certFile contains the private key.
+func TLSClientCert(certFile, keyFile string) TLSOption {
+	return func(c *tls.Config) error {
+		certPEM, err := os.ReadFile(certFile)
+		if err != nil {
+			return err
+		}
+
+		keyPEM := certPEM
+		if keyFile != "" {
+			if keyPEM, err = os.ReadFile(keyFile); err != nil {
+				return err
+			}
+		}
+		return TLSClientCertPEM(certPEM, keyPEM)(c)
+	}
+}
+
+// TLSClientCertPEM add the client certificate and private key in PEM format.
+func TLSClientCertPEM(certPEM, keyPEM []byte) TLSOption {
+	return func(c *tls.Config) error {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return err
+		}
+		c.Certificates = append(c.Certificates, cert)
+		return nil
+	}
+}
+
+// TLSPKCS12 load the client certificate, private key and the CA chain from a PKCS#12(.p12, .pfx) file.
+// Both the legacy(eg: 3DES) and the modern(eg: AES-256 by OpenSSL 3.x) encryption algorithms are supported.
+func TLSPKCS12(file, password string) TLSOption {
+	return func(c *tls.Config) error {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		key, leaf, chain, err := pkcs12.DecodeChain(data, password)
+		if err != nil {
+			return fmt.Errorf("decode PKCS#12 file %q: %w", file, err)
+		}
+
+		cert := tls.Certificate{Certificate: [][]byte{leaf.Raw}, PrivateKey: key, Leaf: leaf}
+		for _, ca := range chain {
+			cert.Certificate = append(cert.Certificate, ca.Raw)
+		}
+		c.Certificates = append(c.Certificates, cert)
+		return nil
+	}
+}
+
+// TLSRootCAs add the root CAs from PEM files or directories, the system CAs are kept.
+// In the directory, the files with ext .pem, .crt, .cer will be loaded.
+func TLSRootCAs(paths ...string) TLSOption {
+	return func(c *tls.Config) error {
+		pool := c.RootCAs
+		if pool == nil {
+			var err error
+			if pool, err = x509.SystemCertPool(); err != nil {
+				pool = x509.NewCertPool()
+			}
+		} else {
+			pool = pool.Clone()
+		}
+
+		for _, path := range paths {
+			files, err := caFiles(path)
+			if err != nil {
+				return err
+			}
+			for _, file := range files {
+				bs, err := os.ReadFile(file)
+				if err != nil {
+					return err
+				}
+				if !pool.AppendCertsFromPEM(bs) {
+					return fmt.Errorf("no valid certificate in the CA file %q", file)
+				}
+			}
+		}
+
+		c.RootCAs = pool
+		return nil
+	}
+}
+
+func caFiles(path string) ([]string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, ent := range entries {
+		switch strings.ToLower(filepath.Ext(ent.Name())) {
+		case ".pem", ".crt", ".cer":
+			if !ent.IsDir() {
+				files = append(files, filepath.Join(path, ent.Name()))
+			}
+		}
+	}
+	return files, nil
+}
+
+// TLSMinVersion set the minimum TLS version. eg: tls.VersionTLS12
+func TLSMinVersion(version uint16) TLSOption {
+	return func(c *tls.Config) error {
+		c.MinVersion = version
+		return nil
+	}
+}
+
+// TLSCiphers set the cipher suites for TLS 1.0-1.2. eg: tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256
+//
+// NOTE: the TLS 1.3 cipher suites are not configurable.
+func TLSCiphers(ids ...uint16) TLSOption {
+	return func(c *tls.Config) error {
+		c.CipherSuites = ids
+		return nil
+	}
+}
+
+// TLSServerName override the server name for SNI and certificate verification.
+func TLSServerName(name string) TLSOption {
+	return func(c *tls.Config) error {
+		c.ServerName = name
+		return nil
+	}
+}
+
+// TLSPinnedPubKeys pin the public key of the server leaf certificate, like curl --pinnedpubkey.
+//
+// The pin format:
+//
+//   - "sha256//<base64 of the SHA256 hash of the SPKI>", allow multi pins separated by ";"
+//   - path of a public key file in PEM or DER format
+//
+// The connection will fail with ErrPinMismatch if the server public key does not match any pin.
+// Generate the pin of a cert:
+//
+//	openssl x509 -in cert.pem -pubkey -noout | openssl pkey -pubin -outform der | openssl dgst -sha256 -binary | base64
+func TLSPinnedPubKeys(pins ...string) TLSOption {
+	return func(c *tls.Config) error {
+		hashes := make(map[string]bool)
+		for _, s := range pins {
+			for _, pin := range strings.Split(s, ";") {
+				if pin = strings.TrimSpace(pin); pin == "" {
+					continue
+				}
+				hash, err := parsePin(pin)
+				if err != nil {
+					return err
+				}
+				hashes[hash] = true
+			}
+		}
+		if len(hashes) == 0 {
+			return nil
+		}
+
+		// applied by WithTLS, the pins are installed by it
+		if v, ok := pinSlots.Load(c); ok {
+			v.(*pinSlot).hashes = hashes
+			return nil
+		}
+
+		pv := &pinVerifier{base: c.VerifyConnection, hashes: hashes}
+		c.VerifyConnection = pv.verify
+		return nil
+	}
+}
+
+// pinSlots the pins set by TLSPinnedPubKeys in a WithTLS call. key is the *tls.Config being configured.
+var pinSlots sync.Map
+
+type pinSlot struct {
+	hashes map[string]bool
+}
+
+// pinVerifier verify the server public key by the pins, after the base verifier.
+type pinVerifier struct {
+	base   func(cs tls.ConnectionState) error
+	hashes map[string]bool
+}
+
+func (p *pinVerifier) verify(cs tls.ConnectionState) error {
+	if p.base != nil {
+		if err := p.base(cs); err != nil {
+			return err
+		}
+	}
+	if len(cs.PeerCertificates) == 0 {
+		return fmt.Errorf("%w: no server certificate", ErrPinMismatch)
+	}
+
+	got := SPKIPin(cs.PeerCertificates[0])
+	if !p.hashes[got] {
+		return fmt.Errorf("%w: got %s for %q", ErrPinMismatch, got, cs.ServerName)
+	}
+	return nil
+}
+
+// SPKIPin get the pin of the certificate public key. format: "sha256//<base64>"
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "sha256//" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// parsePin parse the pin or public key file to the "sha256//<base64>" format
+func parsePin(pin string) (string, error) {
+	if b64, ok := strings.CutPrefix(pin, "sha256//"); ok {
+		if bs, err := base64.StdEncoding.DecodeString(b64); err != nil || len(bs) != sha256.Size {
+			return "", fmt.Errorf("invalid pinned public key %q", pin)
+		}
+		return pin, nil
+	}
+
+	der, err := os.ReadFile(pin)
+	if err != nil {
+		return "", err
+	}
+	if b, _ := pem.Decode(der); b != nil {
+		der = b.Bytes
+	}
+	if _, err = x509.ParsePKIXPublicKey(der); err != nil {
+		return "", fmt.Errorf("invalid public key file %q: %w", pin, err)
+	}
+
+	sum := sha256.Sum256(der)
+	return "sha256//" + base64.StdEncoding.EncodeToString(sum[:]), nil
+}
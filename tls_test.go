@@ -0,0 +1,152 @@
+package greq_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq"
+)
+
+// writeServerCA write the test server cert as a CA file
+func writeServerCA(t *testing.T, ts *httptest.Server) string {
+	file := filepath.Join(t.TempDir(), "server.crt")
+	bs := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	assert.NoErr(t, os.WriteFile(file, bs, 0644))
+	return file
+}
+
+func newMTLSServer(t *testing.T) *httptest.Server {
+	caPEM, err := os.ReadFile("testdata/tls/ca.pem")
+	assert.NoErr(t, err)
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(caPEM)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello " + r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestClient_WithTLS_rootCAs(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	// unknown authority
+	_, err := greq.New(ts.URL).GetDo("/")
+	assert.Err(t, err)
+
+	caFile := writeServerCA(t, ts)
+	for _, path := range []string{caFile, filepath.Dir(caFile)} {
+		client := greq.New(ts.URL).WithTLS(greq.TLSRootCAs(path), greq.TLSMinVersion(tls.VersionTLS12))
+		resp, err := client.GetDo("/")
+		assert.NoErr(t, err)
+		assert.Eq(t, "ok", resp.BodyString())
+		assert.Eq(t, uint16(tls.VersionTLS13), resp.TLS.Version)
+	}
+
+	// SNI override: the httptest cert is valid for example.com
+	var gotSNI string
+	ts2 := httptest.NewUnstartedServer(ts.Config.Handler)
+	ts2.TLS = &tls.Config{GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		gotSNI = hello.ServerName
+		return nil, nil
+	}}
+	ts2.StartTLS()
+	defer ts2.Close()
+
+	client := greq.New(ts2.URL).WithTLS(greq.TLSRootCAs(writeServerCA(t, ts2)), greq.TLSServerName("example.com"))
+	_, err = client.GetDo("/")
+	assert.NoErr(t, err)
+	assert.Eq(t, "example.com", gotSNI)
+
+	// errors on send
+	client = greq.New(ts.URL).WithTLS(greq.TLSRootCAs("not-exists.pem"))
+	_, err = client.GetDo("/")
+	assert.ErrMsgContains(t, err, "invalid TLS config")
+	_, err = client.Sub().GetDo("/")
+	assert.Err(t, err)
+
+	// recover by the valid options
+	resp, err := client.WithTLS(greq.TLSRootCAs(caFile)).GetDo("/")
+	assert.NoErr(t, err)
+	assert.Eq(t, "ok", resp.BodyString())
+}
+
+func TestClient_WithTLS_clientCert(t *testing.T) {
+	ts := newMTLSServer(t)
+	caFile := writeServerCA(t, ts)
+
+	// no client cert
+	_, err := greq.New(ts.URL).WithTLS(greq.TLSRootCAs(caFile)).GetDo("/")
+	assert.Err(t, err)
+
+	tests := map[string]greq.TLSOption{
+		"pem":    greq.TLSClientCert("testdata/tls/client.pem", "testdata/tls/client-key.pem"),
+		"pkcs12": greq.TLSPKCS12("testdata/tls/client.p12", "secret"),
+		// AES-256 and SHA-256 MAC
+		"pkcs12-aes": greq.TLSPKCS12("testdata/tls/client-aes.p12", "secret"),
+	}
+	for name, opt := range tests {
+		client := greq.New(ts.URL).WithTLS(greq.TLSRootCAs(caFile)).WithTLS(opt)
+		resp, err := client.GetDo("/")
+		assert.NoErr(t, err, name)
+		assert.Eq(t, "hello greq client", resp.BodyString(), name)
+	}
+
+	_, err = greq.New(ts.URL).WithTLS(greq.TLSPKCS12("testdata/tls/client.p12", "wrong")).GetDo("/")
+	assert.ErrMsgContains(t, err, "decode PKCS#12")
+}
+
+func TestClient_WithTLS_pinnedPubKeys(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+	caFile := writeServerCA(t, ts)
+	pin := greq.SPKIPin(ts.Certificate())
+
+	client := greq.New(ts.URL).WithTLS(greq.TLSRootCAs(caFile), greq.TLSPinnedPubKeys("sha256//AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=;"+pin))
+	resp, err := client.GetDo("/")
+	assert.NoErr(t, err)
+	assert.Eq(t, "ok", resp.BodyString())
+
+	// public key file
+	pubDER, err := x509.MarshalPKIXPublicKey(ts.Certificate().PublicKey)
+	assert.NoErr(t, err)
+	pubFile := filepath.Join(t.TempDir(), "pub.pem")
+	assert.NoErr(t, os.WriteFile(pubFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0644))
+	_, err = greq.New(ts.URL).WithTLS(greq.TLSRootCAs(caFile), greq.TLSPinnedPubKeys(pubFile)).GetDo("/")
+	assert.NoErr(t, err)
+
+	// mismatch, also checked on skip verify
+	client = greq.New(ts.URL).WithInsecureSkipVerify(true).
+		WithTLS(greq.TLSPinnedPubKeys("sha256//AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="))
+	_, err = client.GetDo("/")
+	assert.True(t, errors.Is(err, greq.ErrPinMismatch))
+	assert.ErrMsgContains(t, err, "got "+pin)
+
+	// rotate the pins, the new pins replace the old
+	_, err = client.WithTLS(greq.TLSPinnedPubKeys(pin)).GetDo("/")
+	assert.NoErr(t, err)
+	// other options keep the pins
+	_, err = client.WithTLS(greq.TLSMinVersion(tls.VersionTLS12)).GetDo("/")
+	assert.NoErr(t, err)
+	_, err = client.WithTLS(greq.TLSPinnedPubKeys("sha256//AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=")).GetDo("/")
+	assert.True(t, errors.Is(err, greq.ErrPinMismatch))
+
+	_, err = greq.New(ts.URL).WithTLS(greq.TLSPinnedPubKeys("sha256//invalid")).GetDo("/")
+	assert.ErrMsgContains(t, err, "invalid pinned public key")
+}
@@ -0,0 +1,110 @@
+package greq
+
+import (
+	"encoding/hex"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// unixHostSuffix the host suffix of the unix socket URL, the socket path is hex encoded in the host.
+// the ".localhost" TLD never resolves to an external host.
+const unixHostSuffix = ".unix.localhost"
+
+// maxLabelLen the max length of a DNS label
+const maxLabelLen = 63
+
+// WithUnixSocket send the requests to the hosts over the unix socket. eg: "/var/run/docker.sock"
+//
+// The hosts default is the host of the BaseURL, the requests to other hosts are dialed normally.
+// The BaseURL will be set to "http://localhost" if it's empty, so the request path can be used directly.
+// The unix socket URLs of the socket path can be used too, other sockets are never dialed.
+//
+//	h := greq.New().WithUnixSocket("/var/run/docker.sock")
+//	resp, err := h.GetDo("/v1.41/containers/json")
+//
+//	// the URLs with host "docker" are sent over the socket
+//	h = greq.New().WithUnixSocket("/var/run/docker.sock", "docker")
+//	resp, err = h.GetDo("http://docker/v1.41/info")
+//	resp, err = h.GetDo("unix:///var/run/docker.sock:/v1.41/info")
+//
+// NOTE: only works when the doer is *http.Client.
+func (h *Client) WithUnixSocket(socketPath string, hosts ...string) *Client {
+	if h.BaseURL == "" {
+		h.BaseURL = "http://localhost"
+	}
+	if len(hosts) == 0 {
+		if u, err := url.Parse(h.BaseURL); err == nil && u.Hostname() != "" {
+			hosts = []string{u.Hostname()}
+		}
+	}
+
+	// the host of the unix socket URLs, see unixSocketURL
+	hosts = append(slices.Clip(hosts), unixSocketHost(socketPath))
+
+	return h.configDialer(func(d *netDialer) {
+		d.unixSocket = socketPath
+		d.unixHosts = hosts
+	})
+}
+
+// unixSocketURL convert the unix socket URL to a http URL, the socket path is encoded in the host.
+// returns false if it's not a unix socket URL. The host is dialed only by Client.WithUnixSocket of the path.
+//
+// Supported formats:
+//
+//	unix:///var/run/docker.sock:/v1.41/containers/json
+//	http+unix://%2Fvar%2Frun%2Fdocker.sock/v1.41/containers/json
+func unixSocketURL(rawURL string) (string, bool) {
+	var sockPath, reqPath string
+	if rest, ok := strings.CutPrefix(rawURL, "unix://"); ok {
+		sockPath, reqPath, _ = strings.Cut(rest, ":")
+	} else if rest, ok = strings.CutPrefix(rawURL, "http+unix://"); ok {
+		host := rest
+		if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+			host, reqPath = rest[:i], rest[i:]
+		}
+
+		var err error
+		if sockPath, err = url.PathUnescape(host); err != nil {
+			return rawURL, false
+		}
+	} else {
+		return rawURL, false
+	}
+
+	if !strings.HasPrefix(reqPath, "/") {
+		reqPath = "/" + reqPath
+	}
+	return "http://" + unixSocketHost(sockPath) + reqPath, true
+}
+
+// unixSocketHost encode the socket path to the host. the hex string is split to
+// labels of at most 63 chars, so it's a valid hostname.
+func unixSocketHost(sockPath string) string {
+	enc := hex.EncodeToString([]byte(sockPath))
+
+	var sb strings.Builder
+	for len(enc) > maxLabelLen {
+		sb.WriteString(enc[:maxLabelLen])
+		sb.WriteByte('.')
+		enc = enc[maxLabelLen:]
+	}
+	sb.WriteString(enc)
+	sb.WriteString(unixHostSuffix)
+	return sb.String()
+}
+
+// unixSocketPath decode the socket path from the host of the URL made by unixSocketURL
+func unixSocketPath(host string) (string, bool) {
+	enc, ok := strings.CutSuffix(host, unixHostSuffix)
+	if !ok {
+		return "", false
+	}
+
+	bs, err := hex.DecodeString(strings.ReplaceAll(enc, ".", ""))
+	if err != nil || len(bs) == 0 {
+		return "", false
+	}
+	return string(bs), true
+}
@@ -0,0 +1,158 @@
+package greq_test
+
+import (
+	"encoding/hex"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq"
+)
+
+func newUnixServer(t *testing.T, dirPattern ...string) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix socket is not supported")
+	}
+
+	pattern := "greq"
+	if len(dirPattern) > 0 {
+		pattern = dirPattern[0]
+	}
+
+	// the socket path length is limited, not use t.TempDir()
+	dir, err := os.MkdirTemp("", pattern)
+	assert.NoErr(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	sockPath := filepath.Join(dir, "api.sock")
+	ln, err := net.Listen("unix", sockPath)
+	assert.NoErr(t, err)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Host", r.Host)
+		_, _ = w.Write([]byte(r.Method + " " + r.URL.RequestURI()))
+	}))
+	ts.Listener = ln
+	ts.Start()
+	t.Cleanup(ts.Close)
+	return sockPath
+}
+
+func TestClient_unixSocketURL(t *testing.T) {
+	sockPath := newUnixServer(t)
+	client := greq.New().WithUnixSocket(sockPath)
+
+	for _, u := range []string{
+		"unix://" + sockPath + ":/v1.41/containers/json?all=1",
+		"http+unix://" + url.PathEscape(sockPath) + "/v1.41/containers/json?all=1",
+	} {
+		resp, err := client.GetDo(u)
+		assert.NoErr(t, err, u)
+		assert.Eq(t, "GET /v1.41/containers/json?all=1", resp.BodyString())
+		assert.Eq(t, "localhost", resp.Header.Get("X-Host"))
+	}
+
+	// empty path
+	resp, err := client.PostDo("unix://"+sockPath, greq.WithBody("hi"))
+	assert.NoErr(t, err)
+	assert.Eq(t, "POST /", resp.BodyString())
+
+	// not works with proxy
+	resp, err = greq.New().WithUnixSocket(sockPath).WithProxy("http://127.0.0.1:1").GetDo("unix://" + sockPath + ":/ping")
+	assert.NoErr(t, err)
+	assert.Eq(t, "GET /ping", resp.BodyString())
+
+	// the socket must be set by WithUnixSocket
+	_, err = client.GetDo("unix:///not-exists.sock:/ping")
+	assert.ErrMsgContains(t, err, "unix socket /not-exists.sock is not set by Client.WithUnixSocket")
+	_, err = greq.New().GetDo("unix://" + sockPath + ":/ping")
+	assert.ErrMsgContains(t, err, "is not set by Client.WithUnixSocket")
+}
+
+func TestClient_unixSocketURL_longPath(t *testing.T) {
+	sockPath := newUnixServer(t, "greq-long-socket-dir-name-for-dns-labels")
+	assert.True(t, len(sockPath) > 31)
+
+	var host string
+	client := greq.New().WithUnixSocket(sockPath).Use(greq.MiddleFunc(func(r *http.Request, next greq.HandleFunc) (*greq.Response, error) {
+		host = r.URL.Hostname()
+		return next(r)
+	}))
+	resp, err := client.GetDo("unix://" + sockPath + ":/ping")
+	assert.NoErr(t, err)
+	assert.Eq(t, "GET /ping", resp.BodyString())
+
+	// each label of the host is valid
+	assert.StrContains(t, host, ".unix.localhost")
+	for _, label := range strings.Split(host, ".") {
+		assert.True(t, len(label) > 0 && len(label) <= 63, label)
+	}
+}
+
+func TestClient_unixSocketURL_redirect(t *testing.T) {
+	sockPath := newUnixServer(t)
+	sockURL := "http://" + hex.EncodeToString([]byte(sockPath)) + ".unix.localhost/ping"
+	ts := httptest.NewServer(http.RedirectHandler(sockURL, http.StatusFound))
+	defer ts.Close()
+
+	// the socket host of the redirect target is not dialed
+	resp, err := greq.New().GetDo(ts.URL)
+	assert.Err(t, err)
+	assert.Nil(t, resp)
+
+	resp, err = greq.New().WithUnixSocket(filepath.Join(filepath.Dir(sockPath), "other.sock")).GetDo(ts.URL)
+	assert.Err(t, err)
+	assert.Nil(t, resp)
+}
+
+func TestClient_WithUnixSocket(t *testing.T) {
+	sockPath := newUnixServer(t)
+
+	client := greq.New().WithUnixSocket(sockPath)
+	assert.Eq(t, "http://localhost", client.BaseURL)
+
+	resp, err := client.GetDo("/v1.41/info")
+	assert.NoErr(t, err)
+	assert.Eq(t, "GET /v1.41/info", resp.BodyString())
+
+	resp, err = client.Sub().DeleteDo("http://localhost/containers/abc")
+	assert.NoErr(t, err)
+	assert.Eq(t, "DELETE /containers/abc", resp.BodyString())
+	assert.Eq(t, "localhost", resp.Header.Get("X-Host"))
+
+	// the placeholder host
+	resp, err = greq.New().WithUnixSocket(sockPath, "docker").GetDo("http://docker/containers/json")
+	assert.NoErr(t, err)
+	assert.Eq(t, "GET /containers/json", resp.BodyString())
+	assert.Eq(t, "docker", resp.Header.Get("X-Host"))
+
+	// keep the base URL, the requests to its host dial the socket
+	client = greq.New().WithBaseURL("http://127.0.0.1:1").WithUnixSocket(sockPath)
+	assert.Eq(t, "http://127.0.0.1:1", client.BaseURL)
+	_, err = client.GetDo("/info")
+	assert.NoErr(t, err)
+
+	// other hosts are dialed normally
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("tcp " + r.URL.Path))
+	}))
+	defer ts.Close()
+	resp, err = greq.New().WithUnixSocket(sockPath).GetDo(ts.URL + "/other")
+	assert.NoErr(t, err)
+	assert.Eq(t, "tcp /other", resp.BodyString())
+
+	// the net policy is checked for other hosts
+	client = greq.New().WithUnixSocket(sockPath).WithNetPolicy(&greq.NetPolicy{})
+	resp, err = client.GetDo("/info")
+	assert.NoErr(t, err)
+	assert.Eq(t, "GET /info", resp.BodyString())
+	_, err = client.GetDo(ts.URL + "/other")
+	assert.ErrIs(t, err, greq.ErrForbiddenDestination)
+}
@@ -0,0 +1,355 @@
+package greq
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrWSHandshake the WebSocket opening handshake is failed.
+var ErrWSHandshake = errors.New("greq: websocket handshake failed")
+
+// the GUID for compute the Sec-WebSocket-Accept. see RFC 6455 section 1.3
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// DefaultWSMaxMessageSize the default max size of a received WebSocket message
+const DefaultWSMaxMessageSize = 16 << 20
+
+// WSOption the option func for the WebSocket connection
+type WSOption func(c *wsConfig)
+
+type wsConfig struct {
+	subprotocols []string
+	compress     bool
+	pingInterval time.Duration
+	maxMsgSize   int64
+	closeTimeout time.Duration
+	optFns       []OptionFn
+}
+
+func newWSConfig(opts []WSOption) *wsConfig {
+	cfg := &wsConfig{maxMsgSize: DefaultWSMaxMessageSize, closeTimeout: 5 * time.Second}
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	return cfg
+}
+
+// WSSubprotocols set the subprotocols. On the client side, they are offered to the server by
+// the Sec-WebSocket-Protocol header; on the server side, they are the supported subprotocols.
+func WSSubprotocols(protocols ...string) WSOption {
+	return func(c *wsConfig) { c.subprotocols = protocols }
+}
+
+// WSCompression enable the permessage-deflate extension(RFC 7692), it's used if the peer supports it.
+func WSCompression() WSOption {
+	return func(c *wsConfig) { c.compress = true }
+}
+
+// WSPingInterval send ping frames in the interval to keep the connection alive.
+// The connection is closed if no frame received in 2 intervals.
+func WSPingInterval(d time.Duration) WSOption {
+	return func(c *wsConfig) { c.pingInterval = d }
+}
+
+// WSMaxMessageSize set the max size of a received message, default is DefaultWSMaxMessageSize. <= 0 for no limit.
+func WSMaxMessageSize(n int64) WSOption {
+	return func(c *wsConfig) { c.maxMsgSize = n }
+}
+
+// WSCloseTimeout set the max time to wait the close frame from the peer on Close, default is 5s.
+func WSCloseTimeout(d time.Duration) WSOption {
+	return func(c *wsConfig) { c.closeTimeout = d }
+}
+
+// WSRequestOptions set the request options for the handshake request. eg: headers, context, timeout.
+func WSRequestOptions(optFns ...OptionFn) WSOption {
+	return func(c *wsConfig) { c.optFns = append(c.optFns, optFns...) }
+}
+
+// the context key for capture the upgraded connection from the core handler
+type upgradeKey struct{}
+
+type upgradeHolder struct {
+	conn io.ReadWriteCloser
+}
+
+// WebSocket open a WebSocket connection by the opening handshake(RFC 6455).
+//
+// The path is resolved like other requests, "ws://" and "wss://" are same as "http://" and "https://".
+// The handshake request shares the client config: base URL, headers, middlewares(eg: auth), proxy and TLS.
+// The Client.Timeout only limits the handshake, not the connection. Disable it by
+// WSRequestOptions(greq.WithNoTimeout()).
+//
+// Usage:
+//
+//	conn, err := greq.New("wss://example.com").WebSocket("/chat", greq.WSCompression())
+//	if err != nil {
+//		return err
+//	}
+//	defer conn.Close()
+//
+//	err = conn.WriteText("hello")
+//	typ, data, err := conn.ReadMessage()
+func (h *Client) WebSocket(path string, opts ...WSOption) (*WSConn, error) {
+	cfg := newWSConfig(opts)
+	opt := NewOpt2(cfg.optFns, http.MethodGet)
+	defer func() {
+		if opt.TCancelFn != nil {
+			opt.TCancelFn()
+		}
+	}()
+
+	ctx := opt.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	hd := &upgradeHolder{}
+	opt.Context = context.WithValue(ctx, upgradeKey{}, hd)
+
+	req, err := h.NewRequestWithOptions(toHTTPScheme(path), opt)
+	if err != nil {
+		return nil, err
+	}
+	// the base URL maybe ws:// or wss://
+	switch req.URL.Scheme {
+	case "ws":
+		req.URL.Scheme = "http"
+	case "wss":
+		req.URL.Scheme = "https"
+	}
+
+	var keyBs [16]byte
+	if _, err = rand.Read(keyBs[:]); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(keyBs[:])
+
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	if len(cfg.subprotocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(cfg.subprotocols, ", "))
+	}
+	if cfg.compress {
+		req.Header.Set("Sec-WebSocket-Extensions", "permessage-deflate; client_no_context_takeover; server_no_context_takeover")
+	}
+
+	resp, err := h.sendRequestWithRetry(req, 0, h.effectiveRetryCfg(opt))
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := clientHandshake(resp, hd.conn, key, cfg)
+	if err != nil {
+		resp.QuietCloseBody()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// clientHandshake check the handshake response and create the connection
+func clientHandshake(resp *Response, rwc io.ReadWriteCloser, key string, cfg *wsConfig) (*WSConn, error) {
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, fmt.Errorf("%w: status %d", ErrWSHandshake, resp.StatusCode)
+	}
+	if !headerContains(resp.Header, "Upgrade", "websocket") {
+		return nil, fmt.Errorf("%w: invalid Upgrade header %q", ErrWSHandshake, resp.Header.Get("Upgrade"))
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != wsAcceptKey(key) {
+		return nil, fmt.Errorf("%w: invalid Sec-WebSocket-Accept", ErrWSHandshake)
+	}
+	if rwc == nil {
+		return nil, fmt.Errorf("%w: the connection can not be upgraded by the doer", ErrWSHandshake)
+	}
+
+	proto := resp.Header.Get("Sec-WebSocket-Protocol")
+	if proto != "" && !containsStr(cfg.subprotocols, proto) {
+		return nil, fmt.Errorf("%w: unexpected subprotocol %q", ErrWSHandshake, proto)
+	}
+
+	var compress bool
+	if ext := resp.Header.Get("Sec-WebSocket-Extensions"); ext != "" {
+		params, ok := parseDeflateExt(ext)
+		if !ok || !cfg.compress {
+			return nil, fmt.Errorf("%w: unexpected extensions %q", ErrWSHandshake, ext)
+		}
+		// the decompressor has no context, the server must not take over it
+		if !containsStr(params, "server_no_context_takeover") {
+			return nil, fmt.Errorf("%w: server_no_context_takeover is required", ErrWSHandshake)
+		}
+		compress = true
+	}
+
+	conn := newWSConn(rwc, nil, false, cfg)
+	conn.resp = resp
+	conn.subprotocol = proto
+	conn.compress = compress
+	return conn, nil
+}
+
+// UpgradeWebSocket upgrade the HTTP server connection to WebSocket, for the WebSocket server or tests.
+//
+// The subprotocol is the first one of the client offered that in WSSubprotocols, the permessage-deflate
+// is used if WSCompression is set and the client supports it.
+//
+// Usage:
+//
+//	http.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+//		conn, err := greq.UpgradeWebSocket(w, r)
+//		if err != nil {
+//			return
+//		}
+//		defer conn.Close()
+//		for {
+//			typ, data, err := conn.ReadMessage()
+//			if err != nil {
+//				return
+//			}
+//			_ = conn.WriteMessage(typ, data)
+//		}
+//	})
+func UpgradeWebSocket(w http.ResponseWriter, r *http.Request, opts ...WSOption) (*WSConn, error) {
+	cfg := newWSConfig(opts)
+	fail := func(msg string) (*WSConn, error) {
+		http.Error(w, msg, http.StatusBadRequest)
+		return nil, fmt.Errorf("%w: %s", ErrWSHandshake, msg)
+	}
+
+	if r.Method != http.MethodGet {
+		return fail("method must be GET")
+	}
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		return fail("not a websocket upgrade request")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return fail("unsupported websocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if bs, err := base64.StdEncoding.DecodeString(key); err != nil || len(bs) != 16 {
+		return fail("invalid Sec-WebSocket-Key")
+	}
+
+	var proto string
+	for _, p := range headerValues(r.Header, "Sec-WebSocket-Protocol") {
+		if containsStr(cfg.subprotocols, p) {
+			proto = p
+			break
+		}
+	}
+
+	var compress bool
+	if cfg.compress {
+		for _, ext := range headerValues(r.Header, "Sec-WebSocket-Extensions") {
+			if _, ok := parseDeflateExt(ext); ok {
+				compress = true
+				break
+			}
+		}
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return fail("the connection can not be hijacked")
+	}
+	nc, brw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	// clear the deadlines set by the server
+	_ = nc.SetDeadline(time.Time{})
+
+	var sb strings.Builder
+	sb.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	sb.WriteString("Sec-WebSocket-Accept: " + wsAcceptKey(key) + "\r\n")
+	if proto != "" {
+		sb.WriteString("Sec-WebSocket-Protocol: " + proto + "\r\n")
+	}
+	if compress {
+		sb.WriteString("Sec-WebSocket-Extensions: permessage-deflate; server_no_context_takeover; client_no_context_takeover\r\n")
+	}
+	sb.WriteString("\r\n")
+	if _, err = nc.Write([]byte(sb.String())); err != nil {
+		_ = nc.Close()
+		return nil, err
+	}
+
+	conn := newWSConn(nc, brw.Reader, true, cfg)
+	conn.subprotocol = proto
+	conn.compress = compress
+	return conn, nil
+}
+
+func wsAcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// toHTTPScheme convert the "ws://" and "wss://" URL to "http://" and "https://"
+func toHTTPScheme(url string) string {
+	if strings.HasPrefix(url, "ws://") {
+		return "http://" + url[5:]
+	}
+	if strings.HasPrefix(url, "wss://") {
+		return "https://" + url[6:]
+	}
+	return url
+}
+
+// parseDeflateExt parse the permessage-deflate extension, returns the params.
+func parseDeflateExt(ext string) ([]string, bool) {
+	for _, item := range strings.Split(ext, ",") {
+		parts := strings.Split(item, ";")
+		if strings.TrimSpace(parts[0]) != "permessage-deflate" {
+			continue
+		}
+
+		params := make([]string, 0, len(parts)-1)
+		for _, p := range parts[1:] {
+			params = append(params, strings.TrimSpace(p))
+		}
+		return params, true
+	}
+	return nil, false
+}
+
+// headerValues get the comma separated values of the header
+func headerValues(h http.Header, key string) []string {
+	var vs []string
+	for _, line := range h.Values(key) {
+		for _, v := range strings.Split(line, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				vs = append(vs, v)
+			}
+		}
+	}
+	return vs
+}
+
+// headerContains check the header has the value, case-insensitive
+func headerContains(h http.Header, key, value string) bool {
+	for _, v := range headerValues(h, key) {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsStr(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
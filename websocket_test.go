@@ -0,0 +1,249 @@
+package greq_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/greq"
+)
+
+// newEchoServer create a WebSocket server, it echoes the messages
+func newEchoServer(t *testing.T, opts ...greq.WSOption) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/plain" {
+			_, _ = w.Write([]byte("not websocket"))
+			return
+		}
+		if r.URL.Path == "/auth" && r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		conn, err := greq.UpgradeWebSocket(w, r, opts...)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			typ, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if string(data) == "bye" {
+				_ = conn.CloseWithCode(greq.WSCloseGoingAway, "bye")
+				return
+			}
+			assert.NoErr(t, conn.WriteMessage(typ, data))
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestClient_WebSocket(t *testing.T) {
+	ts := newEchoServer(t, greq.WSSubprotocols("chat.v2"))
+
+	// the base URL and middleware are shared
+	client := greq.New("ws" + strings.TrimPrefix(ts.URL, "http")).Use(greq.MiddleFunc(func(r *http.Request, next greq.HandleFunc) (*greq.Response, error) {
+		r.Header.Set("Authorization", "Bearer token")
+		return next(r)
+	}))
+	conn, err := client.WebSocket("/auth", greq.WSSubprotocols("chat.v1", "chat.v2"))
+	assert.NoErr(t, err)
+	assert.Eq(t, "chat.v2", conn.Subprotocol())
+	assert.False(t, conn.Compressed())
+	assert.Eq(t, http.StatusSwitchingProtocols, conn.Response().StatusCode)
+
+	assert.NoErr(t, conn.WriteText("hello"))
+	typ, data, err := conn.ReadMessage()
+	assert.NoErr(t, err)
+	assert.Eq(t, greq.WSText, typ)
+	assert.Eq(t, "hello", string(data))
+
+	// binary with 16-bit and 64-bit length
+	for _, size := range []int{300, 70000} {
+		msg := make([]byte, size)
+		for i := range msg {
+			msg[i] = byte(i)
+		}
+		assert.NoErr(t, conn.WriteMessage(greq.WSBinary, msg))
+		typ, data, err = conn.ReadMessage()
+		assert.NoErr(t, err)
+		assert.Eq(t, greq.WSBinary, typ)
+		assert.Eq(t, msg, data)
+	}
+
+	assert.NoErr(t, conn.Ping([]byte("ping")))
+	assert.NoErr(t, conn.Close())
+	assert.ErrIs(t, conn.WriteText("closed"), greq.ErrWSClosed)
+
+	// the client timeout only limits the handshake
+	for _, opt := range []greq.WSOption{greq.WSSubprotocols("chat.v2"), greq.WSRequestOptions(greq.WithNoTimeout())} {
+		conn, err = greq.New("ws"+strings.TrimPrefix(ts.URL, "http")).DefaultTimeout(50).WebSocket("/echo", opt)
+		assert.NoErr(t, err)
+		time.Sleep(80 * time.Millisecond)
+		assert.NoErr(t, conn.WriteText("later"))
+		_, data, err = conn.ReadMessage()
+		assert.NoErr(t, err)
+		assert.Eq(t, "later", string(data))
+		assert.NoErr(t, conn.Close())
+	}
+
+	// handshake failed
+	_, err = greq.New(ts.URL).WebSocket("/plain")
+	assert.ErrIs(t, err, greq.ErrWSHandshake)
+	assert.ErrMsgContains(t, err, "status 200")
+	_, err = greq.New(ts.URL).WebSocket("/auth")
+	assert.ErrMsgContains(t, err, "status 401")
+}
+
+func TestClient_WebSocket_compression(t *testing.T) {
+	ts := newEchoServer(t, greq.WSCompression())
+
+	conn, err := greq.New().WebSocket(ts.URL+"/", greq.WSCompression())
+	assert.NoErr(t, err)
+	defer conn.Close()
+	assert.True(t, conn.Compressed())
+
+	msg := strings.Repeat("compress me ", 1000)
+	for i := 0; i < 2; i++ {
+		assert.NoErr(t, conn.WriteText(msg))
+		_, data, err := conn.ReadMessage()
+		assert.NoErr(t, err)
+		assert.Eq(t, msg, string(data))
+	}
+
+	// the server not support it
+	ts = newEchoServer(t)
+	conn2, err := greq.New(ts.URL).WebSocket("/", greq.WSCompression())
+	assert.NoErr(t, err)
+	defer conn2.Close()
+	assert.False(t, conn2.Compressed())
+}
+
+func TestWSConn_close(t *testing.T) {
+	ts := newEchoServer(t, greq.WSMaxMessageSize(100))
+	client := greq.New(ts.URL)
+
+	// closed by server
+	conn, err := client.WebSocket("/")
+	assert.NoErr(t, err)
+	assert.NoErr(t, conn.WriteText("bye"))
+	_, _, err = conn.ReadMessage()
+	var cerr *greq.WSCloseError
+	assert.True(t, errors.As(err, &cerr))
+	assert.Eq(t, greq.WSCloseGoingAway, cerr.Code)
+	assert.Eq(t, "bye", cerr.Text)
+	// the error is sticky
+	_, _, err = conn.ReadMessage()
+	assert.ErrIs(t, err, cerr)
+	assert.NoErr(t, conn.Close())
+
+	// the message too big for server
+	conn, err = client.WebSocket("/")
+	assert.NoErr(t, err)
+	assert.NoErr(t, conn.WriteText(strings.Repeat("a", 200)))
+	_, _, err = conn.ReadMessage()
+	assert.True(t, errors.As(err, &cerr))
+	assert.Eq(t, greq.WSCloseTooBig, cerr.Code)
+	assert.NoErr(t, conn.Close())
+}
+
+func TestWSConn_closeBlockedWrite(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := greq.UpgradeWebSocket(w, r); err == nil {
+			// not read the messages
+			<-stop
+		}
+	}))
+	defer ts.Close()
+
+	conn, err := greq.New(ts.URL).WebSocket("/", greq.WSCloseTimeout(100*time.Millisecond))
+	assert.NoErr(t, err)
+
+	// the writer is blocked on the peer not reading
+	writeDone := make(chan struct{})
+	go func() {
+		defer close(writeDone)
+		data := make([]byte, 1<<20)
+		for conn.WriteMessage(greq.WSBinary, data) == nil {
+		}
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	start := time.Now()
+	_ = conn.Close()
+	assert.True(t, time.Since(start) < time.Second, time.Since(start))
+	<-writeDone
+}
+
+func TestWSConn_invalidClose(t *testing.T) {
+	tests := []struct {
+		code int
+		text string
+	}{
+		{greq.WSCloseNoStatus, ""},
+		{greq.WSCloseAbnormal, ""},
+		{1015, ""},
+		{999, ""},
+		{greq.WSCloseNormal, "bad \xff reason"},
+	}
+
+	for _, tt := range tests {
+		replied := make(chan error, 1)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := greq.UpgradeWebSocket(w, r)
+			if err != nil {
+				return
+			}
+			go func() {
+				_, _, err := conn.ReadMessage()
+				replied <- err
+			}()
+			_ = conn.CloseWithCode(tt.code, tt.text)
+		}))
+
+		conn, err := greq.New(ts.URL).WebSocket("/")
+		assert.NoErr(t, err)
+		_, _, err = conn.ReadMessage()
+		var cerr *greq.WSCloseError
+		assert.True(t, errors.As(err, &cerr), tt.code)
+		assert.Eq(t, greq.WSCloseProtocolError, cerr.Code, tt.code)
+
+		// the reply of the client is 1002
+		assert.True(t, errors.As(<-replied, &cerr), tt.code)
+		assert.Eq(t, greq.WSCloseProtocolError, cerr.Code, tt.code)
+		ts.Close()
+	}
+}
+
+func TestWSPingInterval(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := greq.UpgradeWebSocket(w, r)
+		if err != nil {
+			return
+		}
+		// not reply the ping
+		<-stop
+		_ = conn.CloseWithCode(greq.WSCloseNormal, "")
+	}))
+	defer ts.Close()
+
+	conn, err := greq.New(ts.URL).WebSocket("/", greq.WSPingInterval(20*time.Millisecond))
+	assert.NoErr(t, err)
+
+	start := time.Now()
+	_, _, err = conn.ReadMessage()
+	assert.Err(t, err)
+	assert.True(t, time.Since(start) < time.Second)
+}
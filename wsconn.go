@@ -0,0 +1,511 @@
+package greq
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// WebSocket message types, same as the frame opcodes.
+const (
+	WSText   = 1
+	WSBinary = 2
+)
+
+// frame opcodes
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+// WebSocket close codes. see RFC 6455 section 7.4.1
+const (
+	WSCloseNormal        = 1000
+	WSCloseGoingAway     = 1001
+	WSCloseProtocolError = 1002
+	WSCloseUnsupported   = 1003
+	WSCloseNoStatus      = 1005
+	WSCloseAbnormal      = 1006
+	WSCloseInvalidData   = 1007
+	WSClosePolicy        = 1008
+	WSCloseTooBig        = 1009
+	WSCloseInternalError = 1011
+)
+
+// ErrWSClosed write to the WebSocket connection after the close frame sent.
+var ErrWSClosed = errors.New("greq: websocket connection is closed")
+
+// WSCloseError the close frame received from the peer, or the connection closed by protocol error.
+type WSCloseError struct {
+	Code int
+	Text string
+}
+
+func (e *WSCloseError) Error() string {
+	if e.Text == "" {
+		return fmt.Sprintf("greq: websocket closed with code %d", e.Code)
+	}
+	return fmt.Sprintf("greq: websocket closed with code %d: %s", e.Code, e.Text)
+}
+
+// the tail of the deflate block, it's removed from the compressed message. see RFC 7692
+var deflateTail = []byte{0x00, 0x00, 0xff, 0xff}
+
+// WSConn is a WebSocket connection, create by Client.WebSocket or UpgradeWebSocket.
+//
+// Only one goroutine can read and multiple goroutines can write at the same time.
+// The ping and close frames from the peer are replied by ReadMessage, so keep reading the messages.
+type WSConn struct {
+	rwc    io.ReadWriteCloser
+	br     *bufio.Reader
+	server bool
+	// the handshake response of the client
+	resp        *Response
+	subprotocol string
+	// permessage-deflate is negotiated
+	compress     bool
+	maxMsgSize   int64
+	closeTimeout time.Duration
+
+	rmu     sync.Mutex
+	readErr error
+	// the last time received a frame, unix nano
+	lastRead atomic.Int64
+
+	wmu       sync.Mutex
+	closeSent bool
+
+	// closed on the read is ended, by the close frame or error
+	readDone     chan struct{}
+	readDoneOnce sync.Once
+	// closed on the connection closed
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+func newWSConn(rwc io.ReadWriteCloser, br *bufio.Reader, server bool, cfg *wsConfig) *WSConn {
+	if br == nil {
+		br = bufio.NewReader(rwc)
+	}
+
+	c := &WSConn{
+		rwc:          rwc,
+		br:           br,
+		server:       server,
+		maxMsgSize:   cfg.maxMsgSize,
+		closeTimeout: cfg.closeTimeout,
+		readDone:     make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	c.lastRead.Store(time.Now().UnixNano())
+	if cfg.pingInterval > 0 {
+		go c.keepalive(cfg.pingInterval)
+	}
+	return c
+}
+
+// Subprotocol get the negotiated subprotocol
+func (c *WSConn) Subprotocol() string { return c.subprotocol }
+
+// Compressed check the permessage-deflate extension is negotiated
+func (c *WSConn) Compressed() bool { return c.compress }
+
+// Response get the handshake response. nil on the server side.
+func (c *WSConn) Response() *Response { return c.resp }
+
+// ReadMessage read a message, returns the message type WSText or WSBinary.
+//
+// The ping frames are replied by pong automatically. It returns a *WSCloseError on the close
+// frame received, and the close frame is replied if not sent.
+func (c *WSConn) ReadMessage() (typ int, data []byte, err error) {
+	c.rmu.Lock()
+	defer c.rmu.Unlock()
+	return c.readMessage()
+}
+
+func (c *WSConn) readMessage() (int, []byte, error) {
+	if c.readErr != nil {
+		return 0, nil, c.readErr
+	}
+
+	var typ int
+	var compressed bool
+	var buf []byte
+	for {
+		fin, rsv1, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, c.failRead(err)
+		}
+
+		switch op {
+		case wsOpPing:
+			if err = c.writeFrame(wsOpPong, false, payload); err != nil && err != ErrWSClosed {
+				return 0, nil, c.failRead(err)
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			return 0, nil, c.failRead(c.onClose(payload))
+		case wsOpText, wsOpBinary:
+			if typ != 0 {
+				return 0, nil, c.failRead(&WSCloseError{Code: WSCloseProtocolError, Text: "expect a continuation frame"})
+			}
+			typ, compressed = int(op), rsv1
+		case wsOpContinuation:
+			if typ == 0 {
+				return 0, nil, c.failRead(&WSCloseError{Code: WSCloseProtocolError, Text: "unexpected continuation frame"})
+			}
+		}
+
+		buf = append(buf, payload...)
+		if c.maxMsgSize > 0 && int64(len(buf)) > c.maxMsgSize {
+			return 0, nil, c.failRead(&WSCloseError{Code: WSCloseTooBig, Text: "message too big"})
+		}
+		if fin {
+			break
+		}
+	}
+
+	if compressed {
+		var err error
+		if buf, err = c.decompress(buf); err != nil {
+			return 0, nil, c.failRead(err)
+		}
+	}
+	if typ == WSText && !utf8.Valid(buf) {
+		return 0, nil, c.failRead(&WSCloseError{Code: WSCloseInvalidData, Text: "invalid UTF-8 text"})
+	}
+	return typ, buf, nil
+}
+
+// readFrame read a frame and unmask the payload
+func (c *WSConn) readFrame() (fin, rsv1 bool, op byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.br, head[:]); err != nil {
+		return
+	}
+	c.lastRead.Store(time.Now().UnixNano())
+
+	fin, rsv1, op = head[0]&0x80 != 0, head[0]&0x40 != 0, head[0]&0x0f
+	masked := head[1]&0x80 != 0
+	size := uint64(head[1] & 0x7f)
+
+	switch {
+	case head[0]&0x30 != 0:
+		err = &WSCloseError{Code: WSCloseProtocolError, Text: "unexpected reserved bits"}
+	case rsv1 && (!c.compress || op == wsOpContinuation || op >= wsOpClose):
+		err = &WSCloseError{Code: WSCloseProtocolError, Text: "unexpected RSV1 bit"}
+	case op > wsOpBinary && op < wsOpClose || op > wsOpPong:
+		err = &WSCloseError{Code: WSCloseProtocolError, Text: fmt.Sprintf("unknown opcode %d", op)}
+	case op >= wsOpClose && (!fin || size > 125):
+		err = &WSCloseError{Code: WSCloseProtocolError, Text: "invalid control frame"}
+	case masked != c.server:
+		err = &WSCloseError{Code: WSCloseProtocolError, Text: "invalid frame mask"}
+	}
+	if err != nil {
+		return
+	}
+
+	switch size {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		size = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		size = binary.BigEndian.Uint64(ext[:])
+	}
+	if size > 1<<62 || c.maxMsgSize > 0 && size > uint64(c.maxMsgSize) {
+		err = &WSCloseError{Code: WSCloseTooBig, Text: "message too big"}
+		return
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+			return
+		}
+	}
+
+	payload = make([]byte, size)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	if masked {
+		maskBytes(mask, payload)
+	}
+	return
+}
+
+// onClose handle the close frame from peer, reply it if not sent.
+func (c *WSConn) onClose(payload []byte) *WSCloseError {
+	cerr := &WSCloseError{Code: WSCloseNoStatus}
+	if len(payload) == 1 {
+		return &WSCloseError{Code: WSCloseProtocolError, Text: "invalid close frame"}
+	}
+	if len(payload) >= 2 {
+		cerr.Code = int(binary.BigEndian.Uint16(payload))
+		cerr.Text = string(payload[2:])
+		if !validCloseCode(cerr.Code) {
+			return &WSCloseError{Code: WSCloseProtocolError, Text: fmt.Sprintf("invalid close code %d", cerr.Code)}
+		}
+		if !utf8.Valid(payload[2:]) {
+			return &WSCloseError{Code: WSCloseProtocolError, Text: "invalid UTF-8 close reason"}
+		}
+	}
+
+	reply := payload
+	if len(reply) > 2 {
+		reply = reply[:2]
+	}
+	_ = c.writeFrame(wsOpClose, false, reply)
+	c.setReadErr(cerr)
+	return cerr
+}
+
+// validCloseCode check the close code can be sent in the close frame. see RFC 6455 section 7.4
+func validCloseCode(code int) bool {
+	switch {
+	case code >= WSCloseNormal && code <= WSCloseUnsupported:
+		return true
+	case code >= WSCloseInvalidData && code <= 1014:
+		return true
+	case code >= 3000 && code <= 4999: // the registered and private codes
+		return true
+	}
+	return false
+}
+
+// failRead set the read error, and close the connection on the protocol error.
+func (c *WSConn) failRead(err error) error {
+	var cerr *WSCloseError
+	if errors.As(err, &cerr) && c.readErr == nil {
+		// the error of local, send the close frame
+		if cerr.Code != WSCloseNoStatus && cerr.Code >= WSCloseProtocolError {
+			_ = c.writeClose(cerr.Code, cerr.Text)
+		}
+		c.setReadErr(err)
+		c.shutdown()
+		return err
+	}
+
+	c.setReadErr(err)
+	return err
+}
+
+func (c *WSConn) setReadErr(err error) {
+	if c.readErr == nil {
+		c.readErr = err
+	}
+	c.readDoneOnce.Do(func() { close(c.readDone) })
+}
+
+// WriteMessage write a message, typ is WSText or WSBinary. It is safe for concurrent use.
+func (c *WSConn) WriteMessage(typ int, data []byte) error {
+	if typ != WSText && typ != WSBinary {
+		return fmt.Errorf("greq: invalid websocket message type %d", typ)
+	}
+
+	if c.compress {
+		var err error
+		if data, err = compressMessage(data); err != nil {
+			return err
+		}
+	}
+	return c.writeFrame(byte(typ), c.compress, data)
+}
+
+// WriteText write a text message
+func (c *WSConn) WriteText(text string) error { return c.WriteMessage(WSText, []byte(text)) }
+
+// Ping send a ping frame, data max length is 125.
+func (c *WSConn) Ping(data []byte) error {
+	if len(data) > 125 {
+		return errors.New("greq: websocket ping data is too long")
+	}
+	return c.writeFrame(wsOpPing, false, data)
+}
+
+func (c *WSConn) writeClose(code int, text string) error {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	if len(text) > 123 {
+		text = text[:123]
+	}
+	return c.writeFrame(wsOpClose, false, append(payload, text...))
+}
+
+// writeFrame write a frame, the payload is masked on the client side.
+func (c *WSConn) writeFrame(op byte, rsv1 bool, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closeSent {
+		return ErrWSClosed
+	}
+	if op == wsOpClose {
+		c.closeSent = true
+	}
+
+	b0 := 0x80 | op
+	if rsv1 {
+		b0 |= 0x40
+	}
+	frame := make([]byte, 0, len(payload)+14)
+	frame = append(frame, b0)
+
+	var maskBit byte
+	if !c.server {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xffff:
+		frame = binary.BigEndian.AppendUint16(append(frame, maskBit|126), uint16(n))
+	default:
+		frame = binary.BigEndian.AppendUint64(append(frame, maskBit|127), uint64(n))
+	}
+
+	if c.server {
+		frame = append(frame, payload...)
+	} else {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, payload...)
+		maskBytes(mask, frame[start:])
+	}
+
+	_, err := c.rwc.Write(frame)
+	return err
+}
+
+// Close the connection with normal close code. see CloseWithCode
+func (c *WSConn) Close() error { return c.CloseWithCode(WSCloseNormal, "") }
+
+// CloseWithCode do the close handshake: send the close frame, wait the close frame from peer, then close the connection.
+//
+// The close handshake is ended by the close timeout(default 5s), include the time to send the close frame.
+// If the messages are being read in another goroutine, it waits the ReadMessage returns the close error.
+func (c *WSConn) CloseWithCode(code int, text string) error {
+	// arm the timer first, the write may be blocked by another writer on the peer not reading
+	timer := time.AfterFunc(c.closeTimeout, c.shutdown)
+	defer timer.Stop()
+
+	err := c.writeClose(code, text)
+	if err == ErrWSClosed {
+		err = nil
+	}
+	if c.rmu.TryLock() {
+		for {
+			if _, _, rerr := c.readMessage(); rerr != nil {
+				break
+			}
+		}
+		c.rmu.Unlock()
+	} else {
+		select {
+		case <-c.readDone:
+		case <-c.done:
+		}
+	}
+
+	c.shutdown()
+	return err
+}
+
+// shutdown close the underlying connection
+func (c *WSConn) shutdown() {
+	c.doneOnce.Do(func() {
+		close(c.done)
+		if c.resp != nil && c.resp.Body != nil {
+			// close by the response body for the middlewares
+			_ = c.resp.Body.Close()
+		}
+		_ = c.rwc.Close()
+	})
+}
+
+// keepalive send ping frames in the interval, close the connection if no frame received in 2 intervals.
+func (c *WSConn) keepalive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if time.Since(time.Unix(0, c.lastRead.Load())) > 2*interval {
+				c.shutdown()
+				return
+			}
+			if err := c.Ping(nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func maskBytes(mask [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= mask[i&3]
+	}
+}
+
+// compressMessage compress the message by deflate, without context takeover.
+func compressMessage(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = fw.Write(data); err != nil {
+		return nil, err
+	}
+	if err = fw.Flush(); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), deflateTail), nil
+}
+
+func (c *WSConn) decompress(data []byte) ([]byte, error) {
+	// append the deflate tail and a final empty block
+	fr := flate.NewReader(io.MultiReader(bytes.NewReader(data), bytes.NewReader(deflateTail),
+		bytes.NewReader([]byte{0x01, 0x00, 0x00, 0xff, 0xff})))
+	defer fr.Close()
+
+	var r io.Reader = fr
+	if c.maxMsgSize > 0 {
+		r = io.LimitReader(fr, c.maxMsgSize+1)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		return nil, &WSCloseError{Code: WSCloseInvalidData, Text: "invalid compressed message"}
+	}
+	if c.maxMsgSize > 0 && int64(len(out)) > c.maxMsgSize {
+		return nil, &WSCloseError{Code: WSCloseTooBig, Text: "message too big"}
+	}
+	return out, nil
+}